@@ -11,6 +11,7 @@ import (
 	"github.com/EmilioCliff/jonche/internal/handlers"
 	"github.com/EmilioCliff/jonche/internal/postgres"
 	"github.com/EmilioCliff/jonche/internal/services"
+	"github.com/EmilioCliff/jonche/internal/sms"
 	"github.com/EmilioCliff/jonche/internal/workers"
 	"github.com/EmilioCliff/jonche/pkg"
 )
@@ -44,7 +45,12 @@ func main() {
 		DB:       0,
 	}
 
-	worker := workers.NewWorkerService(redisConfig, repo, config)
+	smsProvider, err := sms.NewProvider(config)
+	if err != nil {
+		panic(err)
+	}
+
+	worker := workers.NewWorkerService(redisConfig, repo, config, smsProvider)
 	err = worker.StartProcessor()
 	if err != nil {
 		panic(err)
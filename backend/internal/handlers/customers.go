@@ -310,3 +310,82 @@ func (s *Server) deleteCustomers(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, gin.H{"data": "success"})
 }
+
+type refundCustomerReq struct {
+	Amount      float64 `json:"amount"      binding:"required,gt=0"`
+	Method      string  `json:"method"      binding:"required"`
+	Reference   string  `json:"reference"   binding:"required"`
+	Description string  `json:"description"`
+}
+
+func (s *Server) refundCustomer(ctx *gin.Context) {
+	var req refundCustomerReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, err.Error())))
+
+		return
+	}
+
+	id, err := pkg.StringToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+
+		return
+	}
+
+	refund, err := s.repo.CustomerRepo.RefundCustomerCredit(ctx, &repository.Refund{
+		CustomerID:  id,
+		Amount:      req.Amount,
+		Method:      req.Method,
+		Reference:   req.Reference,
+		Description: req.Description,
+	})
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"data": refund})
+}
+
+func (s *Server) getCustomerRefunds(ctx *gin.Context) {
+	pageNoStr := ctx.DefaultQuery("page", "1")
+	pageNo, err := pkg.StringToUint32(pageNoStr)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, err.Error())))
+
+		return
+	}
+
+	pageSizeStr := ctx.DefaultQuery("limit", "10")
+	pageSize, err := pkg.StringToUint32(pageSizeStr)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, err.Error())))
+
+		return
+	}
+
+	id, err := pkg.StringToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+
+		return
+	}
+
+	refunds, metadata, err := s.repo.CustomerRepo.ListCustomerRefunds(
+		ctx,
+		id,
+		&pkg.PaginationMetadata{CurrentPage: pageNo, PageSize: pageSize},
+	)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"metadata": metadata,
+		"data":     refunds,
+	})
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/EmilioCliff/jonche/pkg"
+	"github.com/gin-gonic/gin"
+)
+
+// proxies close connections that stay quiet for long, a comment line every so
+// often keeps the stream open
+const eventStreamKeepAlive = 15 * time.Second
+
+// streamEvents sends the payment, loan and sms events as server-sent events
+// until the client goes away or its token expires. The types query parameter
+// takes a comma separated list of the event types to send.
+func (s *Server) streamEvents(ctx *gin.Context) {
+	var types []string
+	if typesStr := ctx.Query("types"); typesStr != "" {
+		for _, eventType := range strings.Split(typesStr, ",") {
+			if eventType = strings.TrimSpace(eventType); eventType != "" {
+				types = append(types, eventType)
+			}
+		}
+	}
+
+	var expired <-chan time.Time
+	if payload, ok := ctx.Get(authorizationPayloadKey); ok {
+		if claims, ok := payload.(*pkg.Payload); ok && claims.ExpiresAt != nil {
+			timer := time.NewTimer(time.Until(claims.ExpiresAt.Time))
+			defer timer.Stop()
+			expired = timer.C
+		}
+	}
+
+	// the server's write timeout would cut the stream off
+	if err := http.NewResponseController(ctx.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(pkg.Errorf(
+			pkg.INTERNAL_ERROR,
+			"streaming not supported: %s",
+			err.Error(),
+		)))
+
+		return
+	}
+
+	events, unsubscribe := s.taskDistributor.SubscribeEvents()
+	defer unsubscribe()
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+	ctx.Writer.Flush()
+
+	keepAlive := time.NewTicker(eventStreamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case <-expired:
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(ctx.Writer, ": ping\n\n"); err != nil {
+				return
+			}
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+
+			if len(types) > 0 && !slices.Contains(types, event.Type) {
+				continue
+			}
+
+			if _, err := fmt.Fprintf(
+				ctx.Writer,
+				"id: %s\nevent: %s\ndata: %s\n\n",
+				event.ID,
+				event.Type,
+				event.Payload,
+			); err != nil {
+				return
+			}
+		}
+		ctx.Writer.Flush()
+	}
+}
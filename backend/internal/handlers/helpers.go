@@ -33,7 +33,14 @@ func (s *Server) getDashboardStats(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"stats": stats, "overview": overview})
+	balances, err := s.repo.SMSBalanceRepo.ListSMSProviderBalances(ctx)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"stats": stats, "overview": overview, "sms_balances": balances})
 }
 
 func (s *Server) getDashboardOverview(ctx *gin.Context) {
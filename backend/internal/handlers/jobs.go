@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/EmilioCliff/jonche/pkg"
+	"github.com/gin-gonic/gin"
+)
+
+func (s *Server) listJobs(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"data": s.taskDistributor.ListJobs()})
+}
+
+// triggerJob runs a scheduled job now, on top of its schedule.
+func (s *Server) triggerJob(ctx *gin.Context) {
+	taskID, err := s.taskDistributor.TriggerJob(ctx, ctx.Param("name"))
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": gin.H{"task_id": taskID}})
+}
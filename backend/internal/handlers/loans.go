@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/EmilioCliff/jonche/internal/repository"
 	"github.com/EmilioCliff/jonche/pkg"
@@ -12,6 +13,7 @@ type createLoanReq struct {
 	CustomerID  uint32  `json:"customer_id" binding:"required"`
 	Description string  `json:"description" binding:"required"`
 	Amount      float64 `json:"amount"      binding:"required"`
+	DueDate     string  `json:"due_date"`
 }
 
 func (s *Server) createLoan(ctx *gin.Context) {
@@ -25,6 +27,7 @@ func (s *Server) createLoan(ctx *gin.Context) {
 		CustomerID:  req.CustomerID,
 		Description: req.Description,
 		Amount:      req.Amount,
+		DueDate:     req.DueDate,
 	})
 	if err != nil {
 		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
@@ -99,3 +102,49 @@ func (s *Server) deleteLoan(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, gin.H{"data": "success"})
 }
+
+type updateLoanDueDateReq struct {
+	DueDate string `json:"due_date"`
+}
+
+// updateLoanDueDate sets the date a loan should be repaid by, in YYYY-MM-DD. An
+// empty due_date clears it and stops reminders for the loan.
+func (s *Server) updateLoanDueDate(ctx *gin.Context) {
+	var req updateLoanDueDateReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, err.Error())))
+
+		return
+	}
+
+	id, err := pkg.StringToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, err.Error())))
+
+		return
+	}
+
+	var dueDate *time.Time
+	if req.DueDate != "" {
+		date, err := time.Parse(time.DateOnly, req.DueDate)
+		if err != nil {
+			ctx.JSON(
+				http.StatusBadRequest,
+				errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid due_date, use YYYY-MM-DD")),
+			)
+
+			return
+		}
+
+		dueDate = &date
+	}
+
+	loan, err := s.repo.LoanRepo.UpdateLoanDueDate(ctx, id, dueDate)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": loan})
+}
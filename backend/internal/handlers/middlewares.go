@@ -2,8 +2,11 @@ package handlers
 
 import (
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/EmilioCliff/jonche/internal/metrics"
 	"github.com/EmilioCliff/jonche/pkg"
 	"github.com/gin-gonic/gin"
 )
@@ -68,6 +71,37 @@ func authMiddleware(maker pkg.JWTMaker) gin.HandlerFunc {
 	}
 }
 
+// queryTokenMiddleware lets a request carry its token in the access_token query
+// parameter, the browser's EventSource cannot set the Authorization header.
+func queryTokenMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		token := ctx.Query("access_token")
+		if token != "" && ctx.GetHeader(authorizationHeaderKey) == "" {
+			ctx.Request.Header.Set(authorizationHeaderKey, "Bearer "+token)
+		}
+
+		ctx.Next()
+	}
+}
+
+// metricsMiddleware counts and times requests by the route they matched, so ids
+// in the path do not make a series each.
+func metricsMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+
+		route := ctx.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		method := ctx.Request.Method
+
+		metrics.HTTPRequestDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+		metrics.HTTPRequests.WithLabelValues(method, route, strconv.Itoa(ctx.Writer.Status())).Inc()
+	}
+}
+
 func CORSmiddleware() gin.HandlerFunc {
 	allowedOrigins := []string{
 		"http://localhost:5173",
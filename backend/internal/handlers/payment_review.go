@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/EmilioCliff/jonche/internal/repository"
+	"github.com/EmilioCliff/jonche/pkg"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	maxPaymentSuggestions  = 3
+	minPaymentSuggestScore = 0.4
+)
+
+type paymentSuggestion struct {
+	CustomerID  uint32  `json:"customer_id"`
+	Name        string  `json:"name"`
+	PhoneNumber string  `json:"phone_number"`
+	Score       float64 `json:"score"`
+}
+
+type unassignedPayment struct {
+	*repository.Payment
+	AgeDays     int                 `json:"age_days"`
+	Suggestions []paymentSuggestion `json:"suggestions"`
+}
+
+// listUnassignedPayments returns the payments waiting to be matched, oldest first,
+// each with the customers it most likely belongs to.
+func (s *Server) listUnassignedPayments(ctx *gin.Context) {
+	pageNoStr := ctx.DefaultQuery("page", "1")
+	pageNo, err := pkg.StringToUint32(pageNoStr)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, err.Error())))
+
+		return
+	}
+
+	pageSizeStr := ctx.DefaultQuery("limit", "10")
+	pageSize, err := pkg.StringToUint32(pageSizeStr)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, err.Error())))
+
+		return
+	}
+
+	payments, metadata, err := s.repo.PaymentRepo.ListUnassignedPayments(
+		ctx,
+		&pkg.PaginationMetadata{
+			CurrentPage: pageNo,
+			PageSize:    pageSize,
+		},
+	)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	customers, err := s.repo.CustomerRepo.GetCustomerList(ctx)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	queue := make([]unassignedPayment, len(payments))
+	for i, payment := range payments {
+		queue[i] = unassignedPayment{
+			Payment:     payment,
+			AgeDays:     int(time.Since(payment.PaidAt).Hours() / 24),
+			Suggestions: suggestPaymentCustomers(payment, customers),
+		}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": queue, "metadata": metadata})
+}
+
+// suggestPaymentCustomers ranks customers by how well their name and phone number
+// match the payer. The phone only counts when the payment came with one.
+func suggestPaymentCustomers(
+	payment *repository.Payment,
+	customers []*repository.Customer,
+) []paymentSuggestion {
+	suggestions := []paymentSuggestion{}
+	for _, customer := range customers {
+		score := pkg.NameSimilarity(payment.PayingName, customer.Name)
+		if payment.PayingPhone != "" {
+			score = (score + pkg.PhoneSimilarity(payment.PayingPhone, customer.PhoneNumber)) / 2
+		}
+		if score < minPaymentSuggestScore {
+			continue
+		}
+
+		suggestions = append(suggestions, paymentSuggestion{
+			CustomerID:  customer.ID,
+			Name:        customer.Name,
+			PhoneNumber: customer.PhoneNumber,
+			Score:       score,
+		})
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].Score > suggestions[j].Score
+	})
+	if len(suggestions) > maxPaymentSuggestions {
+		suggestions = suggestions[:maxPaymentSuggestions]
+	}
+
+	return suggestions
+}
+
+type bulkAssignPaymentsReq struct {
+	Assignments []struct {
+		PaymentID  uint32 `json:"payment_id"  binding:"required"`
+		CustomerID uint32 `json:"customer_id" binding:"required"`
+	} `json:"assignments" binding:"required,min=1,dive"`
+}
+
+type bulkAssignResult struct {
+	PaymentID  uint32 `json:"payment_id"`
+	CustomerID uint32 `json:"customer_id"`
+	Assigned   bool   `json:"assigned"`
+	Error      string `json:"error,omitempty"`
+}
+
+// bulkAssignPayments assigns several queued payments at once. Each assignment runs
+// on its own so one bad row does not hold back the rest.
+func (s *Server) bulkAssignPayments(ctx *gin.Context) {
+	var req bulkAssignPaymentsReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, err.Error())))
+
+		return
+	}
+
+	assigned := 0
+	results := make([]bulkAssignResult, len(req.Assignments))
+	for i, assignment := range req.Assignments {
+		results[i] = bulkAssignResult{
+			PaymentID:  assignment.PaymentID,
+			CustomerID: assignment.CustomerID,
+		}
+
+		if err := s.repo.PaymentRepo.AssignPayment(
+			ctx,
+			assignment.PaymentID,
+			assignment.CustomerID,
+		); err != nil {
+			results[i].Error = pkg.ErrorMessage(err)
+
+			continue
+		}
+
+		results[i].Assigned = true
+		assigned++
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"assigned": assigned,
+		"failed":   len(results) - assigned,
+		"results":  results,
+	}})
+}
+
+type ignorePaymentReq struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+func (s *Server) ignorePayment(ctx *gin.Context) {
+	var req ignorePaymentReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, err.Error())))
+
+		return
+	}
+
+	id, err := pkg.StringToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, err.Error())))
+
+		return
+	}
+
+	payment, err := s.repo.PaymentRepo.IgnorePayment(ctx, id, req.Reason)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": payment})
+}
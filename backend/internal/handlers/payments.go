@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"strconv"
@@ -38,41 +39,33 @@ func (s *Server) paymentCallback(ctx *gin.Context) {
 
 	callbackData := &repository.Payment{
 		TransactionNumber: req["TransID"].(string),
-		TransactionSource: "MPESA",
+		TransactionSource: mpesaTransactionSource,
 		PayingName:        req["FirstName"].(string),
 		Amount:            amountFlt,
 		PaidAt:            time.Now(),
 		Assigned:          false,
 	}
+	// newer callbacks send a masked or hashed MSISDN, keep whatever is given
+	if msisdn, ok := req["MSISDN"].(string); ok {
+		callbackData.PayingPhone = msisdn
+	}
 
-	id, err := s.repo.CustomerRepo.GetCustomerIDByName(
-		ctx,
-		strings.ToUpper(callbackData.PayingName),
-	)
-	if err != nil {
-		// log the payment to db
-
-		if pkg.ErrorCode(err) != pkg.NOT_FOUND_ERROR {
-			log.Println(err)
-			ctx.JSON(http.StatusOK, gin.H{
-				"ResultCode": 400,
-				"ResultDesc": "Rejected",
-			})
+	if err := s.matchPaymentCustomer(ctx, callbackData); err != nil {
+		log.Println(err)
+		ctx.JSON(http.StatusOK, gin.H{
+			"ResultCode": 400,
+			"ResultDesc": "Rejected",
+		})
 
-			return
-		}
-	}
-	if id != 0 {
-		callbackData.AssignedTo = id
-		callbackData.Assigned = true
+		return
 	}
 
+	// a repeated callback or an imported statement line already recorded it
 	_, err = s.repo.PaymentRepo.CreatePayment(
 		ctx,
 		callbackData,
-		s.taskDistributor.DistributeTaskSendSMS,
 	)
-	if err != nil {
+	if err != nil && pkg.ErrorCode(err) != pkg.ALREADY_EXISTS_ERROR {
 		log.Println(err)
 		ctx.JSON(http.StatusOK, gin.H{
 			"ResultCode": 400,
@@ -88,6 +81,24 @@ func (s *Server) paymentCallback(ctx *gin.Context) {
 	})
 }
 
+// matchPaymentCustomer assigns the payment to the customer whose name matches the
+// paying name. Payments with no matching customer are left unassigned.
+func (s *Server) matchPaymentCustomer(ctx context.Context, payment *repository.Payment) error {
+	id, err := s.repo.CustomerRepo.GetCustomerIDByName(ctx, strings.ToUpper(payment.PayingName))
+	if err != nil {
+		if pkg.ErrorCode(err) == pkg.NOT_FOUND_ERROR {
+			return nil
+		}
+
+		return err
+	}
+
+	payment.AssignedTo = id
+	payment.Assigned = true
+
+	return nil
+}
+
 type createPaymentReq struct {
 	TransactionNumber string    `json:"transaction_number" binding:"required"`
 	TransactionSource string    `json:"transaction_source" binding:"required"`
@@ -123,7 +134,6 @@ func (s *Server) createPayment(ctx *gin.Context) {
 	payment, err := s.repo.PaymentRepo.CreatePayment(
 		ctx,
 		payment,
-		s.taskDistributor.DistributeTaskSendSMS,
 	)
 	if err != nil {
 		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
@@ -229,7 +239,7 @@ func (s *Server) assignPayment(ctx *gin.Context) {
 		return
 	}
 
-	if err := s.repo.PaymentRepo.AssignPayment(ctx, id, loanID, s.taskDistributor.DistributeTaskSendSMS); err != nil {
+	if err := s.repo.PaymentRepo.AssignPayment(ctx, id, loanID); err != nil {
 		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
 
 		return
@@ -237,3 +247,32 @@ func (s *Server) assignPayment(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, gin.H{"data": "success"})
 }
+
+type reversePaymentReq struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+func (s *Server) reversePayment(ctx *gin.Context) {
+	var req reversePaymentReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, err.Error())))
+
+		return
+	}
+
+	id, err := pkg.StringToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, err.Error())))
+
+		return
+	}
+
+	reversal, err := s.repo.PaymentRepo.ReversePayment(ctx, id, req.Reason)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"data": reversal})
+}
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/EmilioCliff/jonche/internal/repository"
+	"github.com/EmilioCliff/jonche/pkg"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	mpesaTransactionSource = "MPESA"
+	bankTransactionSource  = "BANK"
+
+	// how far a callback's stored time may be from the statement's time
+	callbackSkew = time.Hour
+)
+
+type reconciliationMismatch struct {
+	Line    pkg.StatementLine   `json:"line"`
+	Payment *repository.Payment `json:"payment"`
+}
+
+type reconciliationReport struct {
+	From           time.Time                `json:"from"`
+	To             time.Time                `json:"to"`
+	StatementLines int                      `json:"statement_lines"`
+	StatementTotal float64                  `json:"statement_total"`
+	Matched        int                      `json:"matched"`
+	Missing        []pkg.StatementLine      `json:"missing"`
+	Reversed       []reconciliationMismatch `json:"reversed"`
+	Extra          []*repository.Payment    `json:"extra"`
+	Mismatched     []reconciliationMismatch `json:"mismatched"`
+}
+
+// reconcileMpesaStatement compares an M-Pesa paybill statement export (csv or xlsx)
+// with the stored payments by receipt number and amount.
+func (s *Server) reconcileMpesaStatement(ctx *gin.Context) {
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, err.Error())))
+
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		ctx.JSON(
+			http.StatusInternalServerError,
+			errorResponse(pkg.Errorf(pkg.INTERNAL_ERROR, "failed to open file: %s", err.Error())),
+		)
+
+		return
+	}
+	defer file.Close()
+
+	var rows [][]string
+	switch strings.ToLower(filepath.Ext(fileHeader.Filename)) {
+	case ".xlsx":
+		rows, err = pkg.ReadXLSXRows(file, fileHeader.Size)
+	case ".csv":
+		rows, err = pkg.ReadCSVRows(file)
+	default:
+		err = pkg.Errorf(pkg.INVALID_ERROR, "statement must be a csv or xlsx export")
+	}
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	lines, err := pkg.ParseMpesaStatement(rows)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	report := reconciliationReport{
+		StatementLines: len(lines),
+		Missing:        []pkg.StatementLine{},
+		Reversed:       []reconciliationMismatch{},
+		Extra:          []*repository.Payment{},
+		Mismatched:     []reconciliationMismatch{},
+	}
+	if len(lines) == 0 {
+		ctx.JSON(http.StatusOK, gin.H{"data": report})
+
+		return
+	}
+
+	numbers := make([]string, len(lines))
+	inStatement := make(map[string]bool, len(lines))
+	report.From, report.To = lines[0].Date, lines[0].Date
+	for i, line := range lines {
+		numbers[i] = line.Reference
+		inStatement[line.Reference] = true
+		report.StatementTotal += line.Amount
+
+		if line.Date.Before(report.From) {
+			report.From = line.Date
+		}
+		if line.Date.After(report.To) {
+			report.To = line.Date
+		}
+	}
+
+	stored, err := s.repo.PaymentRepo.ListPaymentsByTransactionNumbers(
+		ctx,
+		mpesaTransactionSource,
+		numbers,
+	)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	payments := make(map[string]*repository.Payment, len(stored))
+	for _, payment := range stored {
+		payments[payment.TransactionNumber] = payment
+	}
+
+	for _, line := range lines {
+		payment, ok := payments[line.Reference]
+		switch {
+		case !ok:
+			report.Missing = append(report.Missing, line)
+		case payment.Reversed:
+			// received and then reversed here, importing it again would be refused
+			report.Reversed = append(report.Reversed, reconciliationMismatch{
+				Line:    line,
+				Payment: payment,
+			})
+		case math.Abs(payment.Amount-line.Amount) > 0.005:
+			report.Mismatched = append(report.Mismatched, reconciliationMismatch{
+				Line:    line,
+				Payment: payment,
+			})
+		default:
+			report.Matched++
+		}
+	}
+
+	// callbacks are stamped when they arrive, not with the statement's time
+	sourcePayments, err := s.repo.PaymentRepo.ListSourcePayments(
+		ctx,
+		mpesaTransactionSource,
+		report.From.Add(-callbackSkew),
+		report.To.Add(callbackSkew),
+	)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	for _, payment := range sourcePayments {
+		// reversed payments are not money held
+		if payment.Reversed {
+			continue
+		}
+
+		if !inStatement[payment.TransactionNumber] {
+			report.Extra = append(report.Extra, payment)
+		}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": report})
+}
+
+type importMissingPaymentsReq struct {
+	Lines []pkg.StatementLine `json:"lines" binding:"required"`
+}
+
+// importMissingPayments records the missing lines of a reconciliation report as
+// M-Pesa payments.
+func (s *Server) importMissingPayments(ctx *gin.Context) {
+	var req importMissingPaymentsReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, err.Error())))
+
+		return
+	}
+
+	report := s.importStatementLines(ctx, req.Lines, mpesaTransactionSource)
+
+	ctx.JSON(http.StatusOK, gin.H{"data": report})
+}
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/EmilioCliff/jonche/internal/metrics"
 	"github.com/EmilioCliff/jonche/internal/postgres"
 	"github.com/EmilioCliff/jonche/internal/services"
 	"github.com/EmilioCliff/jonche/pkg"
@@ -54,11 +55,12 @@ func NewServer(
 }
 
 func (s *Server) setUpRoutes() {
-	s.router.Use(CORSmiddleware())
+	s.router.Use(metricsMiddleware(), CORSmiddleware())
 	v1 := s.router.Group("/api/v1")
 
 	// health check
 	s.router.GET("/health-check", s.healthCheckHandler)
+	s.router.GET("/metrics", gin.WrapH(metrics.Handler()))
 
 	// user routes
 	v1.POST("/user", s.createUser)
@@ -78,29 +80,87 @@ func (s *Server) setUpRoutes() {
 	v1.GET("/customer/loans/:id", s.getCustomerLoans)
 	v1.GET("/customer/payments/:id", s.getCustomerPaymens)
 	v1.GET("/customer/sms/:id", s.getCustomerSms)
+	v1.GET("/customer/sms-consent/:id", s.getCustomerSMSConsents)
+	v1.PATCH("/customer/sms-consent/:id", s.updateCustomerSMSConsent)
+	v1.GET("/customer/refunds/:id", s.getCustomerRefunds)
+	v1.POST("/customer/refund/:id", s.refundCustomer)
 	v1.PATCH("/customer/:id", s.updateCustomerDetails)
 	v1.DELETE("/customer/:id", s.deleteCustomers)
 
 	// payments routes
 	v1.POST("/payment/callback", s.paymentCallback)
 	v1.POST("/payment", s.createPayment)
+	v1.POST("/payment/import", s.importStatement)
+	v1.POST("/payment/reconcile", s.reconcileMpesaStatement)
+	v1.POST("/payment/reconcile/import", s.importMissingPayments)
 	v1.GET("/payments", s.listPayments)
+	v1.GET("/payments/unassigned", s.listUnassignedPayments)
+	v1.POST("/payments/unassigned/assign", s.bulkAssignPayments)
 	v1.GET("/payment/:id", s.getPayment)
 	v1.PATCH("/payment/:id", s.assignPayment)
+	v1.POST("/payment/reverse/:id", s.reversePayment)
+	v1.POST("/payment/ignore/:id", s.ignorePayment)
 
 	// loan routes
 	v1.POST("/loan", s.createLoan)
 	v1.GET("/loans", s.listLoans)
 	v1.GET("/loan/:id", s.getLoan)
+	v1.PATCH("/loan/:id", s.updateLoanDueDate)
 	v1.DELETE("/loan/:id", s.deleteLoan)
+	v1.GET("/loan/reminders", s.listLoanReminders)
 
 	// sms routes
 	v1.POST("/sms/callback", s.smsCallback)
+	v1.POST("/sms/callback/:provider", s.smsCallback)
+	v1.POST("/sms/inbound", s.smsInbound)
+	v1.GET("/sms/inbound", s.listInboundSMS)
+	v1.GET("/sms/opt-outs", s.listOptedOutCustomers)
+	v1.GET("/sms/balance", s.listSMSBalances)
+	v1.POST("/sms/balance/release", s.releaseSMSQueues)
 	v1.POST("/sms", s.createSMS)
+	v1.POST("/sms/preview", s.previewSMS)
 	v1.GET("/sms", s.listSMS)
+	v1.POST("/sms/template", s.createSMSTemplate)
+	v1.GET("/sms/templates", s.listSMSTemplates)
+	v1.GET("/sms/template/placeholders", s.listSMSTemplatePlaceholders)
+	v1.POST("/sms/template/preview", s.previewSMSTemplate)
+	v1.GET("/sms/template/:id", s.getSMSTemplate)
+	v1.PATCH("/sms/template/:id", s.updateSMSTemplate)
+	v1.DELETE("/sms/template/:id", s.deleteSMSTemplate)
+	v1.POST("/sms/campaign", s.createSMSCampaign)
+	v1.GET("/sms/campaigns", s.listSMSCampaigns)
+	v1.GET("/sms/campaign/:id", s.getSMSCampaign)
+	v1.POST("/sms/campaign/pause/:id", s.pauseSMSCampaign)
+	v1.POST("/sms/campaign/resume/:id", s.resumeSMSCampaign)
+	v1.POST("/sms/campaign/cancel/:id", s.cancelSMSCampaign)
+	v1.GET("/sms/history/:id", s.listSMSStatusHistory)
 	v1.GET("/sms/:id", s.getSMS)
 	v1.PATCH("/sms/:id", s.deliverSMS)
 
+	// task queue routes
+	v1.GET("/tasks/queues", s.listTaskQueues)
+	v1.GET("/tasks/dead", s.listDeadTasks)
+	v1.POST("/tasks/dead/retry/:queue/:id", s.retryDeadTask)
+	v1.POST("/tasks/dead/replay/:queue", s.replayDeadTasks)
+	v1.DELETE("/tasks/dead/:queue/:id", s.deleteDeadTask)
+
+	// scheduled job routes
+	v1.GET("/jobs", s.listJobs)
+	v1.POST("/jobs/trigger/:name", s.triggerJob)
+
+	// webhook routes
+	v1.POST("/webhook", s.createWebhook)
+	v1.GET("/webhooks", s.listWebhooks)
+	v1.GET("/webhook/events", s.listWebhookEvents)
+	v1.GET("/webhook/deliveries/:id", s.listWebhookDeliveries)
+	v1.POST("/webhook/delivery/redeliver/:id", s.redeliverWebhook)
+	v1.GET("/webhook/:id", s.getWebhook)
+	v1.PATCH("/webhook/:id", s.updateWebhook)
+	v1.DELETE("/webhook/:id", s.deleteWebhook)
+
+	// live event routes
+	v1.GET("/events", queryTokenMiddleware(), authMiddleware(s.tokenMaker), s.streamEvents)
+
 	// helper routes
 	v1.GET("/helper/customer", s.getCustomerList)
 	v1.GET("/dashboard/stats", s.getDashboardStats)
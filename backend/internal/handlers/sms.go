@@ -1,17 +1,26 @@
 package handlers
 
 import (
+	"math"
 	"net/http"
 
 	"github.com/EmilioCliff/jonche/internal/repository"
+	"github.com/EmilioCliff/jonche/internal/services"
+	"github.com/EmilioCliff/jonche/internal/sms"
 	"github.com/EmilioCliff/jonche/pkg"
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 )
 
+// smsCallback takes delivery reports. Tiara posts to /sms/callback, other gateways
+// to /sms/callback/:provider. Gateways only need to know the report was received.
 func (s *Server) smsCallback(ctx *gin.Context) {
-	var rq any
-	if err := ctx.ShouldBindJSON(&rq); err != nil {
+	provider := ctx.Param("provider")
+	if provider == "" {
+		provider = services.SMSProviderTiara
+	}
+
+	report, err := sms.ParseDeliveryReport(provider, ctx.Request)
+	if err != nil {
 		ctx.JSON(http.StatusOK, gin.H{
 			"ResultCode": 400,
 			"ResultDesc": "Rejected",
@@ -20,20 +29,14 @@ func (s *Server) smsCallback(ctx *gin.Context) {
 		return
 	}
 
-	req, _ := rq.(map[string]interface{})
-
-	st := req["status"].(string)
-
-	params := &repository.UpdateSMS{
-		RefID:          uuid.MustParse(req["refId"].(string)),
-		CallbackStatus: &st,
-	}
-	if st == "DeliveredToTerminal" || st == "DELIVRD" {
-		delivered := "delivered"
-		params.DeliveryStatus = &delivered
-	}
-
-	if err := s.repo.SMSRepo.UpdateSMS(ctx, params); err != nil {
+	if _, err := s.repo.SMSRepo.RecordDeliveryReport(
+		ctx,
+		report,
+		repository.SMSResendPolicy{
+			MaxResends: s.config.SMS_RESEND_MAX,
+			Delay:      s.config.SMS_RESEND_DELAY,
+		},
+	); err != nil {
 		ctx.JSON(http.StatusOK, gin.H{
 			"ResultCode": 500,
 			"ResultDesc": "Rejected",
@@ -50,7 +53,9 @@ func (s *Server) smsCallback(ctx *gin.Context) {
 
 type createSMSReq struct {
 	CustomerIDs []uint32 `json:"customer_ids" binding:"required"`
-	Message     string   `json:"message"      binding:"required"`
+	Message     string   `json:"message"`
+	TemplateID  uint32   `json:"template_id"`
+	Category    string   `json:"category"     binding:"omitempty,oneof=transactional marketing"`
 }
 
 func (s *Server) createSMS(ctx *gin.Context) {
@@ -61,13 +66,120 @@ func (s *Server) createSMS(ctx *gin.Context) {
 		return
 	}
 
-	if err := s.repo.SMSRepo.CreateSMS(ctx, &repository.SMS{Message: req.Message}, req.CustomerIDs, s.taskDistributor.DistributeTaskSendSMS); err != nil {
+	if err := s.resolveSMSReq(ctx, &req); err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	// estimated before enqueuing so staff see what a bulk send cost
+	recipients, err := s.repo.SMSRepo.RenderSMS(ctx, req.Message, req.CustomerIDs, req.Category)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+	estimate := s.estimateSMS(recipients)
+
+	skipped, err := s.repo.SMSRepo.CreateSMS(ctx, &repository.SMS{Message: req.Message}, req.CustomerIDs, req.Category)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	if len(skipped) > 0 {
+		ctx.JSON(http.StatusOK, gin.H{
+			"data":     "SMS schedule for sending successfully",
+			"skipped":  skipped,
+			"estimate": estimate,
+		})
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": "SMS schedule for sending successfully", "estimate": estimate})
+}
+
+// previewSMS is a dry run of createSMS, nothing is saved or sent.
+func (s *Server) previewSMS(ctx *gin.Context) {
+	var req createSMSReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, err.Error())))
+
+		return
+	}
+
+	if err := s.resolveSMSReq(ctx, &req); err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	recipients, err := s.repo.SMSRepo.RenderSMS(ctx, req.Message, req.CustomerIDs, req.Category)
+	if err != nil {
 		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
 
 		return
 	}
+	estimate := s.estimateSMS(recipients)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"recipients":     recipients,
+			"total_segments": estimate.Segments,
+			"total_cost":     estimate.Cost,
+		},
+	})
+}
+
+// resolveSMSReq loads the template body and defaults the category.
+func (s *Server) resolveSMSReq(ctx *gin.Context, req *createSMSReq) error {
+	if req.TemplateID != 0 {
+		template, err := s.repo.SMSTemplateRepo.GetSMSTemplate(ctx, req.TemplateID)
+		if err != nil {
+			return err
+		}
+
+		req.Message = template.Body
+	}
+	if req.Message == "" {
+		return pkg.Errorf(pkg.INVALID_ERROR, "message or template_id is required")
+	}
+
+	// staff messages are treated as marketing unless marked transactional
+	if req.Category == "" {
+		req.Category = repository.SMSCategoryMarketing
+	}
+
+	return nil
+}
 
-	ctx.JSON(http.StatusOK, gin.H{"data": "SMS schedule for sending successfully"})
+type smsEstimate struct {
+	Recipients int     `json:"recipients"`
+	Segments   int     `json:"segments"`
+	Cost       float64 `json:"cost"`
+}
+
+// estimateSMS sets the encoding, segments and cost on every recipient that will
+// receive the message and totals them.
+func (s *Server) estimateSMS(recipients []*repository.SMSRecipient) smsEstimate {
+	var total smsEstimate
+	for _, recipient := range recipients {
+		if recipient.OptedOut {
+			continue
+		}
+
+		estimate := pkg.EstimateSMS(recipient.Message, s.config.SMS_COST_PER_SEGMENT)
+		recipient.Estimate = &estimate
+
+		total.Recipients++
+		total.Segments += estimate.Segments
+		total.Cost += estimate.Cost
+	}
+	total.Cost = math.Round(total.Cost*10000) / 10000
+
+	return total
 }
 
 func (s *Server) listSMS(ctx *gin.Context) {
@@ -134,3 +246,21 @@ func (s *Server) deliverSMS(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, gin.H{"data": "success"})
 }
+
+func (s *Server) listSMSStatusHistory(ctx *gin.Context) {
+	id, err := pkg.StringToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, err.Error())))
+
+		return
+	}
+
+	history, err := s.repo.SMSRepo.ListSMSStatusHistory(ctx, id)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": history})
+}
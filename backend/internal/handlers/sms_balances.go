@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/EmilioCliff/jonche/pkg"
+	"github.com/gin-gonic/gin"
+)
+
+func (s *Server) listSMSBalances(ctx *gin.Context) {
+	balances, err := s.repo.SMSBalanceRepo.ListSMSProviderBalances(ctx)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":      balances,
+		"threshold": s.config.SMS_BALANCE_THRESHOLD,
+	})
+}
+
+// releaseSMSQueues resumes queues held for lack of credit once the account has
+// been topped up, without waiting for a critical message to report the balance.
+func (s *Server) releaseSMSQueues(ctx *gin.Context) {
+	s.taskDistributor.ReleaseHeldQueues()
+
+	ctx.JSON(http.StatusOK, gin.H{"data": "SMS queues released"})
+}
@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/EmilioCliff/jonche/internal/repository"
+	"github.com/EmilioCliff/jonche/internal/services"
+	"github.com/EmilioCliff/jonche/internal/workers"
+	"github.com/EmilioCliff/jonche/pkg"
+	"github.com/gin-gonic/gin"
+	"github.com/hibiken/asynq"
+	"github.com/robfig/cron/v3"
+)
+
+type createSMSCampaignReq struct {
+	Name       string     `json:"name"        binding:"required"`
+	Message    string     `json:"message"`
+	TemplateID uint32     `json:"template_id"`
+	MinBalance *float64   `json:"min_balance"`
+	SendAt     *time.Time `json:"send_at"`
+	CronSpec   string     `json:"cron_spec"`
+}
+
+// createSMSCampaign schedules a message for every active customer, optionally only
+// those owing more than min_balance. A campaign either runs once at send_at or
+// repeats on cron_spec, e.g. "0 9 * * MON" or "CRON_TZ=Africa/Nairobi 0 9 * * MON".
+func (s *Server) createSMSCampaign(ctx *gin.Context) {
+	var req createSMSCampaignReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, err.Error())))
+
+		return
+	}
+
+	if (req.Message == "") == (req.TemplateID == 0) {
+		ctx.JSON(
+			http.StatusBadRequest,
+			errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "one of message or template_id is required")),
+		)
+
+		return
+	}
+
+	if req.TemplateID != 0 {
+		if _, err := s.repo.SMSTemplateRepo.GetSMSTemplate(ctx, req.TemplateID); err != nil {
+			ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+			return
+		}
+	} else if err := pkg.ValidateSMSTemplate(req.Message); err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	if (req.SendAt == nil) == (req.CronSpec == "") {
+		ctx.JSON(
+			http.StatusBadRequest,
+			errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "one of send_at or cron_spec is required")),
+		)
+
+		return
+	}
+
+	if req.SendAt != nil && !req.SendAt.After(time.Now()) {
+		ctx.JSON(
+			http.StatusBadRequest,
+			errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "send_at must be in the future")),
+		)
+
+		return
+	}
+
+	if req.CronSpec != "" {
+		if _, err := cron.ParseStandard(req.CronSpec); err != nil {
+			ctx.JSON(
+				http.StatusBadRequest,
+				errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid cron_spec: %s", err.Error())),
+			)
+
+			return
+		}
+	}
+
+	if req.MinBalance != nil && *req.MinBalance < 0 {
+		ctx.JSON(
+			http.StatusBadRequest,
+			errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "min_balance cannot be negative")),
+		)
+
+		return
+	}
+
+	campaign, err := s.repo.SMSCampaignRepo.CreateSMSCampaign(ctx, &repository.SMSCampaign{
+		Name:       req.Name,
+		Message:    req.Message,
+		TemplateID: req.TemplateID,
+		MinBalance: req.MinBalance,
+		SendAt:     req.SendAt,
+		CronSpec:   req.CronSpec,
+	})
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	// recurring campaigns are picked up by the worker's periodic task manager
+	if campaign.SendAt != nil {
+		if err := s.enqueueSMSCampaign(ctx, campaign.ID, *campaign.SendAt); err != nil {
+			ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"data": campaign})
+}
+
+func (s *Server) listSMSCampaigns(ctx *gin.Context) {
+	pageNoStr := ctx.DefaultQuery("page", "1")
+	pageNo, err := pkg.StringToUint32(pageNoStr)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, err.Error())))
+
+		return
+	}
+
+	pageSizeStr := ctx.DefaultQuery("limit", "10")
+	pageSize, err := pkg.StringToUint32(pageSizeStr)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, err.Error())))
+
+		return
+	}
+
+	campaigns, metadata, err := s.repo.SMSCampaignRepo.ListSMSCampaigns(
+		ctx,
+		&pkg.PaginationMetadata{CurrentPage: pageNo, PageSize: pageSize},
+	)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": campaigns, "metadata": metadata})
+}
+
+func (s *Server) getSMSCampaign(ctx *gin.Context) {
+	id, err := pkg.StringToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, err.Error())))
+
+		return
+	}
+
+	campaign, err := s.repo.SMSCampaignRepo.GetSMSCampaign(ctx, id)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": campaign})
+}
+
+func (s *Server) pauseSMSCampaign(ctx *gin.Context) {
+	s.updateSMSCampaignStatus(ctx, repository.CampaignPaused)
+}
+
+func (s *Server) resumeSMSCampaign(ctx *gin.Context) {
+	s.updateSMSCampaignStatus(ctx, repository.CampaignScheduled)
+}
+
+func (s *Server) cancelSMSCampaign(ctx *gin.Context) {
+	s.updateSMSCampaignStatus(ctx, repository.CampaignCancelled)
+}
+
+func (s *Server) updateSMSCampaignStatus(ctx *gin.Context, status string) {
+	id, err := pkg.StringToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, err.Error())))
+
+		return
+	}
+
+	campaign, err := s.repo.SMSCampaignRepo.UpdateSMSCampaignStatus(ctx, id, status)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	// a one-off campaign paused past its send time was dropped by the worker, so
+	// it is queued again, immediately if it is overdue
+	if status == repository.CampaignScheduled && campaign.SendAt != nil {
+		processAt := *campaign.SendAt
+		if processAt.Before(time.Now()) {
+			processAt = time.Now()
+		}
+
+		if err := s.enqueueSMSCampaign(ctx, campaign.ID, processAt); err != nil {
+			ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": campaign})
+}
+
+func (s *Server) enqueueSMSCampaign(ctx *gin.Context, id uint32, processAt time.Time) error {
+	return s.taskDistributor.DistributeTaskDispatchCampaign(
+		ctx,
+		services.DispatchCampaignPayload{CampaignID: id},
+		asynq.ProcessAt(processAt),
+		asynq.Queue(services.QueueLow),
+		asynq.MaxRetry(1),
+		asynq.TaskID(workers.CampaignTaskID(id)),
+	)
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/EmilioCliff/jonche/internal/repository"
+	"github.com/EmilioCliff/jonche/pkg"
+	"github.com/gin-gonic/gin"
+)
+
+func (s *Server) getCustomerSMSConsents(ctx *gin.Context) {
+	id, err := pkg.StringToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, err.Error())))
+
+		return
+	}
+
+	consents, err := s.repo.SMSConsentRepo.ListCustomerSMSConsents(ctx, id)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": consents})
+}
+
+type updateCustomerSMSConsentReq struct {
+	Category string `json:"category"  binding:"omitempty,oneof=transactional marketing"`
+	OptedOut *bool  `json:"opted_out" binding:"required"`
+	Reason   string `json:"reason"`
+}
+
+// updateCustomerSMSConsent records a manual opt-out or opt-in. Without a category
+// it applies to every category.
+func (s *Server) updateCustomerSMSConsent(ctx *gin.Context) {
+	var req updateCustomerSMSConsentReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, err.Error())))
+
+		return
+	}
+
+	id, err := pkg.StringToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, err.Error())))
+
+		return
+	}
+
+	categories := repository.SMSCategories
+	if req.Category != "" {
+		categories = []string{req.Category}
+	}
+
+	if err := s.setSMSConsent(
+		ctx,
+		id,
+		categories,
+		*req.OptedOut,
+		repository.ConsentSourceManual,
+		req.Reason,
+	); err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	consents, err := s.repo.SMSConsentRepo.ListCustomerSMSConsents(ctx, id)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": consents})
+}
+
+func (s *Server) listOptedOutCustomers(ctx *gin.Context) {
+	pageNoStr := ctx.DefaultQuery("page", "1")
+	pageNo, err := pkg.StringToUint32(pageNoStr)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, err.Error())))
+
+		return
+	}
+
+	pageSizeStr := ctx.DefaultQuery("limit", "10")
+	pageSize, err := pkg.StringToUint32(pageSizeStr)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, err.Error())))
+
+		return
+	}
+
+	consents, metadata, err := s.repo.SMSConsentRepo.ListOptedOutCustomers(
+		ctx,
+		&pkg.PaginationMetadata{CurrentPage: pageNo, PageSize: pageSize},
+	)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": consents, "metadata": metadata})
+}
+
+func (s *Server) setSMSConsent(
+	ctx *gin.Context,
+	customerID uint32,
+	categories []string,
+	optedOut bool,
+	source, reason string,
+) error {
+	consents := make([]*repository.SMSConsent, len(categories))
+	for i, category := range categories {
+		consents[i] = &repository.SMSConsent{
+			CustomerID: customerID,
+			Category:   category,
+			OptedOut:   optedOut,
+			Source:     source,
+			Reason:     reason,
+		}
+	}
+
+	_, err := s.repo.SMSConsentRepo.SetSMSConsents(ctx, consents)
+
+	return err
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/EmilioCliff/jonche/internal/repository"
+	"github.com/EmilioCliff/jonche/pkg"
+	"github.com/gin-gonic/gin"
+)
+
+// smsKeywords maps the words customers use to the command they stand for.
+var smsKeywords = map[string]string{
+	"STOP":        repository.SMSKeywordStop,
+	"STOPALL":     repository.SMSKeywordStop,
+	"UNSUBSCRIBE": repository.SMSKeywordStop,
+	"CANCEL":      repository.SMSKeywordStop,
+	"END":         repository.SMSKeywordStop,
+	"QUIT":        repository.SMSKeywordStop,
+	"START":       repository.SMSKeywordStart,
+	"UNSTOP":      repository.SMSKeywordStart,
+	"SUBSCRIBE":   repository.SMSKeywordStart,
+	"BAL":         repository.SMSKeywordBalance,
+	"BALANCE":     repository.SMSKeywordBalance,
+}
+
+type smsInboundReq struct {
+	From string `json:"from" form:"from"`
+	Text string `json:"text" form:"text"`
+	ID   string `json:"id"   form:"id"`
+}
+
+// smsInbound receives messages customers send to the shortcode. Africa's Talking
+// posts a form, other gateways post json with the same from, text and id fields.
+// Every message is stored, STOP and START opt the sender out of, or back into,
+// every category and BAL is answered with their balance.
+func (s *Server) smsInbound(ctx *gin.Context) {
+	var req smsInboundReq
+	if err := ctx.ShouldBind(&req); err != nil || req.From == "" {
+		ctx.JSON(
+			http.StatusBadRequest,
+			errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "from and text are required")),
+		)
+
+		return
+	}
+
+	inbound, err := s.repo.SMSRepo.CreateInboundSMS(ctx, &repository.InboundSMS{
+		PhoneNumber:       req.From,
+		Message:           req.Text,
+		Keyword:           smsKeyword(req.Text),
+		ProviderMessageID: req.ID,
+	})
+	if err != nil {
+		if pkg.ErrorCode(err) == pkg.ALREADY_EXISTS_ERROR {
+			ctx.JSON(http.StatusOK, gin.H{"data": "received"})
+
+			return
+		}
+
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	if inbound.CustomerID != 0 &&
+		(inbound.Keyword == repository.SMSKeywordStop || inbound.Keyword == repository.SMSKeywordStart) {
+		if err := s.setSMSConsent(
+			ctx,
+			inbound.CustomerID,
+			repository.SMSCategories,
+			inbound.Keyword == repository.SMSKeywordStop,
+			repository.ConsentSourceKeyword,
+			req.Text,
+		); err != nil {
+			ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": "received"})
+}
+
+func (s *Server) listInboundSMS(ctx *gin.Context) {
+	pageNoStr := ctx.DefaultQuery("page", "1")
+	pageNo, err := pkg.StringToUint32(pageNoStr)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, err.Error())))
+
+		return
+	}
+
+	pageSizeStr := ctx.DefaultQuery("limit", "10")
+	pageSize, err := pkg.StringToUint32(pageSizeStr)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, err.Error())))
+
+		return
+	}
+
+	sms, metadata, err := s.repo.SMSRepo.ListInboundSMS(
+		ctx,
+		&pkg.PaginationMetadata{CurrentPage: pageNo, PageSize: pageSize},
+	)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": sms, "metadata": metadata})
+}
+
+// smsKeyword returns the command an inbound message starts with, so "Stop." and
+// " bal please" are understood, or an empty string for an ordinary reply.
+func smsKeyword(text string) string {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return ""
+	}
+
+	word := strings.ToUpper(strings.TrimFunc(fields[0], func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}))
+
+	return smsKeywords[word]
+}
@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/EmilioCliff/jonche/internal/repository"
+	"github.com/EmilioCliff/jonche/pkg"
+	"github.com/gin-gonic/gin"
+)
+
+type createSMSTemplateReq struct {
+	Name string `json:"name" binding:"required"`
+	Body string `json:"body" binding:"required"`
+}
+
+func (s *Server) createSMSTemplate(ctx *gin.Context) {
+	var req createSMSTemplateReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, err.Error())))
+
+		return
+	}
+
+	if err := pkg.ValidateSMSTemplate(req.Body); err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	template, err := s.repo.SMSTemplateRepo.CreateSMSTemplate(ctx, &repository.SMSTemplate{
+		Name: req.Name,
+		Body: req.Body,
+	})
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"data": template})
+}
+
+func (s *Server) listSMSTemplates(ctx *gin.Context) {
+	templates, err := s.repo.SMSTemplateRepo.ListSMSTemplates(ctx)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": templates})
+}
+
+func (s *Server) getSMSTemplate(ctx *gin.Context) {
+	id, err := pkg.StringToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, err.Error())))
+
+		return
+	}
+
+	template, err := s.repo.SMSTemplateRepo.GetSMSTemplate(ctx, id)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": template})
+}
+
+type updateSMSTemplateReq struct {
+	Name string `json:"name"`
+	Body string `json:"body"`
+}
+
+func (s *Server) updateSMSTemplate(ctx *gin.Context) {
+	var req updateSMSTemplateReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, err.Error())))
+
+		return
+	}
+
+	id, err := pkg.StringToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, err.Error())))
+
+		return
+	}
+
+	params := &repository.UpdateSMSTemplate{
+		ID: id,
+	}
+
+	if req.Name != "" {
+		params.Name = &req.Name
+	}
+
+	if req.Body != "" {
+		if err := pkg.ValidateSMSTemplate(req.Body); err != nil {
+			ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+			return
+		}
+
+		params.Body = &req.Body
+	}
+
+	template, err := s.repo.SMSTemplateRepo.UpdateSMSTemplate(ctx, params)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": template})
+}
+
+func (s *Server) deleteSMSTemplate(ctx *gin.Context) {
+	id, err := pkg.StringToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, err.Error())))
+
+		return
+	}
+
+	if err := s.repo.SMSTemplateRepo.DeleteSMSTemplate(ctx, id); err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": "success"})
+}
+
+func (s *Server) listSMSTemplatePlaceholders(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"data": pkg.TemplatePlaceholders()})
+}
+
+type previewSMSTemplateReq struct {
+	TemplateID uint32  `json:"template_id"`
+	Body       string  `json:"body"`
+	CustomerID uint32  `json:"customer_id" binding:"required"`
+	Paid       float64 `json:"paid"`
+	PaidDate   string  `json:"paid_date"`
+}
+
+// previewSMSTemplate renders a saved template, or an unsaved body, with a
+// customer's details. Paid and PaidDate only exist at payment time so they can be
+// passed in.
+func (s *Server) previewSMSTemplate(ctx *gin.Context) {
+	var req previewSMSTemplateReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, err.Error())))
+
+		return
+	}
+
+	body := req.Body
+	if req.TemplateID != 0 {
+		template, err := s.repo.SMSTemplateRepo.GetSMSTemplate(ctx, req.TemplateID)
+		if err != nil {
+			ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+			return
+		}
+
+		body = template.Body
+	}
+	if body == "" {
+		ctx.JSON(
+			http.StatusBadRequest,
+			errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "template_id or body is required")),
+		)
+
+		return
+	}
+
+	if err := pkg.ValidateSMSTemplate(body); err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	customer, err := s.repo.CustomerRepo.GetCustomer(ctx, req.CustomerID, "")
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	if req.PaidDate == "" {
+		req.PaidDate = time.Now().Format("02 Jan 2006")
+	}
+
+	messages, err := pkg.GenerateMessages(body, []pkg.CustomerTemplateParams{
+		{
+			Name:        customer.Name,
+			PhoneNumber: customer.PhoneNumber,
+			Loaned:      customer.Loaned,
+			Credit:      customer.Credit,
+			Paid:        req.Paid,
+			PaidDate:    req.PaidDate,
+		},
+	})
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": messages[0]})
+}
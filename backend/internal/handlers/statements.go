@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/EmilioCliff/jonche/internal/repository"
+	"github.com/EmilioCliff/jonche/pkg"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	importStatusImported  = "imported"
+	importStatusDuplicate = "duplicate"
+	importStatusFailed    = "failed"
+)
+
+type statementImportLine struct {
+	pkg.StatementLine
+	Status     string `json:"status"`
+	PaymentID  uint32 `json:"payment_id,omitempty"`
+	CustomerID uint32 `json:"customer_id,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+type statementImportReport struct {
+	Total      int                   `json:"total"`
+	Imported   int                   `json:"imported"`
+	Matched    int                   `json:"matched"`
+	Unmatched  int                   `json:"unmatched"`
+	Duplicates int                   `json:"duplicates"`
+	Failed     int                   `json:"failed"`
+	Lines      []statementImportLine `json:"lines"`
+}
+
+// importStatement takes a bank statement (csv or mt940) as multipart "file" and
+// records every credit line as a payment from source, BANK or MPESA. The csv
+// column headers can be overridden with the *_column form fields.
+func (s *Server) importStatement(ctx *gin.Context) {
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, err.Error())))
+
+		return
+	}
+
+	format := strings.ToLower(ctx.PostForm("format"))
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(fileHeader.Filename)) {
+		case ".csv":
+			format = pkg.StatementFormatCSV
+		case ".sta", ".mt940":
+			format = pkg.StatementFormatMT940
+		}
+	}
+	if format != pkg.StatementFormatCSV && format != pkg.StatementFormatMT940 {
+		ctx.JSON(
+			http.StatusBadRequest,
+			errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "unsupported statement format")),
+		)
+
+		return
+	}
+
+	// references are unique per source, a free-form source would let the same
+	// statement be imported again under another name
+	source := strings.ToUpper(strings.TrimSpace(ctx.DefaultPostForm("source", bankTransactionSource)))
+	if source != bankTransactionSource && source != mpesaTransactionSource {
+		ctx.JSON(
+			http.StatusBadRequest,
+			errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "unsupported transaction source: %s", source)),
+		)
+
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		ctx.JSON(
+			http.StatusInternalServerError,
+			errorResponse(pkg.Errorf(pkg.INTERNAL_ERROR, "failed to open file: %s", err.Error())),
+		)
+
+		return
+	}
+	defer file.Close()
+
+	var lines []pkg.StatementLine
+	switch format {
+	case pkg.StatementFormatCSV:
+		mapping := pkg.DefaultCSVColumnMapping
+		mapping.Reference = ctx.DefaultPostForm("reference_column", mapping.Reference)
+		mapping.PayerName = ctx.DefaultPostForm("name_column", mapping.PayerName)
+		mapping.Amount = ctx.DefaultPostForm("amount_column", mapping.Amount)
+		mapping.Date = ctx.DefaultPostForm("date_column", mapping.Date)
+		mapping.Description = ctx.DefaultPostForm("description_column", mapping.Description)
+		mapping.DateLayout = ctx.DefaultPostForm("date_layout", mapping.DateLayout)
+
+		lines, err = pkg.ParseCSVStatement(file, mapping)
+	case pkg.StatementFormatMT940:
+		lines, err = pkg.ParseMT940Statement(file)
+	}
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	report := s.importStatementLines(ctx, lines, source)
+
+	ctx.JSON(http.StatusOK, gin.H{"data": report})
+}
+
+// importStatementLines records each line as a payment, skipping references that
+// already exist, and runs it through the same customer matching as the callback.
+func (s *Server) importStatementLines(
+	ctx *gin.Context,
+	lines []pkg.StatementLine,
+	source string,
+) statementImportReport {
+	report := statementImportReport{
+		Total: len(lines),
+		Lines: make([]statementImportLine, 0, len(lines)),
+	}
+	seen := make(map[string]bool, len(lines))
+
+	for _, line := range lines {
+		rslt := statementImportLine{StatementLine: line}
+
+		if line.Reference == "" {
+			rslt.Status = importStatusFailed
+			rslt.Error = "missing transaction reference"
+			report.Failed++
+			report.Lines = append(report.Lines, rslt)
+
+			continue
+		}
+
+		if seen[line.Reference] {
+			rslt.Status = importStatusDuplicate
+			report.Duplicates++
+			report.Lines = append(report.Lines, rslt)
+
+			continue
+		}
+		seen[line.Reference] = true
+
+		payment := &repository.Payment{
+			TransactionNumber: line.Reference,
+			TransactionSource: source,
+			PayingName:        line.PayerName,
+			PayingPhone:       line.PayerPhone,
+			Amount:            line.Amount,
+			PaidAt:            line.Date,
+		}
+
+		if err := s.matchPaymentCustomer(ctx, payment); err != nil {
+			rslt.Status = importStatusFailed
+			rslt.Error = pkg.ErrorMessage(err)
+			report.Failed++
+			report.Lines = append(report.Lines, rslt)
+
+			continue
+		}
+
+		payment, err := s.repo.PaymentRepo.CreatePayment(
+			ctx,
+			payment,
+		)
+		if err != nil {
+			// the unique reference catches an import or callback racing this one
+			if pkg.ErrorCode(err) == pkg.ALREADY_EXISTS_ERROR {
+				rslt.Status = importStatusDuplicate
+				report.Duplicates++
+				report.Lines = append(report.Lines, rslt)
+
+				continue
+			}
+
+			rslt.Status = importStatusFailed
+			rslt.Error = pkg.ErrorMessage(err)
+			report.Failed++
+			report.Lines = append(report.Lines, rslt)
+
+			continue
+		}
+
+		rslt.Status = importStatusImported
+		rslt.PaymentID = payment.ID
+		report.Imported++
+		if payment.Assigned {
+			rslt.CustomerID = payment.AssignedTo
+			report.Matched++
+		} else {
+			report.Unmatched++
+		}
+		report.Lines = append(report.Lines, rslt)
+	}
+
+	return report
+}
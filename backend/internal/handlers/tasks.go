@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/EmilioCliff/jonche/internal/services"
+	"github.com/EmilioCliff/jonche/pkg"
+	"github.com/gin-gonic/gin"
+)
+
+func (s *Server) listTaskQueues(ctx *gin.Context) {
+	queues, err := s.taskDistributor.ListQueues()
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": queues})
+}
+
+// deadTaskResponse links a dead send task to the sms row it was sending.
+type deadTaskResponse struct {
+	*services.DeadTask
+	SMSID  uint32 `json:"sms_id,omitempty"`
+	SMSURL string `json:"sms_url,omitempty"`
+}
+
+func (s *Server) listDeadTasks(ctx *gin.Context) {
+	pageNoStr := ctx.DefaultQuery("page", "1")
+	pageNo, err := pkg.StringToUint32(pageNoStr)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, err.Error())))
+
+		return
+	}
+
+	pageSizeStr := ctx.DefaultQuery("limit", "10")
+	pageSize, err := pkg.StringToUint32(pageSizeStr)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, err.Error())))
+
+		return
+	}
+
+	tasks, metadata, err := s.taskDistributor.ListDeadTasks(
+		ctx.DefaultQuery("queue", services.QueueDefault),
+		&pkg.PaginationMetadata{CurrentPage: pageNo, PageSize: pageSize},
+	)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	var refIDs []string
+	for _, task := range tasks {
+		if task.SMS != nil {
+			refIDs = append(refIDs, task.SMS.RefID)
+		}
+	}
+
+	smsIDs, err := s.repo.SMSRepo.ListSMSIDsByRefIDs(ctx, refIDs)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	rslt := make([]deadTaskResponse, len(tasks))
+	for i, task := range tasks {
+		rslt[i] = deadTaskResponse{DeadTask: task}
+		if task.SMS == nil {
+			continue
+		}
+
+		// alerts to admins are sent without an sms row
+		if id, ok := smsIDs[task.SMS.RefID]; ok {
+			rslt[i].SMSID = id
+			rslt[i].SMSURL = fmt.Sprintf("/api/v1/sms/%d", id)
+		}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": rslt, "metadata": metadata})
+}
+
+func (s *Server) retryDeadTask(ctx *gin.Context) {
+	if err := s.taskDistributor.RetryDeadTask(ctx.Param("queue"), ctx.Param("id")); err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": "task queued again"})
+}
+
+func (s *Server) deleteDeadTask(ctx *gin.Context) {
+	if err := s.taskDistributor.DeleteDeadTask(ctx.Param("queue"), ctx.Param("id")); err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": "success"})
+}
+
+type replayDeadTasksReq struct {
+	IDs []string `json:"ids"`
+}
+
+// replayDeadTasks retries the listed dead tasks in a queue, or all of them when no
+// ids are sent.
+func (s *Server) replayDeadTasks(ctx *gin.Context) {
+	var req replayDeadTasksReq
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, err.Error())))
+
+			return
+		}
+	}
+
+	replayed, err := s.taskDistributor.ReplayDeadTasks(ctx.Param("queue"), req.IDs)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": gin.H{"replayed": replayed}})
+}
@@ -0,0 +1,301 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"slices"
+
+	"github.com/EmilioCliff/jonche/internal/repository"
+	"github.com/EmilioCliff/jonche/internal/services"
+	"github.com/EmilioCliff/jonche/pkg"
+	"github.com/gin-gonic/gin"
+)
+
+// secrets shorter than this are too easy to guess
+const minWebhookSecretLength = 16
+
+type createWebhookReq struct {
+	URL         string   `json:"url"         binding:"required"`
+	Events      []string `json:"events"      binding:"required"`
+	Secret      string   `json:"secret"`
+	Description string   `json:"description"`
+}
+
+func (s *Server) createWebhook(ctx *gin.Context) {
+	var req createWebhookReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, err.Error())))
+
+		return
+	}
+
+	if err := validateWebhookURL(ctx, req.URL); err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	events, err := validateWebhookEvents(req.Events)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		if secret, err = newWebhookSecret(); err != nil {
+			ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+			return
+		}
+	} else if len(secret) < minWebhookSecretLength {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(
+			pkg.INVALID_ERROR,
+			"secret must be at least %d characters",
+			minWebhookSecretLength,
+		)))
+
+		return
+	}
+
+	// the secret is only returned here, the subscriber needs it to check signatures
+	subscription, err := s.repo.WebhookRepo.CreateWebhookSubscription(ctx, &repository.WebhookSubscription{
+		URL:         req.URL,
+		Events:      events,
+		Secret:      secret,
+		Description: req.Description,
+	})
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"data": subscription})
+}
+
+func (s *Server) listWebhooks(ctx *gin.Context) {
+	subscriptions, err := s.repo.WebhookRepo.ListWebhookSubscriptions(ctx)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	for _, subscription := range subscriptions {
+		subscription.Secret = ""
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": subscriptions})
+}
+
+func (s *Server) listWebhookEvents(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"data": services.WebhookEvents})
+}
+
+func (s *Server) getWebhook(ctx *gin.Context) {
+	id, err := pkg.StringToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, err.Error())))
+
+		return
+	}
+
+	subscription, err := s.repo.WebhookRepo.GetWebhookSubscription(ctx, id)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+	subscription.Secret = ""
+
+	ctx.JSON(http.StatusOK, gin.H{"data": subscription})
+}
+
+type updateWebhookReq struct {
+	URL         string   `json:"url"`
+	Events      []string `json:"events"`
+	Secret      string   `json:"secret"`
+	Description *string  `json:"description"`
+	Active      *bool    `json:"active"`
+}
+
+func (s *Server) updateWebhook(ctx *gin.Context) {
+	var req updateWebhookReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, err.Error())))
+
+		return
+	}
+
+	id, err := pkg.StringToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, err.Error())))
+
+		return
+	}
+
+	params := &repository.UpdateWebhookSubscription{
+		ID:          id,
+		Description: req.Description,
+		Active:      req.Active,
+	}
+
+	if req.URL != "" {
+		if err := validateWebhookURL(ctx, req.URL); err != nil {
+			ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+			return
+		}
+
+		params.URL = &req.URL
+	}
+
+	if req.Events != nil {
+		if params.Events, err = validateWebhookEvents(req.Events); err != nil {
+			ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+			return
+		}
+	}
+
+	if req.Secret != "" {
+		if len(req.Secret) < minWebhookSecretLength {
+			ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(
+				pkg.INVALID_ERROR,
+				"secret must be at least %d characters",
+				minWebhookSecretLength,
+			)))
+
+			return
+		}
+
+		params.Secret = &req.Secret
+	}
+
+	subscription, err := s.repo.WebhookRepo.UpdateWebhookSubscription(ctx, params)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+	subscription.Secret = ""
+
+	ctx.JSON(http.StatusOK, gin.H{"data": subscription})
+}
+
+func (s *Server) deleteWebhook(ctx *gin.Context) {
+	id, err := pkg.StringToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, err.Error())))
+
+		return
+	}
+
+	if err := s.repo.WebhookRepo.DeleteWebhookSubscription(ctx, id); err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": "success"})
+}
+
+func (s *Server) listWebhookDeliveries(ctx *gin.Context) {
+	id, err := pkg.StringToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, err.Error())))
+
+		return
+	}
+
+	pageNoStr := ctx.DefaultQuery("page", "1")
+	pageNo, err := pkg.StringToUint32(pageNoStr)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, err.Error())))
+
+		return
+	}
+
+	pageSizeStr := ctx.DefaultQuery("limit", "10")
+	pageSize, err := pkg.StringToUint32(pageSizeStr)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, err.Error())))
+
+		return
+	}
+
+	deliveries, metadata, err := s.repo.WebhookRepo.ListWebhookDeliveries(
+		ctx,
+		id,
+		&pkg.PaginationMetadata{CurrentPage: pageNo, PageSize: pageSize},
+	)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": deliveries, "metadata": metadata})
+}
+
+func (s *Server) redeliverWebhook(ctx *gin.Context) {
+	id, err := pkg.StringToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, err.Error())))
+
+		return
+	}
+
+	if err := s.repo.WebhookRepo.RedeliverWebhook(ctx, id); err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": "queued"})
+}
+
+// validateWebhookURL only takes urls on public hosts, the worker would otherwise
+// post to the server's own network for whoever adds a subscription.
+func validateWebhookURL(ctx context.Context, rawURL string) error {
+	u, err := url.ParseRequestURI(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return pkg.Errorf(pkg.INVALID_ERROR, "url must be an absolute http or https url")
+	}
+
+	return pkg.CheckPublicHost(ctx, u.Hostname())
+}
+
+// validateWebhookEvents checks every event is known and drops repeats.
+func validateWebhookEvents(events []string) ([]string, error) {
+	if len(events) == 0 {
+		return nil, pkg.Errorf(pkg.INVALID_ERROR, "at least one event is required")
+	}
+
+	rslt := make([]string, 0, len(events))
+	for _, event := range events {
+		if !services.IsWebhookEvent(event) {
+			return nil, pkg.Errorf(pkg.INVALID_ERROR, "unknown webhook event: %q", event)
+		}
+
+		if !slices.Contains(rslt, event) {
+			rslt = append(rslt, event)
+		}
+	}
+
+	return rslt, nil
+}
+
+func newWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", pkg.Errorf(pkg.INTERNAL_ERROR, "failed to generate secret: %s", err.Error())
+	}
+
+	return hex.EncodeToString(secret), nil
+}
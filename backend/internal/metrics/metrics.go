@@ -0,0 +1,95 @@
+// Package metrics keeps the counters and histograms served on /metrics. They
+// live in the default prometheus registry, next to the go runtime and process
+// collectors.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	TasksProcessed = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "jonche_tasks_processed_total",
+			Help: "Tasks handled by the worker.",
+		},
+		[]string{"type"},
+	)
+	TasksFailed = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "jonche_tasks_failed_total",
+			Help: "Tasks that returned an error, throttled tasks are not failures.",
+		},
+		[]string{"type"},
+	)
+	TasksThrottled = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "jonche_tasks_throttled_total",
+			Help: "Tasks sent back to the queue by the sms rate limit.",
+		},
+		[]string{"type"},
+	)
+	TaskDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "jonche_task_duration_seconds",
+			Help:    "How long tasks took to run.",
+			Buckets: []float64{.01, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60, 120},
+		},
+		[]string{"type"},
+	)
+
+	SMSProviderRequests = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "jonche_sms_provider_requests_total",
+			Help: "Requests to the sms gateways by the HTTP status they returned, or error when there was no response.",
+		},
+		[]string{"provider", "code"},
+	)
+	SMSProviderLatency = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "jonche_sms_provider_request_duration_seconds",
+			Help:    "How long the sms gateways took to respond.",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"provider"},
+	)
+	SMSSent = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "jonche_sms_sent_total",
+			Help: "Messages accepted by the sms gateways.",
+		},
+		[]string{"provider"},
+	)
+	SMSCost = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "jonche_sms_cost_total",
+			Help: "KES charged by the sms gateways for accepted messages.",
+		},
+		[]string{"provider"},
+	)
+
+	HTTPRequests = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "jonche_http_requests_total",
+			Help: "HTTP requests served by route and status.",
+		},
+		[]string{"method", "route", "code"},
+	)
+	HTTPRequestDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "jonche_http_request_duration_seconds",
+			Help:    "How long HTTP requests took to serve.",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"method", "route"},
+	)
+)
+
+// Handler serves everything registered with the default registry.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/EmilioCliff/jonche/internal/postgres/generated"
 	"github.com/EmilioCliff/jonche/internal/repository"
+	"github.com/EmilioCliff/jonche/internal/services"
 	"github.com/EmilioCliff/jonche/pkg"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
@@ -45,6 +46,7 @@ func (c *CustomerRepository) CreateCustomer(
 	customer.ID = uint32(rslt.ID)
 	customer.Status = rslt.Status
 	customer.Loaned = numericToFloat64(rslt.Loaned)
+	customer.Credit = numericToFloat64(rslt.Credit)
 	customer.CreatedAt = rslt.CreatedAt
 
 	return customer, nil
@@ -75,6 +77,7 @@ func (c *CustomerRepository) ListCustomers(
 			PhoneNumber: customer.PhoneNumber,
 			Status:      customer.Status,
 			Loaned:      numericToFloat64(customer.Loaned),
+			Credit:      numericToFloat64(customer.Credit),
 			CreatedAt:   customer.CreatedAt,
 		}
 	}
@@ -152,6 +155,36 @@ func (c *CustomerRepository) GetCustomer(
 		PhoneNumber: customer.PhoneNumber,
 		Status:      customer.Status,
 		Loaned:      numericToFloat64(customer.Loaned),
+		Credit:      numericToFloat64(customer.Credit),
+		CreatedAt:   customer.CreatedAt,
+	}, nil
+}
+
+func (c *CustomerRepository) GetCustomerByPhoneNumber(
+	ctx context.Context,
+	phoneNumber string,
+) (*repository.Customer, error) {
+	suffix := pkg.PhoneSuffix(phoneNumber)
+	if len(suffix) < 9 {
+		return nil, pkg.Errorf(pkg.INVALID_ERROR, "invalid phone number: %s", phoneNumber)
+	}
+
+	customer, err := c.queries.GetCustomerByPhoneSuffix(ctx, suffix)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "customer not found")
+		}
+
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error getting customer: %s", err.Error())
+	}
+
+	return &repository.Customer{
+		ID:          uint32(customer.ID),
+		Name:        customer.Name,
+		PhoneNumber: customer.PhoneNumber,
+		Status:      customer.Status,
+		Loaned:      numericToFloat64(customer.Loaned),
+		Credit:      numericToFloat64(customer.Credit),
 		CreatedAt:   customer.CreatedAt,
 	}, nil
 }
@@ -303,9 +336,23 @@ func (c *CustomerRepository) UpdateCustomerDetails(
 		}
 	}
 
-	rslt, err := c.queries.UpdateCustomer(ctx, params)
+	var rslt generated.Customer
+	err := c.db.ExecTx(ctx, func(q *generated.Queries) error {
+		var err error
+		rslt, err = q.UpdateCustomer(ctx, params)
+		if err != nil {
+			return pkg.Errorf(pkg.INTERNAL_ERROR, "error updating customer: %s", err.Error())
+		}
+
+		return publishEvent(
+			ctx,
+			q,
+			services.EventCustomerUpdated,
+			customerEventFromGenerated(rslt),
+		)
+	})
 	if err != nil {
-		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error updating customer: %s", err.Error())
+		return nil, err
 	}
 
 	return &repository.Customer{
@@ -314,6 +361,7 @@ func (c *CustomerRepository) UpdateCustomerDetails(
 		PhoneNumber: rslt.PhoneNumber,
 		Status:      rslt.Status,
 		Loaned:      numericToFloat64(rslt.Loaned),
+		Credit:      numericToFloat64(rslt.Credit),
 		CreatedAt:   rslt.CreatedAt,
 	}, nil
 }
@@ -329,3 +377,101 @@ func (c *CustomerRepository) DeleteCustomer(ctx context.Context, id uint32) erro
 
 	return nil
 }
+
+// RefundCustomerCredit pays out part of a customer's credit balance and records it.
+func (c *CustomerRepository) RefundCustomerCredit(
+	ctx context.Context,
+	refund *repository.Refund,
+) (*repository.Refund, error) {
+	err := c.db.ExecTx(ctx, func(q *generated.Queries) error {
+		var amount pgtype.Numeric
+
+		if err := amount.Scan(pkg.Float64ToString(refund.Amount)); err != nil {
+			return pkg.Errorf(
+				pkg.INTERNAL_ERROR,
+				"failed to scan float to numeric: %s",
+				err.Error(),
+			)
+		}
+
+		if _, err := q.DeductCustomerCredit(ctx, generated.DeductCustomerCreditParams{
+			Amount: amount,
+			ID:     int64(refund.CustomerID),
+		}); err != nil {
+			if err == pgx.ErrNoRows {
+				return pkg.Errorf(pkg.INVALID_ERROR, "refund amount exceeds customer credit")
+			}
+
+			return pkg.Errorf(pkg.INTERNAL_ERROR, "error deducting customer credit: %s", err.Error())
+		}
+
+		rslt, err := q.CreateRefund(ctx, generated.CreateRefundParams{
+			CustomerID:  int64(refund.CustomerID),
+			Amount:      amount,
+			Method:      refund.Method,
+			Reference:   refund.Reference,
+			Description: refund.Description,
+		})
+		if err != nil {
+			if pkg.PgxErrorCode(err) == pkg.FOREIGN_KEY_VIOLATION {
+				return pkg.Errorf(pkg.INVALID_ERROR, "foreign key violation: %s", err.Error())
+			}
+
+			return pkg.Errorf(pkg.INTERNAL_ERROR, "error creating refund: %s", err.Error())
+		}
+
+		refund.ID = uint32(rslt.ID)
+		refund.CreatedAt = rslt.CreatedAt
+
+		return nil
+	})
+
+	return refund, err
+}
+
+func (c *CustomerRepository) ListCustomerRefunds(
+	ctx context.Context,
+	id uint32,
+	pgData *pkg.PaginationMetadata,
+) ([]*repository.Refund, pkg.PaginationMetadata, error) {
+	rslt, err := c.queries.ListCustomerRefunds(ctx, generated.ListCustomerRefundsParams{
+		CustomerID: int64(id),
+		Offset:     pkg.CalculateOffset(pgData.CurrentPage, pgData.PageSize),
+		Limit:      int32(pgData.PageSize),
+	})
+	if err != nil {
+		return nil, pkg.PaginationMetadata{}, pkg.Errorf(
+			pkg.INTERNAL_ERROR,
+			"error listing customer refunds: %s",
+			err.Error(),
+		)
+	}
+
+	refunds := make([]*repository.Refund, len(rslt))
+	for i, refund := range rslt {
+		refunds[i] = &repository.Refund{
+			ID:          uint32(refund.ID),
+			CustomerID:  uint32(refund.CustomerID),
+			Amount:      numericToFloat64(refund.Amount),
+			Method:      refund.Method,
+			Reference:   refund.Reference,
+			Description: refund.Description,
+			CreatedAt:   refund.CreatedAt,
+		}
+	}
+
+	totalRefunds, err := c.queries.CountCustomerRefunds(ctx, int64(id))
+	if err != nil {
+		return nil, pkg.PaginationMetadata{}, pkg.Errorf(
+			pkg.INTERNAL_ERROR,
+			"failed to count customer refunds: %s",
+			err.Error(),
+		)
+	}
+
+	return refunds, pkg.CreatePaginationMetadata(
+		uint32(totalRefunds),
+		pgData.PageSize,
+		pgData.CurrentPage,
+	), nil
+}
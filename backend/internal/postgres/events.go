@@ -0,0 +1,109 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/EmilioCliff/jonche/internal/postgres/generated"
+	"github.com/EmilioCliff/jonche/internal/services"
+	"github.com/EmilioCliff/jonche/pkg"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/jackc/pgx/v5"
+)
+
+// publishEvent queues the event in the caller's transaction, so webhook
+// subscribers and the dashboard only hear about changes that were committed.
+// Every active subscription to the event gets a delivery of its own.
+func publishEvent(ctx context.Context, q *generated.Queries, eventType string, data any) error {
+	eventID := uuid.NewString()
+	payload, err := json.Marshal(services.Event{
+		ID:        eventID,
+		Type:      eventType,
+		CreatedAt: time.Now(),
+		Data:      data,
+	})
+	if err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to marshal event: %s", err.Error())
+	}
+
+	if services.IsWebhookEvent(eventType) {
+		ids, err := q.CreateWebhookDeliveries(ctx, generated.CreateWebhookDeliveriesParams{
+			EventID:   eventID,
+			EventType: eventType,
+			Payload:   payload,
+		})
+		if err != nil {
+			return pkg.Errorf(pkg.INTERNAL_ERROR, "error creating webhook deliveries: %s", err.Error())
+		}
+
+		for _, id := range ids {
+			if err := enqueueWebhookDelivery(ctx, q, uint32(id)); err != nil {
+				return err
+			}
+		}
+	}
+
+	// an event the dashboard gets minutes late is no longer live
+	return enqueueTask(
+		ctx,
+		q,
+		services.PublishEventTask,
+		json.RawMessage(payload),
+		asynq.Queue(services.QueueCritical),
+		asynq.MaxRetry(1),
+	)
+}
+
+// publishSMSStatusChanged tells the dashboard about the message's new status.
+func publishSMSStatusChanged(ctx context.Context, q *generated.Queries, refID string) error {
+	sms, err := q.GetSMSForDeliveryReport(ctx, generated.GetSMSForDeliveryReportParams{
+		RefID: refID,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil
+		}
+
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "error getting sms: %s", err.Error())
+	}
+
+	return publishEvent(ctx, q, services.EventSMSStatusChanged, smsEventFromGenerated(sms))
+}
+
+func customerEventFromGenerated(customer generated.Customer) *services.CustomerEvent {
+	return &services.CustomerEvent{
+		ID:          uint32(customer.ID),
+		Name:        customer.Name,
+		PhoneNumber: customer.PhoneNumber,
+		Status:      customer.Status,
+		Loaned:      numericToFloat64(customer.Loaned),
+		Credit:      numericToFloat64(customer.Credit),
+	}
+}
+
+func paymentEventFromGenerated(payment generated.Payment) *services.PaymentEvent {
+	return &services.PaymentEvent{
+		ID:                uint32(payment.ID),
+		TransactionNumber: payment.TransactionNumber,
+		TransactionSource: payment.TransactionSource,
+		PayingName:        payment.PayingName,
+		PayingPhone:       payment.PayingPhone,
+		Amount:            numericToFloat64(payment.Amount),
+		PaidAt:            payment.PaidAt,
+		Assigned:          payment.Assigned,
+	}
+}
+
+func smsEventFromGenerated(sms generated.GetSMSForDeliveryReportRow) *services.SMSEvent {
+	return &services.SMSEvent{
+		ID:                uint32(sms.ID),
+		RefID:             sms.RefID,
+		CustomerID:        uint32(sms.CustomerID),
+		PhoneNumber:       sms.CustomerPhoneNumber,
+		Provider:          sms.Provider,
+		ProviderMessageID: sms.ProviderMessageID,
+		Status:            sms.Status,
+	}
+}
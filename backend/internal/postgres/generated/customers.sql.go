@@ -13,17 +13,18 @@ import (
 
 const addCustomerLoaned = `-- name: AddCustomerLoaned :exec
 UPDATE customers
-SET loaned = loaned + $1
+SET loaned = loaned + GREATEST($1 - credit, 0),
+    credit = GREATEST(credit - $1, 0)
 WHERE id = $2
 `
 
 type AddCustomerLoanedParams struct {
-	Loaned pgtype.Numeric `json:"loaned"`
+	Amount pgtype.Numeric `json:"amount"`
 	ID     int64          `json:"id"`
 }
 
 func (q *Queries) AddCustomerLoaned(ctx context.Context, arg AddCustomerLoanedParams) error {
-	_, err := q.db.Exec(ctx, addCustomerLoaned, arg.Loaned, arg.ID)
+	_, err := q.db.Exec(ctx, addCustomerLoaned, arg.Amount, arg.ID)
 	return err
 }
 
@@ -44,7 +45,7 @@ INSERT INTO customers (
 ) VALUES (
   $1, $2
 )
-RETURNING id, name, phone_number, status, loaned, created_at
+RETURNING id, name, phone_number, status, loaned, created_at, credit
 `
 
 type CreateCustomerParams struct {
@@ -62,6 +63,34 @@ func (q *Queries) CreateCustomer(ctx context.Context, arg CreateCustomerParams)
 		&i.Status,
 		&i.Loaned,
 		&i.CreatedAt,
+		&i.Credit,
+	)
+	return i, err
+}
+
+const deductCustomerCredit = `-- name: DeductCustomerCredit :one
+UPDATE customers
+SET credit = credit - $1
+WHERE id = $2 AND credit >= $1
+RETURNING id, name, phone_number, status, loaned, created_at, credit
+`
+
+type DeductCustomerCreditParams struct {
+	Amount pgtype.Numeric `json:"amount"`
+	ID     int64          `json:"id"`
+}
+
+func (q *Queries) DeductCustomerCredit(ctx context.Context, arg DeductCustomerCreditParams) (Customer, error) {
+	row := q.db.QueryRow(ctx, deductCustomerCredit, arg.Amount, arg.ID)
+	var i Customer
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.PhoneNumber,
+		&i.Status,
+		&i.Loaned,
+		&i.CreatedAt,
+		&i.Credit,
 	)
 	return i, err
 }
@@ -76,7 +105,7 @@ func (q *Queries) DeleteCustomer(ctx context.Context, id int64) error {
 }
 
 const getCustomer = `-- name: GetCustomer :one
-SELECT id, name, phone_number, status, loaned, created_at FROM customers
+SELECT id, name, phone_number, status, loaned, created_at, credit FROM customers
 WHERE 
   (id = $1 OR phone_number = $2)
 LIMIT 1
@@ -97,17 +126,40 @@ func (q *Queries) GetCustomer(ctx context.Context, arg GetCustomerParams) (Custo
 		&i.Status,
 		&i.Loaned,
 		&i.CreatedAt,
+		&i.Credit,
+	)
+	return i, err
+}
+
+const getCustomerByPhoneSuffix = `-- name: GetCustomerByPhoneSuffix :one
+SELECT id, name, phone_number, status, loaned, created_at, credit FROM customers
+WHERE right(regexp_replace(phone_number, '\D', '', 'g'), 9) = $1
+ORDER BY id
+LIMIT 1
+`
+
+func (q *Queries) GetCustomerByPhoneSuffix(ctx context.Context, phoneSuffix string) (Customer, error) {
+	row := q.db.QueryRow(ctx, getCustomerByPhoneSuffix, phoneSuffix)
+	var i Customer
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.PhoneNumber,
+		&i.Status,
+		&i.Loaned,
+		&i.CreatedAt,
+		&i.Credit,
 	)
 	return i, err
 }
 
 const getCustomerFullData = `-- name: GetCustomerFullData :one
 WITH customer_data AS (
-  SELECT customers.id, customers.name, customers.phone_number, customers.status, customers.loaned, customers.created_at FROM customers
+  SELECT customers.id, customers.name, customers.phone_number, customers.status, customers.loaned, customers.created_at, customers.credit FROM customers
   WHERE customers.id = $1
 ),
 loans_paginated AS (
-  SELECT id, customer_id, description, amount, created_at FROM loans 
+  SELECT id, customer_id, description, amount, created_at, due_date FROM loans 
   WHERE loans.customer_id = $1
   ORDER BY created_at DESC
   LIMIT $3 OFFSET $2
@@ -119,7 +171,7 @@ sms_paginated AS (
   LIMIT $3 OFFSET $2
 ),
 payments_paginated AS (
-  SELECT id, transaction_number, transaction_source, paying_name, amount, assigned, assigned_to, paid_at FROM payments 
+  SELECT id, transaction_number, transaction_source, paying_name, amount, assigned, assigned_to, paid_at, reversed, reversal_of, reversal_reason, paying_phone, ignored, ignored_reason FROM payments 
   WHERE payments.assigned_to = $1
   ORDER BY paid_at DESC
   LIMIT $3 OFFSET $2
@@ -134,7 +186,7 @@ payments_count AS (
   SELECT COUNT(*) AS total_payments FROM payments WHERE payments.assigned_to = $1
 )
 SELECT 
-  (SELECT row_to_json(c) FROM (SELECT id, name, phone_number, status, loaned, created_at FROM customer_data) c) AS customer,
+  (SELECT row_to_json(c) FROM (SELECT id, name, phone_number, status, loaned, created_at, credit FROM customer_data) c) AS customer,
   (SELECT json_agg(l) FROM loans_paginated l) AS loans,
   (SELECT json_agg(s) FROM sms_paginated s) AS sms,
   (SELECT json_agg(p) FROM payments_paginated p) AS payments,
@@ -219,7 +271,7 @@ func (q *Queries) GetCustomerList(ctx context.Context) ([]GetCustomerListRow, er
 }
 
 const listCustomers = `-- name: ListCustomers :many
-SELECT id, name, phone_number, status, loaned, created_at FROM customers
+SELECT id, name, phone_number, status, loaned, created_at, credit FROM customers
 ORDER BY created_at DESC
 LIMIT $1 OFFSET $2
 `
@@ -245,6 +297,7 @@ func (q *Queries) ListCustomers(ctx context.Context, arg ListCustomersParams) ([
 			&i.Status,
 			&i.Loaned,
 			&i.CreatedAt,
+			&i.Credit,
 		); err != nil {
 			return nil, err
 		}
@@ -258,18 +311,19 @@ func (q *Queries) ListCustomers(ctx context.Context, arg ListCustomersParams) ([
 
 const reduceCustomerLoaned = `-- name: ReduceCustomerLoaned :one
 UPDATE customers
-SET loaned = loaned - $1
+SET loaned = GREATEST(loaned - $1, 0),
+    credit = credit + GREATEST($1 - loaned, 0)
 WHERE id = $2
-RETURNING id, name, phone_number, status, loaned, created_at
+RETURNING id, name, phone_number, status, loaned, created_at, credit
 `
 
 type ReduceCustomerLoanedParams struct {
-	Loaned pgtype.Numeric `json:"loaned"`
+	Amount pgtype.Numeric `json:"amount"`
 	ID     int64          `json:"id"`
 }
 
 func (q *Queries) ReduceCustomerLoaned(ctx context.Context, arg ReduceCustomerLoanedParams) (Customer, error) {
-	row := q.db.QueryRow(ctx, reduceCustomerLoaned, arg.Loaned, arg.ID)
+	row := q.db.QueryRow(ctx, reduceCustomerLoaned, arg.Amount, arg.ID)
 	var i Customer
 	err := row.Scan(
 		&i.ID,
@@ -278,6 +332,7 @@ func (q *Queries) ReduceCustomerLoaned(ctx context.Context, arg ReduceCustomerLo
 		&i.Status,
 		&i.Loaned,
 		&i.CreatedAt,
+		&i.Credit,
 	)
 	return i, err
 }
@@ -288,7 +343,7 @@ SET name = coalesce($1, name),
     phone_number = coalesce($2, phone_number),
     status = coalesce($3, status)
 WHERE id = $4
-RETURNING id, name, phone_number, status, loaned, created_at
+RETURNING id, name, phone_number, status, loaned, created_at, credit
 `
 
 type UpdateCustomerParams struct {
@@ -313,6 +368,7 @@ func (q *Queries) UpdateCustomer(ctx context.Context, arg UpdateCustomerParams)
 		&i.Status,
 		&i.Loaned,
 		&i.CreatedAt,
+		&i.Credit,
 	)
 	return i, err
 }
@@ -26,6 +26,7 @@ FROM (
         0 AS loans_amount,
         amount AS payments_amount
     FROM payments
+    WHERE reversed = FALSE AND reversal_of IS NULL AND ignored = FALSE
 ) AS combined
 GROUP BY date_trunc('month', date_val)
 ORDER BY date_trunc('month', date_val)
@@ -75,8 +76,11 @@ WITH
     SELECT 
       SUM(amount) AS total_payments_received,
       SUM(amount) FILTER (WHERE assigned = TRUE) AS assigned_total,
-      SUM(amount) FILTER (WHERE assigned = FALSE) AS unassigned_total
+      SUM(amount) FILTER (WHERE assigned = FALSE) AS unassigned_total,
+      COUNT(*) FILTER (WHERE assigned = FALSE) AS unassigned_count,
+      MIN(paid_at) FILTER (WHERE assigned = FALSE) AS oldest_unassigned_at
     FROM payments
+    WHERE reversed = FALSE AND reversal_of IS NULL AND ignored = FALSE
   ),
   sms_stats AS (
     SELECT 
@@ -99,6 +103,9 @@ SELECT
   tp.total_payments_received,
   tp.assigned_total,
   tp.unassigned_total,
+  tp.unassigned_count,
+  tp.oldest_unassigned_at,
+  COALESCE(EXTRACT(DAY FROM now() - tp.oldest_unassigned_at), 0)::int AS oldest_unassigned_days,
 
   -- SMS
   ss.delivered AS sms_delivered,
@@ -108,17 +115,20 @@ FROM total_customers tc, total_loans tl, total_payments tp, sms_stats ss
 `
 
 type GetDashboardStatsRow struct {
-	TotalCustomers        int64 `json:"total_customers"`
-	ActiveCustomers       int64 `json:"active_customers"`
-	InactiveCustomers     int64 `json:"inactive_customers"`
-	TotalLoans            int64 `json:"total_loans"`
-	TotalDisbursed        int64 `json:"total_disbursed"`
-	TotalPaymentsReceived int64 `json:"total_payments_received"`
-	AssignedTotal         int64 `json:"assigned_total"`
-	UnassignedTotal       int64 `json:"unassigned_total"`
-	SmsDelivered          int64 `json:"sms_delivered"`
-	SmsUndelivered        int64 `json:"sms_undelivered"`
-	TotalSms              int64 `json:"total_sms"`
+	TotalCustomers        int64       `json:"total_customers"`
+	ActiveCustomers       int64       `json:"active_customers"`
+	InactiveCustomers     int64       `json:"inactive_customers"`
+	TotalLoans            int64       `json:"total_loans"`
+	TotalDisbursed        int64       `json:"total_disbursed"`
+	TotalPaymentsReceived int64       `json:"total_payments_received"`
+	AssignedTotal         int64       `json:"assigned_total"`
+	UnassignedTotal       int64       `json:"unassigned_total"`
+	UnassignedCount       int64       `json:"unassigned_count"`
+	OldestUnassignedAt    interface{} `json:"oldest_unassigned_at"`
+	OldestUnassignedDays  int32       `json:"oldest_unassigned_days"`
+	SmsDelivered          int64       `json:"sms_delivered"`
+	SmsUndelivered        int64       `json:"sms_undelivered"`
+	TotalSms              int64       `json:"total_sms"`
 }
 
 func (q *Queries) GetDashboardStats(ctx context.Context) (GetDashboardStatsRow, error) {
@@ -133,6 +143,9 @@ func (q *Queries) GetDashboardStats(ctx context.Context) (GetDashboardStatsRow,
 		&i.TotalPaymentsReceived,
 		&i.AssignedTotal,
 		&i.UnassignedTotal,
+		&i.UnassignedCount,
+		&i.OldestUnassignedAt,
+		&i.OldestUnassignedDays,
 		&i.SmsDelivered,
 		&i.SmsUndelivered,
 		&i.TotalSms,
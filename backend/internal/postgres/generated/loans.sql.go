@@ -36,21 +36,27 @@ func (q *Queries) CountLoans(ctx context.Context) (int64, error) {
 
 const createLoan = `-- name: CreateLoan :one
 INSERT INTO loans (
-    customer_id, description, amount
+    customer_id, description, amount, due_date
 ) VALUES (
-    $1, $2, $3
+    $1, $2, $3, $4
 )
-RETURNING id, customer_id, description, amount, created_at
+RETURNING id, customer_id, description, amount, created_at, due_date
 `
 
 type CreateLoanParams struct {
 	CustomerID  int64          `json:"customer_id"`
 	Description string         `json:"description"`
 	Amount      pgtype.Numeric `json:"amount"`
+	DueDate     pgtype.Date    `json:"due_date"`
 }
 
 func (q *Queries) CreateLoan(ctx context.Context, arg CreateLoanParams) (Loan, error) {
-	row := q.db.QueryRow(ctx, createLoan, arg.CustomerID, arg.Description, arg.Amount)
+	row := q.db.QueryRow(ctx, createLoan,
+		arg.CustomerID,
+		arg.Description,
+		arg.Amount,
+		arg.DueDate,
+	)
 	var i Loan
 	err := row.Scan(
 		&i.ID,
@@ -58,6 +64,7 @@ func (q *Queries) CreateLoan(ctx context.Context, arg CreateLoanParams) (Loan, e
 		&i.Description,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.DueDate,
 	)
 	return i, err
 }
@@ -73,7 +80,7 @@ func (q *Queries) DeleteLoan(ctx context.Context, id int64) error {
 
 const getLoan = `-- name: GetLoan :one
 SELECT
-    loans.id, loans.customer_id, loans.description, loans.amount, loans.created_at,
+    loans.id, loans.customer_id, loans.description, loans.amount, loans.created_at, loans.due_date,
     customers.name AS customer_name, 
     customers.phone_number AS customer_phone_number
 FROM loans
@@ -88,6 +95,7 @@ type GetLoanRow struct {
 	Description         string         `json:"description"`
 	Amount              pgtype.Numeric `json:"amount"`
 	CreatedAt           time.Time      `json:"created_at"`
+	DueDate             pgtype.Date    `json:"due_date"`
 	CustomerName        string         `json:"customer_name"`
 	CustomerPhoneNumber string         `json:"customer_phone_number"`
 }
@@ -101,6 +109,7 @@ func (q *Queries) GetLoan(ctx context.Context, id int64) (GetLoanRow, error) {
 		&i.Description,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.DueDate,
 		&i.CustomerName,
 		&i.CustomerPhoneNumber,
 	)
@@ -109,7 +118,7 @@ func (q *Queries) GetLoan(ctx context.Context, id int64) (GetLoanRow, error) {
 
 const listCustomerLoans = `-- name: ListCustomerLoans :many
 SELECT 
-  loans.id, loans.customer_id, loans.description, loans.amount, loans.created_at, 
+  loans.id, loans.customer_id, loans.description, loans.amount, loans.created_at, loans.due_date, 
   customers.name AS customer_name, 
   customers.phone_number AS customer_phone_number
 FROM loans
@@ -131,6 +140,7 @@ type ListCustomerLoansRow struct {
 	Description         string         `json:"description"`
 	Amount              pgtype.Numeric `json:"amount"`
 	CreatedAt           time.Time      `json:"created_at"`
+	DueDate             pgtype.Date    `json:"due_date"`
 	CustomerName        string         `json:"customer_name"`
 	CustomerPhoneNumber string         `json:"customer_phone_number"`
 }
@@ -150,6 +160,7 @@ func (q *Queries) ListCustomerLoans(ctx context.Context, arg ListCustomerLoansPa
 			&i.Description,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.DueDate,
 			&i.CustomerName,
 			&i.CustomerPhoneNumber,
 		); err != nil {
@@ -165,7 +176,7 @@ func (q *Queries) ListCustomerLoans(ctx context.Context, arg ListCustomerLoansPa
 
 const listLoans = `-- name: ListLoans :many
 SELECT 
-  loans.id, loans.customer_id, loans.description, loans.amount, loans.created_at, 
+  loans.id, loans.customer_id, loans.description, loans.amount, loans.created_at, loans.due_date, 
   customers.name AS customer_name, 
   customers.phone_number AS customer_phone_number
 FROM loans
@@ -185,6 +196,7 @@ type ListLoansRow struct {
 	Description         string         `json:"description"`
 	Amount              pgtype.Numeric `json:"amount"`
 	CreatedAt           time.Time      `json:"created_at"`
+	DueDate             pgtype.Date    `json:"due_date"`
 	CustomerName        string         `json:"customer_name"`
 	CustomerPhoneNumber string         `json:"customer_phone_number"`
 }
@@ -204,6 +216,7 @@ func (q *Queries) ListLoans(ctx context.Context, arg ListLoansParams) ([]ListLoa
 			&i.Description,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.DueDate,
 			&i.CustomerName,
 			&i.CustomerPhoneNumber,
 		); err != nil {
@@ -216,3 +229,29 @@ func (q *Queries) ListLoans(ctx context.Context, arg ListLoansParams) ([]ListLoa
 	}
 	return items, nil
 }
+
+const updateLoanDueDate = `-- name: UpdateLoanDueDate :one
+UPDATE loans
+SET due_date = $1
+WHERE id = $2
+RETURNING id, customer_id, description, amount, created_at, due_date
+`
+
+type UpdateLoanDueDateParams struct {
+	DueDate pgtype.Date `json:"due_date"`
+	ID      int64       `json:"id"`
+}
+
+func (q *Queries) UpdateLoanDueDate(ctx context.Context, arg UpdateLoanDueDateParams) (Loan, error) {
+	row := q.db.QueryRow(ctx, updateLoanDueDate, arg.DueDate, arg.ID)
+	var i Loan
+	err := row.Scan(
+		&i.ID,
+		&i.CustomerID,
+		&i.Description,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.DueDate,
+	)
+	return i, err
+}
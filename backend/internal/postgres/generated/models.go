@@ -17,6 +17,8 @@ type Customer struct {
 	Status      bool           `json:"status"`
 	Loaned      pgtype.Numeric `json:"loaned"`
 	CreatedAt   time.Time      `json:"created_at"`
+	// overpaid amount applied to the next loan
+	Credit pgtype.Numeric `json:"credit"`
 }
 
 type Loan struct {
@@ -25,6 +27,18 @@ type Loan struct {
 	Description string         `json:"description"`
 	Amount      pgtype.Numeric `json:"amount"`
 	CreatedAt   time.Time      `json:"created_at"`
+	// when the loan is expected to be repaid, reminders are only sent for loans with one
+	DueDate pgtype.Date `json:"due_date"`
+}
+
+type LoanReminder struct {
+	ID         int64 `json:"id"`
+	LoanID     int64 `json:"loan_id"`
+	CustomerID int64 `json:"customer_id"`
+	// before_<days>, due or overdue_<days>
+	Stage    string    `json:"stage"`
+	SmsRefID string    `json:"sms_ref_id"`
+	SentAt   time.Time `json:"sent_at"`
 }
 
 type Payment struct {
@@ -36,6 +50,23 @@ type Payment struct {
 	Assigned          bool           `json:"assigned"`
 	AssignedTo        pgtype.Int8    `json:"assigned_to"`
 	PaidAt            time.Time      `json:"paid_at"`
+	Reversed          bool           `json:"reversed"`
+	ReversalOf        pgtype.Int8    `json:"reversal_of"`
+	ReversalReason    string         `json:"reversal_reason"`
+	PayingPhone       string         `json:"paying_phone"`
+	// reviewed and marked as not a loan repayment
+	Ignored       bool   `json:"ignored"`
+	IgnoredReason string `json:"ignored_reason"`
+}
+
+type Refund struct {
+	ID          int64          `json:"id"`
+	CustomerID  int64          `json:"customer_id"`
+	Amount      pgtype.Numeric `json:"amount"`
+	Method      string         `json:"method"`
+	Reference   string         `json:"reference"`
+	Description string         `json:"description"`
+	CreatedAt   time.Time      `json:"created_at"`
 }
 
 type Sm struct {
@@ -44,13 +75,128 @@ type Sm struct {
 	Message    string `json:"message"`
 	// automated or manual
 	Type string `json:"type"`
-	// delivered or undelivered
-	Status         string    `json:"status"`
-	CreatedAt      time.Time `json:"created_at"`
-	RefID          string    `json:"ref_id"`
-	Cost           string    `json:"cost"`
+	// pending, delivered, failed, expired or rejected
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+	RefID     string    `json:"ref_id"`
+	// what the provider charged in KES, 0 until the message is sent
+	Cost              pgtype.Numeric `json:"cost"`
+	Description       string         `json:"description"`
+	CallbackStatus    string         `json:"callback_status"`
+	Provider          string         `json:"provider"`
+	ProviderMessageID string         `json:"provider_message_id"`
+	// times the message was sent again after a failed delivery report
+	ResendCount int32 `json:"resend_count"`
+}
+
+type SmsCampaign struct {
+	ID         int64       `json:"id"`
+	Name       string      `json:"name"`
+	Message    string      `json:"message"`
+	TemplateID pgtype.Int8 `json:"template_id"`
+	// only customers owing more than this, every active customer when null
+	MinBalance pgtype.Numeric     `json:"min_balance"`
+	SendAt     pgtype.Timestamptz `json:"send_at"`
+	// recurring campaigns only, one-off campaigns use send_at
+	CronSpec string `json:"cron_spec"`
+	// scheduled, paused, cancelled or completed
+	Status    string             `json:"status"`
+	LastRunAt pgtype.Timestamptz `json:"last_run_at"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+type SmsConsent struct {
+	CustomerID int64 `json:"customer_id"`
+	// transactional or marketing, payment receipts are always sent
+	Category string `json:"category"`
+	OptedOut bool   `json:"opted_out"`
+	// keyword when the customer texted STOP or START, manual when set by staff
+	Source    string    `json:"source"`
+	Reason    string    `json:"reason"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type SmsInbound struct {
+	ID int64 `json:"id"`
+	// matched on the last nine digits of the sender, null for unknown numbers
+	CustomerID  pgtype.Int8 `json:"customer_id"`
+	PhoneNumber string      `json:"phone_number"`
+	Message     string      `json:"message"`
+	// STOP, START or BAL when the message was a command
+	Keyword           string    `json:"keyword"`
+	ProviderMessageID string    `json:"provider_message_id"`
+	ReceivedAt        time.Time `json:"received_at"`
+}
+
+type SmsProviderBalance struct {
+	Provider string `json:"provider"`
+	// credit left as last reported by the gateway after a send
+	Balance pgtype.Numeric `json:"balance"`
+	// set once admins were told the balance is low, cleared after a top up
+	Alerted   bool      `json:"alerted"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type SmsStatusHistory struct {
+	ID int64 `json:"id"`
+	// null until a report for a message we have not recorded yet is matched
+	SmsID             pgtype.Int8 `json:"sms_id"`
+	RefID             string      `json:"ref_id"`
+	Provider          string      `json:"provider"`
+	ProviderMessageID string      `json:"provider_message_id"`
+	// pending, delivered, failed, expired or rejected
+	Status string `json:"status"`
+	// the status exactly as the gateway reported it
+	ProviderStatus string    `json:"provider_status"`
 	Description    string    `json:"description"`
-	CallbackStatus string    `json:"callback_status"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// every hand over of a message to a gateway, a retried send task skips messages already accepted
+type SmsSubmission struct {
+	RefID string `json:"ref_id"`
+	// 0 for the first send, then the resend number
+	Resend int32 `json:"resend"`
+	// submitting while the request is in flight, a task that died mid request leaves it submitting
+	Status            string         `json:"status"`
+	Provider          string         `json:"provider"`
+	ProviderMessageID string         `json:"provider_message_id"`
+	Cost              pgtype.Numeric `json:"cost"`
+	Description       string         `json:"description"`
+	Attempts          int32          `json:"attempts"`
+	Error             string         `json:"error"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+}
+
+type SmsTemplate struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	Body string `json:"body"`
+	// used by the application, can be edited but not renamed or deleted
+	System    bool      `json:"system"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// tasks written with the rows they belong to, published to redis by the relay after commit
+type TaskOutbox struct {
+	ID        int64              `json:"id"`
+	TaskType  string             `json:"task_type"`
+	Payload   []byte             `json:"payload"`
+	Queue     string             `json:"queue"`
+	MaxRetry  pgtype.Int4        `json:"max_retry"`
+	ProcessAt pgtype.Timestamptz `json:"process_at"`
+	// asynq task id, outbox:<id> when empty so a task published twice is only queued once
+	TaskID      string             `json:"task_id"`
+	Attempts    int32              `json:"attempts"`
+	LastError   string             `json:"last_error"`
+	CreatedAt   time.Time          `json:"created_at"`
+	PublishedAt pgtype.Timestamptz `json:"published_at"`
+	// when the relay tries to publish the task next, pushed back after every failure
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	// set when the relay gave up on the task
+	FailedAt pgtype.Timestamptz `json:"failed_at"`
 }
 
 type User struct {
@@ -60,3 +206,36 @@ type User struct {
 	Password     string `json:"password"`
 	RefreshToken string `json:"refresh_token"`
 }
+
+// one event sent to one subscription, with the result of the latest attempt
+type WebhookDelivery struct {
+	ID             int64  `json:"id"`
+	SubscriptionID int64  `json:"subscription_id"`
+	EventID        string `json:"event_id"`
+	EventType      string `json:"event_type"`
+	// the exact body posted, signatures are computed over it
+	Payload []byte `json:"payload"`
+	// pending until the url answers with a 2xx, failed once the retries run out
+	Status         string             `json:"status"`
+	Attempts       int32              `json:"attempts"`
+	ResponseStatus int32              `json:"response_status"`
+	ResponseBody   string             `json:"response_body"`
+	Error          string             `json:"error"`
+	DurationMs     int32              `json:"duration_ms"`
+	CreatedAt      time.Time          `json:"created_at"`
+	UpdatedAt      time.Time          `json:"updated_at"`
+	DeliveredAt    pgtype.Timestamptz `json:"delivered_at"`
+}
+
+// urls told about business events, each request is signed with the secret
+type WebhookSubscription struct {
+	ID  int64  `json:"id"`
+	Url string `json:"url"`
+	// event types the url receives, like payment.received
+	Events      []string  `json:"events"`
+	Secret      string    `json:"secret"`
+	Description string    `json:"description"`
+	Active      bool      `json:"active"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
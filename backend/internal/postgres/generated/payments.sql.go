@@ -17,7 +17,7 @@ UPDATE payments
 SET assigned = true,
     assigned_to = $1
 WHERE id = $2
-RETURNING id, transaction_number, transaction_source, paying_name, amount, assigned, assigned_to, paid_at
+RETURNING id, transaction_number, transaction_source, paying_name, amount, assigned, assigned_to, paid_at, reversed, reversal_of, reversal_reason, paying_phone, ignored, ignored_reason
 `
 
 type AssignPaymentParams struct {
@@ -37,6 +37,12 @@ func (q *Queries) AssignPayment(ctx context.Context, arg AssignPaymentParams) (P
 		&i.Assigned,
 		&i.AssignedTo,
 		&i.PaidAt,
+		&i.Reversed,
+		&i.ReversalOf,
+		&i.ReversalReason,
+		&i.PayingPhone,
+		&i.Ignored,
+		&i.IgnoredReason,
 	)
 	return i, err
 }
@@ -86,19 +92,36 @@ func (q *Queries) CountPayments(ctx context.Context, arg CountPaymentsParams) (i
 	return total_payments, err
 }
 
+const countUnassignedPayments = `-- name: CountUnassignedPayments :one
+SELECT COUNT(*) AS total_payments FROM payments
+WHERE assigned = FALSE
+  AND ignored = FALSE
+  AND reversed = FALSE
+  AND reversal_of IS NULL
+`
+
+func (q *Queries) CountUnassignedPayments(ctx context.Context) (int64, error) {
+	row := q.db.QueryRow(ctx, countUnassignedPayments)
+	var total_payments int64
+	err := row.Scan(&total_payments)
+	return total_payments, err
+}
+
 const createPayment = `-- name: CreatePayment :one
 INSERT INTO payments (
-    transaction_number, transaction_source, paying_name, amount, assigned, assigned_to, paid_at
+    transaction_number, transaction_source, paying_name, paying_phone, amount, assigned, assigned_to, paid_at
 ) VALUES (
-    $1, $2, $3, $4, $5, COALESCE($6, 0), $7
+    $1, $2, $3, $4, $5, $6, COALESCE($7, 0), $8
 )
-RETURNING id, transaction_number, transaction_source, paying_name, amount, assigned, assigned_to, paid_at
+ON CONFLICT (transaction_number, transaction_source) DO NOTHING
+RETURNING id, transaction_number, transaction_source, paying_name, amount, assigned, assigned_to, paid_at, reversed, reversal_of, reversal_reason, paying_phone, ignored, ignored_reason
 `
 
 type CreatePaymentParams struct {
 	TransactionNumber string         `json:"transaction_number"`
 	TransactionSource string         `json:"transaction_source"`
 	PayingName        string         `json:"paying_name"`
+	PayingPhone       string         `json:"paying_phone"`
 	Amount            pgtype.Numeric `json:"amount"`
 	Assigned          bool           `json:"assigned"`
 	AssignedTo        interface{}    `json:"assigned_to"`
@@ -107,6 +130,58 @@ type CreatePaymentParams struct {
 
 func (q *Queries) CreatePayment(ctx context.Context, arg CreatePaymentParams) (Payment, error) {
 	row := q.db.QueryRow(ctx, createPayment,
+		arg.TransactionNumber,
+		arg.TransactionSource,
+		arg.PayingName,
+		arg.PayingPhone,
+		arg.Amount,
+		arg.Assigned,
+		arg.AssignedTo,
+		arg.PaidAt,
+	)
+	var i Payment
+	err := row.Scan(
+		&i.ID,
+		&i.TransactionNumber,
+		&i.TransactionSource,
+		&i.PayingName,
+		&i.Amount,
+		&i.Assigned,
+		&i.AssignedTo,
+		&i.PaidAt,
+		&i.Reversed,
+		&i.ReversalOf,
+		&i.ReversalReason,
+		&i.PayingPhone,
+		&i.Ignored,
+		&i.IgnoredReason,
+	)
+	return i, err
+}
+
+const createPaymentReversal = `-- name: CreatePaymentReversal :one
+INSERT INTO payments (
+    transaction_number, transaction_source, paying_name, amount, assigned, assigned_to, paid_at, reversal_of, reversal_reason
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+RETURNING id, transaction_number, transaction_source, paying_name, amount, assigned, assigned_to, paid_at, reversed, reversal_of, reversal_reason, paying_phone, ignored, ignored_reason
+`
+
+type CreatePaymentReversalParams struct {
+	TransactionNumber string         `json:"transaction_number"`
+	TransactionSource string         `json:"transaction_source"`
+	PayingName        string         `json:"paying_name"`
+	Amount            pgtype.Numeric `json:"amount"`
+	Assigned          bool           `json:"assigned"`
+	AssignedTo        pgtype.Int8    `json:"assigned_to"`
+	PaidAt            time.Time      `json:"paid_at"`
+	ReversalOf        pgtype.Int8    `json:"reversal_of"`
+	ReversalReason    string         `json:"reversal_reason"`
+}
+
+func (q *Queries) CreatePaymentReversal(ctx context.Context, arg CreatePaymentReversalParams) (Payment, error) {
+	row := q.db.QueryRow(ctx, createPaymentReversal,
 		arg.TransactionNumber,
 		arg.TransactionSource,
 		arg.PayingName,
@@ -114,6 +189,8 @@ func (q *Queries) CreatePayment(ctx context.Context, arg CreatePaymentParams) (P
 		arg.Assigned,
 		arg.AssignedTo,
 		arg.PaidAt,
+		arg.ReversalOf,
+		arg.ReversalReason,
 	)
 	var i Payment
 	err := row.Scan(
@@ -125,13 +202,19 @@ func (q *Queries) CreatePayment(ctx context.Context, arg CreatePaymentParams) (P
 		&i.Assigned,
 		&i.AssignedTo,
 		&i.PaidAt,
+		&i.Reversed,
+		&i.ReversalOf,
+		&i.ReversalReason,
+		&i.PayingPhone,
+		&i.Ignored,
+		&i.IgnoredReason,
 	)
 	return i, err
 }
 
 const getPayment = `-- name: GetPayment :one
 SELECT 
-    payments.id, payments.transaction_number, payments.transaction_source, payments.paying_name, payments.amount, payments.assigned, payments.assigned_to, payments.paid_at, 
+    payments.id, payments.transaction_number, payments.transaction_source, payments.paying_name, payments.amount, payments.assigned, payments.assigned_to, payments.paid_at, payments.reversed, payments.reversal_of, payments.reversal_reason, payments.paying_phone, payments.ignored, payments.ignored_reason, 
     CASE 
         WHEN payments.assigned = TRUE THEN customers.name 
         ELSE NULL 
@@ -155,6 +238,12 @@ type GetPaymentRow struct {
 	Assigned            bool           `json:"assigned"`
 	AssignedTo          pgtype.Int8    `json:"assigned_to"`
 	PaidAt              time.Time      `json:"paid_at"`
+	Reversed            bool           `json:"reversed"`
+	ReversalOf          pgtype.Int8    `json:"reversal_of"`
+	ReversalReason      string         `json:"reversal_reason"`
+	PayingPhone         string         `json:"paying_phone"`
+	Ignored             bool           `json:"ignored"`
+	IgnoredReason       string         `json:"ignored_reason"`
 	CustomerName        interface{}    `json:"customer_name"`
 	CustomerPhoneNumber interface{}    `json:"customer_phone_number"`
 }
@@ -171,15 +260,84 @@ func (q *Queries) GetPayment(ctx context.Context, id int64) (GetPaymentRow, erro
 		&i.Assigned,
 		&i.AssignedTo,
 		&i.PaidAt,
+		&i.Reversed,
+		&i.ReversalOf,
+		&i.ReversalReason,
+		&i.PayingPhone,
+		&i.Ignored,
+		&i.IgnoredReason,
 		&i.CustomerName,
 		&i.CustomerPhoneNumber,
 	)
 	return i, err
 }
 
+const getPaymentForUpdate = `-- name: GetPaymentForUpdate :one
+SELECT id, transaction_number, transaction_source, paying_name, amount, assigned, assigned_to, paid_at, reversed, reversal_of, reversal_reason, paying_phone, ignored, ignored_reason FROM payments
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) GetPaymentForUpdate(ctx context.Context, id int64) (Payment, error) {
+	row := q.db.QueryRow(ctx, getPaymentForUpdate, id)
+	var i Payment
+	err := row.Scan(
+		&i.ID,
+		&i.TransactionNumber,
+		&i.TransactionSource,
+		&i.PayingName,
+		&i.Amount,
+		&i.Assigned,
+		&i.AssignedTo,
+		&i.PaidAt,
+		&i.Reversed,
+		&i.ReversalOf,
+		&i.ReversalReason,
+		&i.PayingPhone,
+		&i.Ignored,
+		&i.IgnoredReason,
+	)
+	return i, err
+}
+
+const ignorePayment = `-- name: IgnorePayment :one
+UPDATE payments
+SET ignored = true,
+    ignored_reason = $1
+WHERE id = $2
+RETURNING id, transaction_number, transaction_source, paying_name, amount, assigned, assigned_to, paid_at, reversed, reversal_of, reversal_reason, paying_phone, ignored, ignored_reason
+`
+
+type IgnorePaymentParams struct {
+	IgnoredReason string `json:"ignored_reason"`
+	ID            int64  `json:"id"`
+}
+
+func (q *Queries) IgnorePayment(ctx context.Context, arg IgnorePaymentParams) (Payment, error) {
+	row := q.db.QueryRow(ctx, ignorePayment, arg.IgnoredReason, arg.ID)
+	var i Payment
+	err := row.Scan(
+		&i.ID,
+		&i.TransactionNumber,
+		&i.TransactionSource,
+		&i.PayingName,
+		&i.Amount,
+		&i.Assigned,
+		&i.AssignedTo,
+		&i.PaidAt,
+		&i.Reversed,
+		&i.ReversalOf,
+		&i.ReversalReason,
+		&i.PayingPhone,
+		&i.Ignored,
+		&i.IgnoredReason,
+	)
+	return i, err
+}
+
 const listCustomerPayments = `-- name: ListCustomerPayments :many
 SELECT 
-    payments.id, payments.transaction_number, payments.transaction_source, payments.paying_name, payments.amount, payments.assigned, payments.assigned_to, payments.paid_at, 
+    payments.id, payments.transaction_number, payments.transaction_source, payments.paying_name, payments.amount, payments.assigned, payments.assigned_to, payments.paid_at, payments.reversed, payments.reversal_of, payments.reversal_reason, payments.paying_phone, payments.ignored, payments.ignored_reason, 
     customers.name AS customer_name,
     customers.phone_number AS customer_phone_number
 FROM payments
@@ -204,6 +362,12 @@ type ListCustomerPaymentsRow struct {
 	Assigned            bool           `json:"assigned"`
 	AssignedTo          pgtype.Int8    `json:"assigned_to"`
 	PaidAt              time.Time      `json:"paid_at"`
+	Reversed            bool           `json:"reversed"`
+	ReversalOf          pgtype.Int8    `json:"reversal_of"`
+	ReversalReason      string         `json:"reversal_reason"`
+	PayingPhone         string         `json:"paying_phone"`
+	Ignored             bool           `json:"ignored"`
+	IgnoredReason       string         `json:"ignored_reason"`
 	CustomerName        pgtype.Text    `json:"customer_name"`
 	CustomerPhoneNumber pgtype.Text    `json:"customer_phone_number"`
 }
@@ -226,6 +390,12 @@ func (q *Queries) ListCustomerPayments(ctx context.Context, arg ListCustomerPaym
 			&i.Assigned,
 			&i.AssignedTo,
 			&i.PaidAt,
+			&i.Reversed,
+			&i.ReversalOf,
+			&i.ReversalReason,
+			&i.PayingPhone,
+			&i.Ignored,
+			&i.IgnoredReason,
 			&i.CustomerName,
 			&i.CustomerPhoneNumber,
 		); err != nil {
@@ -241,7 +411,7 @@ func (q *Queries) ListCustomerPayments(ctx context.Context, arg ListCustomerPaym
 
 const listPayments = `-- name: ListPayments :many
 SELECT 
-    payments.id, payments.transaction_number, payments.transaction_source, payments.paying_name, payments.amount, payments.assigned, payments.assigned_to, payments.paid_at, 
+    payments.id, payments.transaction_number, payments.transaction_source, payments.paying_name, payments.amount, payments.assigned, payments.assigned_to, payments.paid_at, payments.reversed, payments.reversal_of, payments.reversal_reason, payments.paying_phone, payments.ignored, payments.ignored_reason, 
     CASE 
         WHEN payments.assigned = TRUE THEN customers.name 
         ELSE NULL 
@@ -273,6 +443,12 @@ type ListPaymentsRow struct {
 	Assigned            bool           `json:"assigned"`
 	AssignedTo          pgtype.Int8    `json:"assigned_to"`
 	PaidAt              time.Time      `json:"paid_at"`
+	Reversed            bool           `json:"reversed"`
+	ReversalOf          pgtype.Int8    `json:"reversal_of"`
+	ReversalReason      string         `json:"reversal_reason"`
+	PayingPhone         string         `json:"paying_phone"`
+	Ignored             bool           `json:"ignored"`
+	IgnoredReason       string         `json:"ignored_reason"`
 	CustomerName        interface{}    `json:"customer_name"`
 	CustomerPhoneNumber interface{}    `json:"customer_phone_number"`
 }
@@ -300,6 +476,12 @@ func (q *Queries) ListPayments(ctx context.Context, arg ListPaymentsParams) ([]L
 			&i.Assigned,
 			&i.AssignedTo,
 			&i.PaidAt,
+			&i.Reversed,
+			&i.ReversalOf,
+			&i.ReversalReason,
+			&i.PayingPhone,
+			&i.Ignored,
+			&i.IgnoredReason,
 			&i.CustomerName,
 			&i.CustomerPhoneNumber,
 		); err != nil {
@@ -312,3 +494,166 @@ func (q *Queries) ListPayments(ctx context.Context, arg ListPaymentsParams) ([]L
 	}
 	return items, nil
 }
+
+const listPaymentsByTransactionNumbers = `-- name: ListPaymentsByTransactionNumbers :many
+SELECT id, transaction_number, transaction_source, paying_name, amount, assigned, assigned_to, paid_at, reversed, reversal_of, reversal_reason, paying_phone, ignored, ignored_reason FROM payments
+WHERE transaction_source = $1
+  AND transaction_number = ANY($2::text[])
+  AND reversal_of IS NULL
+`
+
+type ListPaymentsByTransactionNumbersParams struct {
+	TransactionSource  string   `json:"transaction_source"`
+	TransactionNumbers []string `json:"transaction_numbers"`
+}
+
+func (q *Queries) ListPaymentsByTransactionNumbers(ctx context.Context, arg ListPaymentsByTransactionNumbersParams) ([]Payment, error) {
+	rows, err := q.db.Query(ctx, listPaymentsByTransactionNumbers, arg.TransactionSource, arg.TransactionNumbers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Payment{}
+	for rows.Next() {
+		var i Payment
+		if err := rows.Scan(
+			&i.ID,
+			&i.TransactionNumber,
+			&i.TransactionSource,
+			&i.PayingName,
+			&i.Amount,
+			&i.Assigned,
+			&i.AssignedTo,
+			&i.PaidAt,
+			&i.Reversed,
+			&i.ReversalOf,
+			&i.ReversalReason,
+			&i.PayingPhone,
+			&i.Ignored,
+			&i.IgnoredReason,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSourcePayments = `-- name: ListSourcePayments :many
+SELECT id, transaction_number, transaction_source, paying_name, amount, assigned, assigned_to, paid_at, reversed, reversal_of, reversal_reason, paying_phone, ignored, ignored_reason FROM payments
+WHERE transaction_source = $1
+  AND reversal_of IS NULL
+  AND paid_at BETWEEN $2 AND $3
+ORDER BY paid_at
+`
+
+type ListSourcePaymentsParams struct {
+	TransactionSource string    `json:"transaction_source"`
+	StartDate         time.Time `json:"start_date"`
+	EndDate           time.Time `json:"end_date"`
+}
+
+func (q *Queries) ListSourcePayments(ctx context.Context, arg ListSourcePaymentsParams) ([]Payment, error) {
+	rows, err := q.db.Query(ctx, listSourcePayments, arg.TransactionSource, arg.StartDate, arg.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Payment{}
+	for rows.Next() {
+		var i Payment
+		if err := rows.Scan(
+			&i.ID,
+			&i.TransactionNumber,
+			&i.TransactionSource,
+			&i.PayingName,
+			&i.Amount,
+			&i.Assigned,
+			&i.AssignedTo,
+			&i.PaidAt,
+			&i.Reversed,
+			&i.ReversalOf,
+			&i.ReversalReason,
+			&i.PayingPhone,
+			&i.Ignored,
+			&i.IgnoredReason,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUnassignedPayments = `-- name: ListUnassignedPayments :many
+SELECT id, transaction_number, transaction_source, paying_name, amount, assigned, assigned_to, paid_at, reversed, reversal_of, reversal_reason, paying_phone, ignored, ignored_reason FROM payments
+WHERE assigned = FALSE
+  AND ignored = FALSE
+  AND reversed = FALSE
+  AND reversal_of IS NULL
+ORDER BY paid_at
+LIMIT $1 OFFSET $2
+`
+
+type ListUnassignedPaymentsParams struct {
+	Limit  int32 `json:"limit"`
+	Offset int32 `json:"offset"`
+}
+
+func (q *Queries) ListUnassignedPayments(ctx context.Context, arg ListUnassignedPaymentsParams) ([]Payment, error) {
+	rows, err := q.db.Query(ctx, listUnassignedPayments, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Payment{}
+	for rows.Next() {
+		var i Payment
+		if err := rows.Scan(
+			&i.ID,
+			&i.TransactionNumber,
+			&i.TransactionSource,
+			&i.PayingName,
+			&i.Amount,
+			&i.Assigned,
+			&i.AssignedTo,
+			&i.PaidAt,
+			&i.Reversed,
+			&i.ReversalOf,
+			&i.ReversalReason,
+			&i.PayingPhone,
+			&i.Ignored,
+			&i.IgnoredReason,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markPaymentReversed = `-- name: MarkPaymentReversed :exec
+UPDATE payments
+SET reversed = true,
+    reversal_reason = $1
+WHERE id = $2
+`
+
+type MarkPaymentReversedParams struct {
+	ReversalReason string `json:"reversal_reason"`
+	ID             int64  `json:"id"`
+}
+
+func (q *Queries) MarkPaymentReversed(ctx context.Context, arg MarkPaymentReversedParams) error {
+	_, err := q.db.Exec(ctx, markPaymentReversed, arg.ReversalReason, arg.ID)
+	return err
+}
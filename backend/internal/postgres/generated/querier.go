@@ -11,27 +11,54 @@ import (
 )
 
 type Querier interface {
+	AcceptSMSSubmission(ctx context.Context, arg AcceptSMSSubmissionParams) error
 	AddCustomerLoaned(ctx context.Context, arg AddCustomerLoanedParams) error
 	AssignPayment(ctx context.Context, arg AssignPaymentParams) (Payment, error)
+	BeginSMSSubmission(ctx context.Context, arg BeginSMSSubmissionParams) (SmsSubmission, error)
 	CheckPaymentAssigned(ctx context.Context, id int64) (bool, error)
 	CheckSMSDelivered(ctx context.Context, id int64) (bool, error)
+	ClaimSMSStatusHistory(ctx context.Context, refID string) ([]SmsStatusHistory, error)
 	CountCustomerLoans(ctx context.Context, customerID int64) (int64, error)
 	CountCustomerPayments(ctx context.Context, assignedTo pgtype.Int8) (int64, error)
+	CountCustomerRefunds(ctx context.Context, customerID int64) (int64, error)
+	CountCustomerRemindersSince(ctx context.Context, arg CountCustomerRemindersSinceParams) (int64, error)
 	CountCustomerSMS(ctx context.Context, customerID int64) (int64, error)
 	CountCustomers(ctx context.Context) (int64, error)
+	CountInboundSMS(ctx context.Context) (int64, error)
+	CountLoanReminders(ctx context.Context) (int64, error)
 	CountLoans(ctx context.Context) (int64, error)
+	CountOptedOutCustomers(ctx context.Context) (int64, error)
 	CountPayments(ctx context.Context, arg CountPaymentsParams) (int64, error)
 	CountSMS(ctx context.Context) (int64, error)
+	CountSMSCampaigns(ctx context.Context) (int64, error)
+	CountUnassignedPayments(ctx context.Context) (int64, error)
+	CountWebhookDeliveries(ctx context.Context, subscriptionID int64) (int64, error)
 	CreateCustomer(ctx context.Context, arg CreateCustomerParams) (Customer, error)
+	CreateInboundSMS(ctx context.Context, arg CreateInboundSMSParams) (SmsInbound, error)
 	CreateLoan(ctx context.Context, arg CreateLoanParams) (Loan, error)
+	CreateLoanReminder(ctx context.Context, arg CreateLoanReminderParams) (LoanReminder, error)
+	CreateOutboxTask(ctx context.Context, arg CreateOutboxTaskParams) error
 	CreatePayment(ctx context.Context, arg CreatePaymentParams) (Payment, error)
+	CreatePaymentReversal(ctx context.Context, arg CreatePaymentReversalParams) (Payment, error)
+	CreateRefund(ctx context.Context, arg CreateRefundParams) (Refund, error)
 	CreateSMS(ctx context.Context, arg CreateSMSParams) (Sm, error)
+	CreateSMSCampaign(ctx context.Context, arg CreateSMSCampaignParams) (SmsCampaign, error)
+	CreateSMSStatusHistory(ctx context.Context, arg CreateSMSStatusHistoryParams) (SmsStatusHistory, error)
+	CreateSMSTemplate(ctx context.Context, arg CreateSMSTemplateParams) (SmsTemplate, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	CreateWebhookDeliveries(ctx context.Context, arg CreateWebhookDeliveriesParams) ([]int64, error)
+	CreateWebhookSubscription(ctx context.Context, arg CreateWebhookSubscriptionParams) (WebhookSubscription, error)
+	DeductCustomerCredit(ctx context.Context, arg DeductCustomerCreditParams) (Customer, error)
 	DeleteCustomer(ctx context.Context, id int64) error
 	DeleteLoan(ctx context.Context, id int64) error
+	DeletePublishedOutboxTasks(ctx context.Context, publishedAt pgtype.Timestamptz) (int64, error)
+	DeleteSMSTemplate(ctx context.Context, id int64) error
 	DeleteUser(ctx context.Context, id int64) error
+	DeleteWebhookSubscription(ctx context.Context, id int64) (int64, error)
 	DeliverSMS(ctx context.Context, arg DeliverSMSParams) error
+	FailSMSSubmission(ctx context.Context, arg FailSMSSubmissionParams) error
 	GetCustomer(ctx context.Context, arg GetCustomerParams) (Customer, error)
+	GetCustomerByPhoneSuffix(ctx context.Context, phoneSuffix string) (Customer, error)
 	GetCustomerFullData(ctx context.Context, arg GetCustomerFullDataParams) (GetCustomerFullDataRow, error)
 	GetCustomerIDByName(ctx context.Context, name string) (int64, error)
 	GetCustomerList(ctx context.Context) ([]GetCustomerListRow, error)
@@ -39,20 +66,62 @@ type Querier interface {
 	GetDashboardStats(ctx context.Context) (GetDashboardStatsRow, error)
 	GetLoan(ctx context.Context, id int64) (GetLoanRow, error)
 	GetPayment(ctx context.Context, id int64) (GetPaymentRow, error)
+	GetPaymentForUpdate(ctx context.Context, id int64) (Payment, error)
 	GetSMS(ctx context.Context, id int64) (GetSMSRow, error)
+	GetSMSCampaign(ctx context.Context, id int64) (SmsCampaign, error)
+	GetSMSForDeliveryReport(ctx context.Context, arg GetSMSForDeliveryReportParams) (GetSMSForDeliveryReportRow, error)
+	GetSMSTemplate(ctx context.Context, id int64) (SmsTemplate, error)
+	GetSMSTemplateByName(ctx context.Context, name string) (SmsTemplate, error)
 	GetUser(ctx context.Context, arg GetUserParams) (User, error)
+	GetWebhookDelivery(ctx context.Context, id int64) (GetWebhookDeliveryRow, error)
+	GetWebhookSubscription(ctx context.Context, id int64) (WebhookSubscription, error)
+	IgnorePayment(ctx context.Context, arg IgnorePaymentParams) (Payment, error)
+	IsCustomerOptedOut(ctx context.Context, arg IsCustomerOptedOutParams) (bool, error)
+	ListCampaignRecipients(ctx context.Context, minBalance pgtype.Numeric) ([]int64, error)
 	ListCustomerLoans(ctx context.Context, arg ListCustomerLoansParams) ([]ListCustomerLoansRow, error)
 	ListCustomerPayments(ctx context.Context, arg ListCustomerPaymentsParams) ([]ListCustomerPaymentsRow, error)
+	ListCustomerRefunds(ctx context.Context, arg ListCustomerRefundsParams) ([]Refund, error)
 	ListCustomerSMS(ctx context.Context, arg ListCustomerSMSParams) ([]ListCustomerSMSRow, error)
+	ListCustomerSMSConsents(ctx context.Context, customerID int64) ([]SmsConsent, error)
 	ListCustomers(ctx context.Context, arg ListCustomersParams) ([]Customer, error)
+	ListDueInstalments(ctx context.Context, arg ListDueInstalmentsParams) ([]ListDueInstalmentsRow, error)
+	ListInboundSMS(ctx context.Context, arg ListInboundSMSParams) ([]ListInboundSMSRow, error)
+	ListLoanReminders(ctx context.Context, arg ListLoanRemindersParams) ([]ListLoanRemindersRow, error)
 	ListLoans(ctx context.Context, arg ListLoansParams) ([]ListLoansRow, error)
+	ListOptedOutCustomers(ctx context.Context, arg ListOptedOutCustomersParams) ([]ListOptedOutCustomersRow, error)
 	ListPayments(ctx context.Context, arg ListPaymentsParams) ([]ListPaymentsRow, error)
+	ListPaymentsByTransactionNumbers(ctx context.Context, arg ListPaymentsByTransactionNumbersParams) ([]Payment, error)
+	ListPendingOutboxTasks(ctx context.Context, limit int32) ([]TaskOutbox, error)
+	ListRecurringSMSCampaigns(ctx context.Context) ([]SmsCampaign, error)
 	ListSMS(ctx context.Context, arg ListSMSParams) ([]ListSMSRow, error)
+	ListSMSByRefIDs(ctx context.Context, refIds []string) ([]ListSMSByRefIDsRow, error)
+	ListSMSCampaigns(ctx context.Context, arg ListSMSCampaignsParams) ([]SmsCampaign, error)
+	ListSMSProviderBalances(ctx context.Context) ([]SmsProviderBalance, error)
+	ListSMSStatusHistory(ctx context.Context, smsID pgtype.Int8) ([]SmsStatusHistory, error)
+	ListSMSTemplates(ctx context.Context) ([]SmsTemplate, error)
+	ListSourcePayments(ctx context.Context, arg ListSourcePaymentsParams) ([]Payment, error)
+	ListUnassignedPayments(ctx context.Context, arg ListUnassignedPaymentsParams) ([]Payment, error)
 	ListUsers(ctx context.Context) ([]ListUsersRow, error)
+	ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error)
+	ListWebhookSubscriptions(ctx context.Context) ([]WebhookSubscription, error)
+	MarkOutboxTaskFailed(ctx context.Context, arg MarkOutboxTaskFailedParams) error
+	MarkOutboxTaskPublished(ctx context.Context, id int64) error
+	MarkPaymentReversed(ctx context.Context, arg MarkPaymentReversedParams) error
+	MarkSMSCampaignRun(ctx context.Context, id int64) error
+	MarkSMSResend(ctx context.Context, id int64) error
+	RecordWebhookAttempt(ctx context.Context, arg RecordWebhookAttemptParams) error
 	ReduceCustomerLoaned(ctx context.Context, arg ReduceCustomerLoanedParams) (Customer, error)
+	ResetWebhookDelivery(ctx context.Context, id int64) error
+	SetSMSProviderBalanceAlerted(ctx context.Context, arg SetSMSProviderBalanceAlertedParams) error
 	UpdateCustomer(ctx context.Context, arg UpdateCustomerParams) (Customer, error)
+	UpdateLoanDueDate(ctx context.Context, arg UpdateLoanDueDateParams) (Loan, error)
 	UpdateSMS(ctx context.Context, arg UpdateSMSParams) error
+	UpdateSMSCampaignStatus(ctx context.Context, arg UpdateSMSCampaignStatusParams) (SmsCampaign, error)
+	UpdateSMSTemplate(ctx context.Context, arg UpdateSMSTemplateParams) (SmsTemplate, error)
 	UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error)
+	UpdateWebhookSubscription(ctx context.Context, arg UpdateWebhookSubscriptionParams) (WebhookSubscription, error)
+	UpsertSMSConsent(ctx context.Context, arg UpsertSMSConsentParams) (SmsConsent, error)
+	UpsertSMSProviderBalance(ctx context.Context, arg UpsertSMSProviderBalanceParams) (SmsProviderBalance, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -30,7 +30,10 @@ func (q *Queries) CheckSMSDelivered(ctx context.Context, id int64) (bool, error)
 }
 
 const countCustomerSMS = `-- name: CountCustomerSMS :one
-SELECT COUNT(*) AS total_sms FROM sms WHERE customer_id = $1
+SELECT (
+  (SELECT COUNT(*) FROM sms WHERE sms.customer_id = $1) +
+  (SELECT COUNT(*) FROM sms_inbound WHERE sms_inbound.customer_id = $1)
+)::bigint AS total_sms
 `
 
 func (q *Queries) CountCustomerSMS(ctx context.Context, customerID int64) (int64, error) {
@@ -40,6 +43,17 @@ func (q *Queries) CountCustomerSMS(ctx context.Context, customerID int64) (int64
 	return total_sms, err
 }
 
+const countInboundSMS = `-- name: CountInboundSMS :one
+SELECT COUNT(*) AS total_sms FROM sms_inbound
+`
+
+func (q *Queries) CountInboundSMS(ctx context.Context) (int64, error) {
+	row := q.db.QueryRow(ctx, countInboundSMS)
+	var total_sms int64
+	err := row.Scan(&total_sms)
+	return total_sms, err
+}
+
 const countSMS = `-- name: CountSMS :one
 SELECT COUNT(*) AS total_sms FROM sms
 `
@@ -51,13 +65,52 @@ func (q *Queries) CountSMS(ctx context.Context) (int64, error) {
 	return total_sms, err
 }
 
+const createInboundSMS = `-- name: CreateInboundSMS :one
+INSERT INTO sms_inbound (
+    customer_id, phone_number, message, keyword, provider_message_id
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+ON CONFLICT (provider_message_id) WHERE provider_message_id != '' DO NOTHING
+RETURNING id, customer_id, phone_number, message, keyword, provider_message_id, received_at
+`
+
+type CreateInboundSMSParams struct {
+	CustomerID        pgtype.Int8 `json:"customer_id"`
+	PhoneNumber       string      `json:"phone_number"`
+	Message           string      `json:"message"`
+	Keyword           string      `json:"keyword"`
+	ProviderMessageID string      `json:"provider_message_id"`
+}
+
+func (q *Queries) CreateInboundSMS(ctx context.Context, arg CreateInboundSMSParams) (SmsInbound, error) {
+	row := q.db.QueryRow(ctx, createInboundSMS,
+		arg.CustomerID,
+		arg.PhoneNumber,
+		arg.Message,
+		arg.Keyword,
+		arg.ProviderMessageID,
+	)
+	var i SmsInbound
+	err := row.Scan(
+		&i.ID,
+		&i.CustomerID,
+		&i.PhoneNumber,
+		&i.Message,
+		&i.Keyword,
+		&i.ProviderMessageID,
+		&i.ReceivedAt,
+	)
+	return i, err
+}
+
 const createSMS = `-- name: CreateSMS :one
 INSERT INTO sms (
     customer_id, message, type, ref_id
 ) VALUES (
     $1, $2, $3, $4
 )
-RETURNING id, customer_id, message, type, status, created_at, ref_id, cost, description, callback_status
+RETURNING id, customer_id, message, type, status, created_at, ref_id, cost, description, callback_status, provider, provider_message_id, resend_count
 `
 
 type CreateSMSParams struct {
@@ -86,6 +139,9 @@ func (q *Queries) CreateSMS(ctx context.Context, arg CreateSMSParams) (Sm, error
 		&i.Cost,
 		&i.Description,
 		&i.CallbackStatus,
+		&i.Provider,
+		&i.ProviderMessageID,
+		&i.ResendCount,
 	)
 	return i, err
 }
@@ -108,7 +164,7 @@ func (q *Queries) DeliverSMS(ctx context.Context, arg DeliverSMSParams) error {
 
 const getSMS = `-- name: GetSMS :one
 SELECT
-    sms.id, sms.customer_id, sms.message, sms.type, sms.status, sms.created_at, sms.ref_id, sms.cost, sms.description, sms.callback_status,
+    sms.id, sms.customer_id, sms.message, sms.type, sms.status, sms.created_at, sms.ref_id, sms.cost, sms.description, sms.callback_status, sms.provider, sms.provider_message_id, sms.resend_count,
     customers.name AS customer_name, 
     customers.phone_number AS customer_phone_number
 FROM sms
@@ -118,18 +174,21 @@ ORDER BY sms.created_at DESC
 `
 
 type GetSMSRow struct {
-	ID                  int64     `json:"id"`
-	CustomerID          int64     `json:"customer_id"`
-	Message             string    `json:"message"`
-	Type                string    `json:"type"`
-	Status              string    `json:"status"`
-	CreatedAt           time.Time `json:"created_at"`
-	RefID               string    `json:"ref_id"`
-	Cost                string    `json:"cost"`
-	Description         string    `json:"description"`
-	CallbackStatus      string    `json:"callback_status"`
-	CustomerName        string    `json:"customer_name"`
-	CustomerPhoneNumber string    `json:"customer_phone_number"`
+	ID                  int64          `json:"id"`
+	CustomerID          int64          `json:"customer_id"`
+	Message             string         `json:"message"`
+	Type                string         `json:"type"`
+	Status              string         `json:"status"`
+	CreatedAt           time.Time      `json:"created_at"`
+	RefID               string         `json:"ref_id"`
+	Cost                pgtype.Numeric `json:"cost"`
+	Description         string         `json:"description"`
+	CallbackStatus      string         `json:"callback_status"`
+	Provider            string         `json:"provider"`
+	ProviderMessageID   string         `json:"provider_message_id"`
+	ResendCount         int32          `json:"resend_count"`
+	CustomerName        string         `json:"customer_name"`
+	CustomerPhoneNumber string         `json:"customer_phone_number"`
 }
 
 func (q *Queries) GetSMS(ctx context.Context, id int64) (GetSMSRow, error) {
@@ -146,6 +205,9 @@ func (q *Queries) GetSMS(ctx context.Context, id int64) (GetSMSRow, error) {
 		&i.Cost,
 		&i.Description,
 		&i.CallbackStatus,
+		&i.Provider,
+		&i.ProviderMessageID,
+		&i.ResendCount,
 		&i.CustomerName,
 		&i.CustomerPhoneNumber,
 	)
@@ -153,40 +215,53 @@ func (q *Queries) GetSMS(ctx context.Context, id int64) (GetSMSRow, error) {
 }
 
 const listCustomerSMS = `-- name: ListCustomerSMS :many
-SELECT 
-  sms.id, sms.customer_id, sms.message, sms.type, sms.status, sms.created_at, sms.ref_id, sms.cost, sms.description, sms.callback_status, 
-  customers.name AS customer_name, 
+SELECT
+  thread.id, thread.customer_id, thread.message, thread.type, thread.status, thread.created_at,
+  thread.ref_id, thread.cost, thread.description, thread.callback_status, thread.direction,
+  customers.name AS customer_name,
   customers.phone_number AS customer_phone_number
-FROM sms
-JOIN customers ON customers.id = sms.customer_id
-WHERE sms.customer_id = $1
-ORDER BY sms.created_at DESC
-LIMIT $3 OFFSET $2
+FROM (
+  SELECT
+    sms.id, sms.customer_id, sms.message, sms.type, sms.status, sms.created_at,
+    sms.ref_id, sms.cost, sms.description, sms.callback_status, 'outbound'::varchar AS direction
+  FROM sms
+  WHERE sms.customer_id = $1
+  UNION ALL
+  SELECT
+    sms_inbound.id, sms_inbound.customer_id, sms_inbound.message, 'reply', 'received', sms_inbound.received_at,
+    sms_inbound.provider_message_id, 0, '', '', 'inbound'::varchar
+  FROM sms_inbound
+  WHERE sms_inbound.customer_id = $1
+) AS thread
+JOIN customers ON customers.id = thread.customer_id
+ORDER BY thread.created_at DESC
+LIMIT $2 OFFSET $3
 `
 
 type ListCustomerSMSParams struct {
 	CustomerID int64 `json:"customer_id"`
-	Offset     int32 `json:"offset"`
 	Limit      int32 `json:"limit"`
+	Offset     int32 `json:"offset"`
 }
 
 type ListCustomerSMSRow struct {
-	ID                  int64     `json:"id"`
-	CustomerID          int64     `json:"customer_id"`
-	Message             string    `json:"message"`
-	Type                string    `json:"type"`
-	Status              string    `json:"status"`
-	CreatedAt           time.Time `json:"created_at"`
-	RefID               string    `json:"ref_id"`
-	Cost                string    `json:"cost"`
-	Description         string    `json:"description"`
-	CallbackStatus      string    `json:"callback_status"`
-	CustomerName        string    `json:"customer_name"`
-	CustomerPhoneNumber string    `json:"customer_phone_number"`
+	ID                  int64          `json:"id"`
+	CustomerID          int64          `json:"customer_id"`
+	Message             string         `json:"message"`
+	Type                string         `json:"type"`
+	Status              string         `json:"status"`
+	CreatedAt           time.Time      `json:"created_at"`
+	RefID               string         `json:"ref_id"`
+	Cost                pgtype.Numeric `json:"cost"`
+	Description         string         `json:"description"`
+	CallbackStatus      string         `json:"callback_status"`
+	Direction           string         `json:"direction"`
+	CustomerName        string         `json:"customer_name"`
+	CustomerPhoneNumber string         `json:"customer_phone_number"`
 }
 
 func (q *Queries) ListCustomerSMS(ctx context.Context, arg ListCustomerSMSParams) ([]ListCustomerSMSRow, error) {
-	rows, err := q.db.Query(ctx, listCustomerSMS, arg.CustomerID, arg.Offset, arg.Limit)
+	rows, err := q.db.Query(ctx, listCustomerSMS, arg.CustomerID, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
@@ -205,6 +280,7 @@ func (q *Queries) ListCustomerSMS(ctx context.Context, arg ListCustomerSMSParams
 			&i.Cost,
 			&i.Description,
 			&i.CallbackStatus,
+			&i.Direction,
 			&i.CustomerName,
 			&i.CustomerPhoneNumber,
 		); err != nil {
@@ -218,9 +294,64 @@ func (q *Queries) ListCustomerSMS(ctx context.Context, arg ListCustomerSMSParams
 	return items, nil
 }
 
+const listInboundSMS = `-- name: ListInboundSMS :many
+SELECT
+  sms_inbound.id, sms_inbound.customer_id, sms_inbound.phone_number, sms_inbound.message, sms_inbound.keyword, sms_inbound.provider_message_id, sms_inbound.received_at,
+  COALESCE(customers.name, '')::varchar AS customer_name
+FROM sms_inbound
+LEFT JOIN customers ON customers.id = sms_inbound.customer_id
+ORDER BY sms_inbound.received_at DESC
+LIMIT $1 OFFSET $2
+`
+
+type ListInboundSMSParams struct {
+	Limit  int32 `json:"limit"`
+	Offset int32 `json:"offset"`
+}
+
+type ListInboundSMSRow struct {
+	ID                int64       `json:"id"`
+	CustomerID        pgtype.Int8 `json:"customer_id"`
+	PhoneNumber       string      `json:"phone_number"`
+	Message           string      `json:"message"`
+	Keyword           string      `json:"keyword"`
+	ProviderMessageID string      `json:"provider_message_id"`
+	ReceivedAt        time.Time   `json:"received_at"`
+	CustomerName      string      `json:"customer_name"`
+}
+
+func (q *Queries) ListInboundSMS(ctx context.Context, arg ListInboundSMSParams) ([]ListInboundSMSRow, error) {
+	rows, err := q.db.Query(ctx, listInboundSMS, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListInboundSMSRow{}
+	for rows.Next() {
+		var i ListInboundSMSRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CustomerID,
+			&i.PhoneNumber,
+			&i.Message,
+			&i.Keyword,
+			&i.ProviderMessageID,
+			&i.ReceivedAt,
+			&i.CustomerName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listSMS = `-- name: ListSMS :many
 SELECT 
-  sms.id, sms.customer_id, sms.message, sms.type, sms.status, sms.created_at, sms.ref_id, sms.cost, sms.description, sms.callback_status, 
+  sms.id, sms.customer_id, sms.message, sms.type, sms.status, sms.created_at, sms.ref_id, sms.cost, sms.description, sms.callback_status, sms.provider, sms.provider_message_id, sms.resend_count, 
   customers.name AS customer_name, 
   customers.phone_number AS customer_phone_number
 FROM sms
@@ -235,18 +366,21 @@ type ListSMSParams struct {
 }
 
 type ListSMSRow struct {
-	ID                  int64     `json:"id"`
-	CustomerID          int64     `json:"customer_id"`
-	Message             string    `json:"message"`
-	Type                string    `json:"type"`
-	Status              string    `json:"status"`
-	CreatedAt           time.Time `json:"created_at"`
-	RefID               string    `json:"ref_id"`
-	Cost                string    `json:"cost"`
-	Description         string    `json:"description"`
-	CallbackStatus      string    `json:"callback_status"`
-	CustomerName        string    `json:"customer_name"`
-	CustomerPhoneNumber string    `json:"customer_phone_number"`
+	ID                  int64          `json:"id"`
+	CustomerID          int64          `json:"customer_id"`
+	Message             string         `json:"message"`
+	Type                string         `json:"type"`
+	Status              string         `json:"status"`
+	CreatedAt           time.Time      `json:"created_at"`
+	RefID               string         `json:"ref_id"`
+	Cost                pgtype.Numeric `json:"cost"`
+	Description         string         `json:"description"`
+	CallbackStatus      string         `json:"callback_status"`
+	Provider            string         `json:"provider"`
+	ProviderMessageID   string         `json:"provider_message_id"`
+	ResendCount         int32          `json:"resend_count"`
+	CustomerName        string         `json:"customer_name"`
+	CustomerPhoneNumber string         `json:"customer_phone_number"`
 }
 
 func (q *Queries) ListSMS(ctx context.Context, arg ListSMSParams) ([]ListSMSRow, error) {
@@ -269,6 +403,9 @@ func (q *Queries) ListSMS(ctx context.Context, arg ListSMSParams) ([]ListSMSRow,
 			&i.Cost,
 			&i.Description,
 			&i.CallbackStatus,
+			&i.Provider,
+			&i.ProviderMessageID,
+			&i.ResendCount,
 			&i.CustomerName,
 			&i.CustomerPhoneNumber,
 		); err != nil {
@@ -282,21 +419,58 @@ func (q *Queries) ListSMS(ctx context.Context, arg ListSMSParams) ([]ListSMSRow,
 	return items, nil
 }
 
+const listSMSByRefIDs = `-- name: ListSMSByRefIDs :many
+SELECT id, ref_id FROM sms
+WHERE ref_id = ANY($1::text[])
+`
+
+type ListSMSByRefIDsRow struct {
+	ID    int64  `json:"id"`
+	RefID string `json:"ref_id"`
+}
+
+func (q *Queries) ListSMSByRefIDs(ctx context.Context, refIds []string) ([]ListSMSByRefIDsRow, error) {
+	rows, err := q.db.Query(ctx, listSMSByRefIDs, refIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListSMSByRefIDsRow{}
+	for rows.Next() {
+		var i ListSMSByRefIDsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.RefID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const updateSMS = `-- name: UpdateSMS :exec
 UPDATE sms
 SET cost = coalesce($1, cost),
     description = coalesce($2, description),
     callback_status = coalesce($3, callback_status),
-    status = coalesce($4, status)
-WHERE ref_id = $5
+    status = coalesce($4, status),
+    provider = coalesce($5, provider),
+    provider_message_id = coalesce($6, provider_message_id)
+WHERE ref_id = $7
 `
 
 type UpdateSMSParams struct {
-	Cost           pgtype.Text `json:"cost"`
-	Description    pgtype.Text `json:"description"`
-	CallbackStatus pgtype.Text `json:"callback_status"`
-	Status         pgtype.Text `json:"status"`
-	RefID          string      `json:"ref_id"`
+	Cost              pgtype.Numeric `json:"cost"`
+	Description       pgtype.Text    `json:"description"`
+	CallbackStatus    pgtype.Text    `json:"callback_status"`
+	Status            pgtype.Text    `json:"status"`
+	Provider          pgtype.Text    `json:"provider"`
+	ProviderMessageID pgtype.Text    `json:"provider_message_id"`
+	RefID             string         `json:"ref_id"`
 }
 
 func (q *Queries) UpdateSMS(ctx context.Context, arg UpdateSMSParams) error {
@@ -305,6 +479,8 @@ func (q *Queries) UpdateSMS(ctx context.Context, arg UpdateSMSParams) error {
 		arg.Description,
 		arg.CallbackStatus,
 		arg.Status,
+		arg.Provider,
+		arg.ProviderMessageID,
 		arg.RefID,
 	)
 	return err
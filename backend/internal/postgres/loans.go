@@ -2,9 +2,11 @@ package postgres
 
 import (
 	"context"
+	"time"
 
 	"github.com/EmilioCliff/jonche/internal/postgres/generated"
 	"github.com/EmilioCliff/jonche/internal/repository"
+	"github.com/EmilioCliff/jonche/internal/services"
 	"github.com/EmilioCliff/jonche/pkg"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
@@ -40,10 +42,16 @@ func (l *LoanRepository) CreateLoan(
 				err.Error(),
 			)
 		}
+		dueDate, err := dateFromString(loan.DueDate)
+		if err != nil {
+			return err
+		}
+
 		rslt, err := q.CreateLoan(ctx, generated.CreateLoanParams{
 			CustomerID:  int64(loan.CustomerID),
 			Description: loan.Description,
 			Amount:      amount,
+			DueDate:     dueDate,
 		})
 		if err != nil {
 			if pkg.PgxErrorCode(err) == pkg.FOREIGN_KEY_VIOLATION {
@@ -58,9 +66,10 @@ func (l *LoanRepository) CreateLoan(
 		rsp.Description = rslt.Description
 		rsp.Amount = numericToFloat64(rslt.Amount)
 		rsp.CreatedAt = rslt.CreatedAt
+		rsp.DueDate = dateToString(rslt.DueDate)
 
 		if err := q.AddCustomerLoaned(ctx, generated.AddCustomerLoanedParams{
-			Loaned: amount,
+			Amount: amount,
 			ID:     int64(loan.CustomerID),
 		}); err != nil {
 			return pkg.Errorf(
@@ -70,7 +79,14 @@ func (l *LoanRepository) CreateLoan(
 			)
 		}
 
-		return nil
+		return publishEvent(ctx, q, services.EventLoanCreated, services.LoanEvent{
+			ID:          rsp.ID,
+			CustomerID:  rsp.CustomerID,
+			Description: rsp.Description,
+			Amount:      rsp.Amount,
+			DueDate:     rsp.DueDate,
+			CreatedAt:   rsp.CreatedAt,
+		})
 	})
 
 	return &rsp, err
@@ -100,6 +116,7 @@ func (l *LoanRepository) ListLoan(
 			Description: loan.Description,
 			Amount:      numericToFloat64(loan.Amount),
 			CreatedAt:   loan.CreatedAt,
+			DueDate:     dateToString(loan.DueDate),
 			CustomerDetails: &repository.Customer{
 				ID:          uint32(loan.CustomerID),
 				Name:        loan.CustomerName,
@@ -154,6 +171,7 @@ func (l *LoanRepository) ListCustomerLoans(
 			Description: loan.Description,
 			Amount:      numericToFloat64(loan.Amount),
 			CreatedAt:   loan.CreatedAt,
+			DueDate:     dateToString(loan.DueDate),
 			CustomerDetails: &repository.Customer{
 				ID:          uint32(loan.CustomerID),
 				Name:        loan.CustomerName,
@@ -194,6 +212,7 @@ func (l *LoanRepository) GetLoan(ctx context.Context, id uint32) (*repository.Lo
 		Description: loan.Description,
 		Amount:      numericToFloat64(loan.Amount),
 		CreatedAt:   loan.CreatedAt,
+		DueDate:     dateToString(loan.DueDate),
 		CustomerDetails: &repository.Customer{
 			ID:          uint32(loan.CustomerID),
 			Name:        loan.CustomerName,
@@ -214,3 +233,58 @@ func (l *LoanRepository) DeleteLoan(ctx context.Context, id uint32) error {
 
 	return nil
 }
+
+// UpdateLoanDueDate sets or, with a nil dueDate, clears when a loan should be
+// repaid. Reminders follow the new date.
+func (l *LoanRepository) UpdateLoanDueDate(
+	ctx context.Context,
+	id uint32,
+	dueDate *time.Time,
+) (*repository.Loan, error) {
+	var date pgtype.Date
+	if dueDate != nil {
+		date = pgtype.Date{Valid: true, Time: *dueDate}
+	}
+
+	rslt, err := l.queries.UpdateLoanDueDate(ctx, generated.UpdateLoanDueDateParams{
+		DueDate: date,
+		ID:      int64(id),
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "loan not found")
+		}
+
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error updating loan due date: %s", err.Error())
+	}
+
+	return &repository.Loan{
+		ID:          uint32(rslt.ID),
+		CustomerID:  uint32(rslt.CustomerID),
+		Description: rslt.Description,
+		Amount:      numericToFloat64(rslt.Amount),
+		CreatedAt:   rslt.CreatedAt,
+		DueDate:     dateToString(rslt.DueDate),
+	}, nil
+}
+
+func dateFromString(date string) (pgtype.Date, error) {
+	if date == "" {
+		return pgtype.Date{}, nil
+	}
+
+	t, err := time.Parse(time.DateOnly, date)
+	if err != nil {
+		return pgtype.Date{}, pkg.Errorf(pkg.INVALID_ERROR, "invalid date %q, use YYYY-MM-DD", date)
+	}
+
+	return pgtype.Date{Valid: true, Time: t}, nil
+}
+
+func dateToString(date pgtype.Date) string {
+	if !date.Valid {
+		return ""
+	}
+
+	return date.Time.Format(time.DateOnly)
+}
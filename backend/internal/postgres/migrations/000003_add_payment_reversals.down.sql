@@ -0,0 +1,5 @@
+ALTER TABLE payments DROP CONSTRAINT "payments_reversal_of_fkey";
+
+ALTER TABLE payments DROP reversal_reason;
+ALTER TABLE payments DROP reversal_of;
+ALTER TABLE payments DROP reversed;
\ No newline at end of file
@@ -0,0 +1,7 @@
+ALTER TABLE payments ADD reversed BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE payments ADD reversal_of BIGINT;
+ALTER TABLE payments ADD reversal_reason TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE payments ADD CONSTRAINT "payments_reversal_of_fkey" FOREIGN KEY ("reversal_of") REFERENCES "payments" ("id");
+
+CREATE INDEX ON "payments" ("reversal_of");
\ No newline at end of file
@@ -0,0 +1,6 @@
+ALTER TABLE "refunds" DROP CONSTRAINT "refunds_customer_id_fkey";
+
+DROP TABLE "refunds";
+
+UPDATE customers SET loaned = loaned - credit;
+ALTER TABLE customers DROP credit;
\ No newline at end of file
@@ -0,0 +1,19 @@
+ALTER TABLE customers ADD credit NUMERIC(12,2) NOT NULL DEFAULT 0;
+
+UPDATE customers SET credit = -loaned, loaned = 0 WHERE loaned < 0;
+
+CREATE TABLE "refunds" (
+  "id" bigserial PRIMARY KEY,
+  "customer_id" bigint NOT NULL,
+  "amount" numeric(12,2) NOT NULL,
+  "method" varchar(255) NOT NULL,
+  "reference" varchar(255) NOT NULL,
+  "description" text NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+
+  CONSTRAINT "refunds_customer_id_fkey" FOREIGN KEY ("customer_id") REFERENCES "customers" ("id")
+);
+
+CREATE INDEX ON "refunds" ("customer_id");
+
+COMMENT ON COLUMN "customers"."credit" IS 'overpaid amount applied to the next loan';
\ No newline at end of file
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS "payments_transaction_number_idx";
\ No newline at end of file
@@ -0,0 +1 @@
+CREATE INDEX ON "payments" ("transaction_number");
\ No newline at end of file
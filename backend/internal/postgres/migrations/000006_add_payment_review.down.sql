@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS payments_unassigned_idx;
+
+ALTER TABLE payments DROP ignored_reason;
+ALTER TABLE payments DROP ignored;
+ALTER TABLE payments DROP paying_phone;
\ No newline at end of file
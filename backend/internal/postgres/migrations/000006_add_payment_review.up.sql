@@ -0,0 +1,7 @@
+ALTER TABLE payments ADD paying_phone TEXT NOT NULL DEFAULT '';
+ALTER TABLE payments ADD ignored BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE payments ADD ignored_reason TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX payments_unassigned_idx ON "payments" ("paid_at") WHERE assigned = false AND ignored = false;
+
+COMMENT ON COLUMN "payments"."ignored" IS 'reviewed and marked as not a loan repayment';
\ No newline at end of file
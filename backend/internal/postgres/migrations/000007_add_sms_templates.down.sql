@@ -0,0 +1 @@
+DROP TABLE IF EXISTS sms_templates;
\ No newline at end of file
@@ -0,0 +1,16 @@
+CREATE TABLE "sms_templates" (
+  "id" bigserial PRIMARY KEY,
+  "name" varchar(255) UNIQUE NOT NULL,
+  "body" text NOT NULL,
+  "system" bool NOT NULL DEFAULT false,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "sms_templates"."system" IS 'used by the application, can be edited but not renamed or deleted';
+
+INSERT INTO sms_templates (name, body, system) VALUES (
+  'payment_confirmation',
+  'Hello {{.Name}}, we have received your payment of KES {{.Paid}} on {{.PaidDate}}. Your new balance is KES {{.Loaned}}.{{if .Credit}} You have a credit of KES {{.Credit}} which will be applied to your next loan.{{end}} Thank you!',
+  true
+);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS sms_campaigns;
\ No newline at end of file
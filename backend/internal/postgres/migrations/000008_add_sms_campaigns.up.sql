@@ -0,0 +1,22 @@
+CREATE TABLE "sms_campaigns" (
+  "id" bigserial PRIMARY KEY,
+  "name" varchar(255) NOT NULL,
+  "message" text NOT NULL DEFAULT '',
+  "template_id" bigint,
+  "min_balance" numeric(12,2),
+  "send_at" timestamptz,
+  "cron_spec" varchar(255) NOT NULL DEFAULT '',
+  "status" varchar(50) NOT NULL DEFAULT 'scheduled',
+  "last_run_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+
+  CONSTRAINT "sms_campaigns_template_id_fkey" FOREIGN KEY ("template_id") REFERENCES "sms_templates" ("id")
+);
+
+CREATE INDEX ON "sms_campaigns" ("status");
+
+COMMENT ON COLUMN "sms_campaigns"."min_balance" IS 'only customers owing more than this, every active customer when null';
+
+COMMENT ON COLUMN "sms_campaigns"."cron_spec" IS 'recurring campaigns only, one-off campaigns use send_at';
+
+COMMENT ON COLUMN "sms_campaigns"."status" IS 'scheduled, paused, cancelled or completed';
\ No newline at end of file
@@ -0,0 +1,5 @@
+DELETE FROM sms_templates WHERE name IN ('reminder_before', 'reminder_due', 'reminder_overdue');
+
+DROP TABLE IF EXISTS loan_reminders;
+
+ALTER TABLE "loans" DROP COLUMN IF EXISTS "due_date";
\ No newline at end of file
@@ -0,0 +1,39 @@
+ALTER TABLE "loans" ADD COLUMN "due_date" date;
+
+CREATE INDEX ON "loans" ("due_date");
+
+COMMENT ON COLUMN "loans"."due_date" IS 'when the loan is expected to be repaid, reminders are only sent for loans with one';
+
+CREATE TABLE "loan_reminders" (
+  "id" bigserial PRIMARY KEY,
+  "loan_id" bigint NOT NULL,
+  "customer_id" bigint NOT NULL,
+  "stage" varchar(255) NOT NULL,
+  "sms_ref_id" text NOT NULL,
+  "sent_at" timestamptz NOT NULL DEFAULT (now()),
+
+  CONSTRAINT "loan_reminders_loan_id_fkey" FOREIGN KEY ("loan_id") REFERENCES "loans" ("id") ON DELETE CASCADE,
+  CONSTRAINT "loan_reminders_customer_id_fkey" FOREIGN KEY ("customer_id") REFERENCES "customers" ("id"),
+  CONSTRAINT "loan_reminders_loan_id_stage_key" UNIQUE ("loan_id", "stage")
+);
+
+CREATE INDEX ON "loan_reminders" ("customer_id", "sent_at");
+
+COMMENT ON COLUMN "loan_reminders"."stage" IS 'before_<days>, due or overdue_<days>';
+
+INSERT INTO sms_templates (name, body, system) VALUES
+(
+  'reminder_before',
+  'Hello {{.Name}}, this is a reminder that KES {{.DueAmount}} is due on {{.DueDate}}. Your balance is KES {{.Loaned}}. Thank you!',
+  true
+),
+(
+  'reminder_due',
+  'Hello {{.Name}}, KES {{.DueAmount}} is due today, {{.DueDate}}. Your balance is KES {{.Loaned}}. Thank you!',
+  true
+),
+(
+  'reminder_overdue',
+  'Hello {{.Name}}, your payment of KES {{.DueAmount}} due on {{.DueDate}} is {{.DaysOverdue}} days overdue. Please pay to clear your balance of KES {{.Loaned}}.',
+  true
+);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS sms_consents;
\ No newline at end of file
@@ -0,0 +1,17 @@
+CREATE TABLE "sms_consents" (
+  "customer_id" bigint NOT NULL,
+  "category" varchar(50) NOT NULL,
+  "opted_out" bool NOT NULL DEFAULT false,
+  "source" varchar(50) NOT NULL,
+  "reason" text NOT NULL DEFAULT '',
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+
+  PRIMARY KEY ("customer_id", "category"),
+  CONSTRAINT "sms_consents_customer_id_fkey" FOREIGN KEY ("customer_id") REFERENCES "customers" ("id") ON DELETE CASCADE
+);
+
+CREATE INDEX ON "sms_consents" ("opted_out");
+
+COMMENT ON COLUMN "sms_consents"."category" IS 'transactional or marketing, payment receipts are always sent';
+
+COMMENT ON COLUMN "sms_consents"."source" IS 'keyword when the customer texted STOP or START, manual when set by staff';
\ No newline at end of file
@@ -0,0 +1,3 @@
+DELETE FROM sms_templates WHERE name = 'balance_reply';
+
+DROP TABLE IF EXISTS sms_inbound;
\ No newline at end of file
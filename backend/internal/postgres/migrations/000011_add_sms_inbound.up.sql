@@ -0,0 +1,25 @@
+CREATE TABLE "sms_inbound" (
+  "id" bigserial PRIMARY KEY,
+  "customer_id" bigint,
+  "phone_number" varchar(255) NOT NULL,
+  "message" text NOT NULL,
+  "keyword" varchar(50) NOT NULL DEFAULT '',
+  "provider_message_id" varchar(255) NOT NULL DEFAULT '',
+  "received_at" timestamptz NOT NULL DEFAULT (now()),
+
+  CONSTRAINT "sms_inbound_customer_id_fkey" FOREIGN KEY ("customer_id") REFERENCES "customers" ("id") ON DELETE SET NULL
+);
+
+CREATE INDEX ON "sms_inbound" ("customer_id");
+
+CREATE UNIQUE INDEX "sms_inbound_provider_message_id_key" ON "sms_inbound" ("provider_message_id") WHERE "provider_message_id" != '';
+
+COMMENT ON COLUMN "sms_inbound"."customer_id" IS 'matched on the last nine digits of the sender, null for unknown numbers';
+
+COMMENT ON COLUMN "sms_inbound"."keyword" IS 'STOP, START or BAL when the message was a command';
+
+INSERT INTO sms_templates (name, body, system) VALUES (
+  'balance_reply',
+  'Hello {{.Name}}, your balance is KES {{.Loaned}}.{{if .Credit}} You have a credit of KES {{.Credit}}.{{end}} Thank you!',
+  true
+);
\ No newline at end of file
@@ -0,0 +1,7 @@
+ALTER TABLE sms ALTER COLUMN cost DROP DEFAULT;
+
+ALTER TABLE sms ALTER COLUMN cost TYPE VARCHAR(255) USING cost::text;
+
+ALTER TABLE sms ALTER COLUMN cost SET DEFAULT 'N/A';
+
+COMMENT ON COLUMN "sms"."cost" IS NULL;
\ No newline at end of file
@@ -0,0 +1,7 @@
+ALTER TABLE sms ALTER COLUMN cost DROP DEFAULT;
+
+ALTER TABLE sms ALTER COLUMN cost TYPE numeric(12,4) USING COALESCE(substring(cost FROM '[0-9]+\.?[0-9]*')::numeric, 0);
+
+ALTER TABLE sms ALTER COLUMN cost SET DEFAULT 0;
+
+COMMENT ON COLUMN "sms"."cost" IS 'what the provider charged in KES, 0 until the message is sent';
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "sms_provider_balances";
\ No newline at end of file
@@ -0,0 +1,10 @@
+CREATE TABLE "sms_provider_balances" (
+  "provider" varchar(50) PRIMARY KEY,
+  "balance" numeric(12,4) NOT NULL,
+  "alerted" bool NOT NULL DEFAULT false,
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "sms_provider_balances"."balance" IS 'credit left as last reported by the gateway after a send';
+
+COMMENT ON COLUMN "sms_provider_balances"."alerted" IS 'set once admins were told the balance is low, cleared after a top up';
\ No newline at end of file
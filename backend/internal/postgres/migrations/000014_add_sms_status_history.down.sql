@@ -0,0 +1,17 @@
+DROP TABLE IF EXISTS "sms_status_history";
+
+DROP INDEX IF EXISTS "sms_ref_id_idx";
+
+ALTER TABLE "sms" DROP COLUMN IF EXISTS "resend_count";
+
+ALTER TABLE "sms" DROP COLUMN IF EXISTS "provider_message_id";
+
+ALTER TABLE "sms" DROP COLUMN IF EXISTS "provider";
+
+ALTER TABLE "sms" DROP CONSTRAINT IF EXISTS "sms_status_check";
+
+ALTER TABLE "sms" ALTER COLUMN "status" SET DEFAULT 'undelivered';
+
+UPDATE "sms" SET "status" = 'undelivered' WHERE "status" != 'delivered';
+
+COMMENT ON COLUMN "sms"."status" IS 'delivered or undelivered';
\ No newline at end of file
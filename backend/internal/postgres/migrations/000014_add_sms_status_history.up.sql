@@ -0,0 +1,43 @@
+UPDATE "sms" SET "status" = 'pending' WHERE "status" != 'delivered';
+
+ALTER TABLE "sms" ALTER COLUMN "status" SET DEFAULT 'pending';
+
+ALTER TABLE "sms" ADD CONSTRAINT "sms_status_check" CHECK ("status" IN ('pending', 'delivered', 'failed', 'expired', 'rejected'));
+
+ALTER TABLE "sms" ADD "provider" varchar(50) NOT NULL DEFAULT '';
+
+ALTER TABLE "sms" ADD "provider_message_id" varchar(255) NOT NULL DEFAULT '';
+
+ALTER TABLE "sms" ADD "resend_count" int NOT NULL DEFAULT 0;
+
+CREATE INDEX ON "sms" ("ref_id");
+
+CREATE INDEX ON "sms" ("provider", "provider_message_id") WHERE "provider_message_id" != '';
+
+CREATE TABLE "sms_status_history" (
+  "id" bigserial PRIMARY KEY,
+  "sms_id" bigint,
+  "ref_id" text NOT NULL DEFAULT '',
+  "provider" varchar(50) NOT NULL DEFAULT '',
+  "provider_message_id" varchar(255) NOT NULL DEFAULT '',
+  "status" varchar(50) NOT NULL,
+  "provider_status" varchar(255) NOT NULL DEFAULT '',
+  "description" text NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+
+  CONSTRAINT "sms_status_history_sms_id_fkey" FOREIGN KEY ("sms_id") REFERENCES "sms" ("id") ON DELETE CASCADE
+);
+
+CREATE INDEX ON "sms_status_history" ("sms_id");
+
+CREATE INDEX ON "sms_status_history" ("provider", "provider_message_id") WHERE "sms_id" IS NULL;
+
+COMMENT ON COLUMN "sms"."status" IS 'pending, delivered, failed, expired or rejected';
+
+COMMENT ON COLUMN "sms"."resend_count" IS 'times the message was sent again after a failed delivery report';
+
+COMMENT ON COLUMN "sms_status_history"."sms_id" IS 'null until a report for a message we have not recorded yet is matched';
+
+COMMENT ON COLUMN "sms_status_history"."status" IS 'pending, delivered, failed, expired or rejected';
+
+COMMENT ON COLUMN "sms_status_history"."provider_status" IS 'the status exactly as the gateway reported it';
\ No newline at end of file
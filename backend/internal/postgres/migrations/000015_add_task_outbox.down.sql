@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "task_outbox";
\ No newline at end of file
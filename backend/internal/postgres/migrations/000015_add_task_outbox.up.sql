@@ -0,0 +1,23 @@
+CREATE TABLE "task_outbox" (
+  "id" bigserial PRIMARY KEY,
+  "task_type" varchar(100) NOT NULL,
+  "payload" jsonb NOT NULL,
+  "queue" varchar(50) NOT NULL DEFAULT '',
+  "max_retry" int,
+  "process_at" timestamptz,
+  "task_id" varchar(255) NOT NULL DEFAULT '',
+  "attempts" int NOT NULL DEFAULT 0,
+  "last_error" text NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "published_at" timestamptz
+);
+
+CREATE INDEX ON "task_outbox" ("id") WHERE "published_at" IS NULL;
+
+CREATE INDEX ON "task_outbox" ("published_at");
+
+COMMENT ON TABLE "task_outbox" IS 'tasks written with the rows they belong to, published to redis by the relay after commit';
+
+COMMENT ON COLUMN "task_outbox"."task_id" IS 'asynq task id, outbox:<id> when empty so a task published twice is only queued once';
+
+COMMENT ON COLUMN "task_outbox"."published_at" IS 'null until the relay has queued the task';
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "sms_submissions";
\ No newline at end of file
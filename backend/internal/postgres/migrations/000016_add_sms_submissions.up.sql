@@ -0,0 +1,20 @@
+CREATE TABLE "sms_submissions" (
+  "ref_id" text NOT NULL,
+  "resend" int NOT NULL DEFAULT 0,
+  "status" varchar(20) NOT NULL DEFAULT 'submitting' CHECK ("status" IN ('submitting', 'accepted', 'failed')),
+  "provider" varchar(50) NOT NULL DEFAULT '',
+  "provider_message_id" text NOT NULL DEFAULT '',
+  "cost" numeric(12,4) NOT NULL DEFAULT 0,
+  "description" text NOT NULL DEFAULT '',
+  "attempts" int NOT NULL DEFAULT 1,
+  "error" text NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("ref_id", "resend")
+);
+
+COMMENT ON TABLE "sms_submissions" IS 'every hand over of a message to a gateway, a retried send task skips messages already accepted';
+
+COMMENT ON COLUMN "sms_submissions"."resend" IS '0 for the first send, then the resend number';
+
+COMMENT ON COLUMN "sms_submissions"."status" IS 'submitting while the request is in flight, a task that died mid request leaves it submitting';
\ No newline at end of file
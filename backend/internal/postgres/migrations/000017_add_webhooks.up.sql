@@ -0,0 +1,42 @@
+CREATE TABLE "webhook_subscriptions" (
+  "id" bigserial PRIMARY KEY,
+  "url" text NOT NULL,
+  "events" text[] NOT NULL,
+  "secret" text NOT NULL,
+  "description" text NOT NULL DEFAULT '',
+  "active" boolean NOT NULL DEFAULT true,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "webhook_deliveries" (
+  "id" bigserial PRIMARY KEY,
+  "subscription_id" bigint NOT NULL,
+  "event_id" text NOT NULL,
+  "event_type" varchar(50) NOT NULL,
+  "payload" jsonb NOT NULL,
+  "status" varchar(20) NOT NULL DEFAULT 'pending' CHECK ("status" IN ('pending', 'succeeded', 'failed')),
+  "attempts" int NOT NULL DEFAULT 0,
+  "response_status" int NOT NULL DEFAULT 0,
+  "response_body" text NOT NULL DEFAULT '',
+  "error" text NOT NULL DEFAULT '',
+  "duration_ms" int NOT NULL DEFAULT 0,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  "delivered_at" timestamptz,
+  CONSTRAINT "webhook_deliveries_subscription_id_fkey" FOREIGN KEY ("subscription_id") REFERENCES "webhook_subscriptions" ("id") ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX ON "webhook_deliveries" ("event_id", "subscription_id");
+
+CREATE INDEX ON "webhook_deliveries" ("subscription_id", "id");
+
+COMMENT ON TABLE "webhook_subscriptions" IS 'urls told about business events, each request is signed with the secret';
+
+COMMENT ON COLUMN "webhook_subscriptions"."events" IS 'event types the url receives, like payment.received';
+
+COMMENT ON TABLE "webhook_deliveries" IS 'one event sent to one subscription, with the result of the latest attempt';
+
+COMMENT ON COLUMN "webhook_deliveries"."payload" IS 'the exact body posted, signatures are computed over it';
+
+COMMENT ON COLUMN "webhook_deliveries"."status" IS 'pending until the url answers with a 2xx, failed once the retries run out';
\ No newline at end of file
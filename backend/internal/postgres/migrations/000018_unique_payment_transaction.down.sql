@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "payments_transaction_number_transaction_source_idx";
+
+CREATE INDEX ON "payments" ("transaction_number");
\ No newline at end of file
@@ -0,0 +1,60 @@
+-- new payments are stored with a trimmed, upper-case source, so "bank" and "BANK " are one source
+UPDATE "payments"
+SET "transaction_source" = upper(btrim("transaction_source"))
+WHERE "transaction_source" <> upper(btrim("transaction_source"));
+
+-- repeated callbacks and imports recorded some transactions more than once, the
+-- earliest row of each is kept and the others are merged into it
+CREATE TEMP TABLE "duplicate_payments" AS
+SELECT "id", "keep_id", "assigned", "assigned_to", "amount", "reversed", false AS "moved"
+FROM (
+  SELECT *, min("id") OVER (PARTITION BY "transaction_number", "transaction_source") AS "keep_id"
+  FROM "payments"
+  WHERE "reversal_of" IS NULL
+) AS "p"
+WHERE "id" <> "keep_id";
+
+-- a kept row nobody assigned takes the assignment of its first assigned duplicate,
+-- the customer's balance already counts that payment once
+UPDATE "duplicate_payments" AS "d"
+SET "moved" = true
+FROM "payments" AS "k"
+WHERE "k"."id" = "d"."keep_id"
+  AND NOT "k"."assigned"
+  AND NOT "k"."reversed"
+  AND "d"."id" = (
+    SELECT min("id") FROM "duplicate_payments"
+    WHERE "keep_id" = "d"."keep_id" AND "assigned" AND NOT "reversed"
+  );
+
+UPDATE "payments" AS "k"
+SET "assigned" = true,
+    "assigned_to" = "d"."assigned_to",
+    "ignored" = false,
+    "ignored_reason" = ''
+FROM "duplicate_payments" AS "d"
+WHERE "d"."moved" AND "k"."id" = "d"."keep_id";
+
+-- every other assigned duplicate reduced the customer's balance a second time,
+-- that is given back the way a reversal does
+UPDATE "customers" AS "c"
+SET "loaned" = "c"."loaned" + GREATEST("d"."amount" - "c"."credit", 0),
+    "credit" = GREATEST("c"."credit" - "d"."amount", 0)
+FROM (
+  SELECT "assigned_to", sum("amount") AS "amount"
+  FROM "duplicate_payments"
+  WHERE "assigned" AND NOT "reversed" AND NOT "moved"
+  GROUP BY "assigned_to"
+) AS "d"
+WHERE "c"."id" = "d"."assigned_to";
+
+-- a reversed duplicate and its reversal entry cancel out
+DELETE FROM "payments" WHERE "reversal_of" IN (SELECT "id" FROM "duplicate_payments");
+
+DELETE FROM "payments" WHERE "id" IN (SELECT "id" FROM "duplicate_payments");
+
+DROP TABLE "duplicate_payments";
+
+DROP INDEX IF EXISTS "payments_transaction_number_idx";
+
+CREATE UNIQUE INDEX ON "payments" ("transaction_number", "transaction_source");
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE "task_outbox" DROP COLUMN IF EXISTS "failed_at";
+
+ALTER TABLE "task_outbox" DROP COLUMN IF EXISTS "next_attempt_at";
\ No newline at end of file
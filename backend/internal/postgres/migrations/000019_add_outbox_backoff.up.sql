@@ -0,0 +1,7 @@
+ALTER TABLE "task_outbox" ADD COLUMN "next_attempt_at" timestamptz NOT NULL DEFAULT (now());
+
+ALTER TABLE "task_outbox" ADD COLUMN "failed_at" timestamptz;
+
+COMMENT ON COLUMN "task_outbox"."next_attempt_at" IS 'when the relay tries to publish the task next, pushed back after every failure';
+
+COMMENT ON COLUMN "task_outbox"."failed_at" IS 'set when the relay gave up on the task';
\ No newline at end of file
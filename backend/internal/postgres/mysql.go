@@ -16,20 +16,34 @@ import (
 )
 
 type PostgresRepo struct {
-	CustomerRepo repository.CustomerRepository
-	UserRepo     repository.UserRepository
-	LoanRepo     repository.LoanRepository
-	SMSRepo      repository.SMSRepository
-	PaymentRepo  repository.PaymentRepository
+	CustomerRepo    repository.CustomerRepository
+	UserRepo        repository.UserRepository
+	LoanRepo        repository.LoanRepository
+	SMSRepo         repository.SMSRepository
+	PaymentRepo     repository.PaymentRepository
+	SMSTemplateRepo repository.SMSTemplateRepository
+	SMSCampaignRepo repository.SMSCampaignRepository
+	ReminderRepo    repository.ReminderRepository
+	SMSConsentRepo  repository.SMSConsentRepository
+	SMSBalanceRepo  repository.SMSBalanceRepository
+	OutboxRepo      repository.OutboxRepository
+	WebhookRepo     repository.WebhookRepository
 }
 
 func NewPostgresRepo(store *Store) *PostgresRepo {
 	return &PostgresRepo{
-		CustomerRepo: NewCustomerRepo(store),
-		UserRepo:     NewUserRepository(store),
-		LoanRepo:     NewLoanRepository(store),
-		SMSRepo:      NewSMSRepository(store),
-		PaymentRepo:  NewPaymentRepository(store),
+		CustomerRepo:    NewCustomerRepo(store),
+		UserRepo:        NewUserRepository(store),
+		LoanRepo:        NewLoanRepository(store),
+		SMSRepo:         NewSMSRepository(store),
+		PaymentRepo:     NewPaymentRepository(store),
+		SMSTemplateRepo: NewSMSTemplateRepository(store),
+		SMSCampaignRepo: NewSMSCampaignRepository(store),
+		ReminderRepo:    NewReminderRepository(store),
+		SMSConsentRepo:  NewSMSConsentRepository(store),
+		SMSBalanceRepo:  NewSMSBalanceRepository(store),
+		OutboxRepo:      NewOutboxRepository(store),
+		WebhookRepo:     NewWebhookRepository(store),
 	}
 }
 
@@ -0,0 +1,186 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/EmilioCliff/jonche/internal/postgres/generated"
+	"github.com/EmilioCliff/jonche/internal/repository"
+	"github.com/EmilioCliff/jonche/internal/services"
+	"github.com/EmilioCliff/jonche/pkg"
+	"github.com/hibiken/asynq"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var _ repository.OutboxRepository = (*OutboxRepository)(nil)
+
+// a task is retried with a backoff of up to an hour, this many attempts ride out
+// about nine hours of redis being away
+const outboxMaxAttempts = 20
+
+type OutboxRepository struct {
+	db      *Store
+	queries generated.Querier
+}
+
+func NewOutboxRepository(db *Store) *OutboxRepository {
+	return &OutboxRepository{
+		db:      db,
+		queries: generated.New(db.pool),
+	}
+}
+
+func (o *OutboxRepository) RelayOutboxTasks(
+	ctx context.Context,
+	limit int32,
+	publish func(context.Context, *repository.OutboxTask) error,
+) (int, error) {
+	published := 0
+
+	err := o.db.ExecTx(ctx, func(q *generated.Queries) error {
+		// locked so a second relay skips the batch instead of publishing it again
+		tasks, err := q.ListPendingOutboxTasks(ctx, limit)
+		if err != nil {
+			return pkg.Errorf(pkg.INTERNAL_ERROR, "error listing outbox tasks: %s", err.Error())
+		}
+
+		for _, task := range tasks {
+			if err := publish(ctx, outboxTaskFromGenerated(task)); err != nil {
+				// the task is tried again later, the rest of the batch goes on
+				giveUp := task.Attempts+1 >= outboxMaxAttempts
+				if markErr := q.MarkOutboxTaskFailed(ctx, generated.MarkOutboxTaskFailedParams{
+					ID:        task.ID,
+					LastError: pkg.ErrorMessage(err),
+					GiveUp:    giveUp,
+				}); markErr != nil {
+					return pkg.Errorf(pkg.INTERNAL_ERROR, "error marking outbox task: %s", markErr.Error())
+				}
+
+				if giveUp {
+					log.Printf(
+						"giving up on outbox task %d %s after %d attempts: %s",
+						task.ID,
+						task.TaskType,
+						outboxMaxAttempts,
+						pkg.ErrorMessage(err),
+					)
+				}
+
+				continue
+			}
+
+			if err := q.MarkOutboxTaskPublished(ctx, task.ID); err != nil {
+				return pkg.Errorf(pkg.INTERNAL_ERROR, "error marking outbox task: %s", err.Error())
+			}
+			published++
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return published, nil
+}
+
+func (o *OutboxRepository) DeletePublishedOutboxTasks(
+	ctx context.Context,
+	before time.Time,
+) (int64, error) {
+	deleted, err := o.queries.DeletePublishedOutboxTasks(ctx, pgtype.Timestamptz{
+		Valid: true,
+		Time:  before,
+	})
+	if err != nil {
+		return 0, pkg.Errorf(pkg.INTERNAL_ERROR, "error deleting outbox tasks: %s", err.Error())
+	}
+
+	return deleted, nil
+}
+
+// enqueueTask saves a task in the caller's transaction, it reaches redis once the
+// transaction commits. Only the queue, retry, schedule and task id options are kept.
+func enqueueTask(
+	ctx context.Context,
+	q *generated.Queries,
+	taskType string,
+	payload any,
+	opts ...asynq.Option,
+) error {
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to marshal payload: %s", err.Error())
+	}
+
+	params := generated.CreateOutboxTaskParams{
+		TaskType: taskType,
+		Payload:  jsonPayload,
+	}
+	for _, opt := range opts {
+		switch opt.Type() {
+		case asynq.QueueOpt:
+			params.Queue = opt.Value().(string)
+		case asynq.MaxRetryOpt:
+			params.MaxRetry = pgtype.Int4{
+				Valid: true,
+				Int32: int32(opt.Value().(int)),
+			}
+		case asynq.ProcessAtOpt:
+			params.ProcessAt = pgtype.Timestamptz{
+				Valid: true,
+				Time:  opt.Value().(time.Time),
+			}
+		case asynq.ProcessInOpt:
+			params.ProcessAt = pgtype.Timestamptz{
+				Valid: true,
+				Time:  time.Now().Add(opt.Value().(time.Duration)),
+			}
+		case asynq.TaskIDOpt:
+			params.TaskID = opt.Value().(string)
+		}
+	}
+
+	if err := q.CreateOutboxTask(ctx, params); err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "error creating outbox task: %s", err.Error())
+	}
+
+	return nil
+}
+
+// enqueueSendSMS queues the message under its SendSMSTaskID unless opts set
+// another.
+func enqueueSendSMS(
+	ctx context.Context,
+	q *generated.Queries,
+	payload services.SendSMSPayload,
+	opts ...asynq.Option,
+) error {
+	opts = append(
+		[]asynq.Option{asynq.TaskID(services.SendSMSTaskID(payload.RefID, payload.Resend))},
+		opts...,
+	)
+
+	return enqueueTask(ctx, q, services.SendSMSTask, payload, opts...)
+}
+
+func outboxTaskFromGenerated(task generated.TaskOutbox) *repository.OutboxTask {
+	maxRetry := -1
+	if task.MaxRetry.Valid {
+		maxRetry = int(task.MaxRetry.Int32)
+	}
+
+	return &repository.OutboxTask{
+		ID:        uint32(task.ID),
+		TaskType:  task.TaskType,
+		Payload:   task.Payload,
+		Queue:     task.Queue,
+		MaxRetry:  maxRetry,
+		ProcessAt: task.ProcessAt.Time,
+		TaskID:    task.TaskID,
+		Attempts:  uint32(task.Attempts),
+		CreatedAt: task.CreatedAt,
+	}
+}
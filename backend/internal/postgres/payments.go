@@ -3,6 +3,7 @@ package postgres
 import (
 	"context"
 	"strings"
+	"time"
 
 	"github.com/EmilioCliff/jonche/internal/postgres/generated"
 	"github.com/EmilioCliff/jonche/internal/repository"
@@ -32,8 +33,13 @@ func NewPaymentRepository(db *Store) *PaymentRepository {
 func (p *PaymentRepository) CreatePayment(
 	ctx context.Context,
 	payment *repository.Payment,
-	afterCreate func(context.Context, services.SendSMSPayload, ...asynq.Option) error,
 ) (*repository.Payment, error) {
+	// transaction numbers are unique per source, "bank" and "BANK " are the same one
+	payment.TransactionSource = strings.ToUpper(strings.TrimSpace(payment.TransactionSource))
+
+	// set inside the transaction, its errors all come out as internal
+	var duplicate bool
+
 	err := p.db.ExecTx(ctx, func(q *generated.Queries) error {
 		var amount pgtype.Numeric
 
@@ -49,6 +55,7 @@ func (p *PaymentRepository) CreatePayment(
 			TransactionNumber: payment.TransactionNumber,
 			TransactionSource: payment.TransactionSource,
 			PayingName:        strings.ToUpper(payment.PayingName),
+			PayingPhone:       payment.PayingPhone,
 			Amount:            amount,
 			Assigned:          false,
 			PaidAt:            payment.PaidAt,
@@ -60,6 +67,12 @@ func (p *PaymentRepository) CreatePayment(
 
 		pp, err := q.CreatePayment(ctx, params)
 		if err != nil {
+			// a callback, import or manual entry already recorded it
+			if err == pgx.ErrNoRows {
+				duplicate = true
+
+				return nil
+			}
 			if pkg.PgxErrorCode(err) == pkg.FOREIGN_KEY_VIOLATION {
 				return pkg.Errorf(pkg.INVALID_ERROR, "foreign key violation: %s", err.Error())
 			}
@@ -70,10 +83,15 @@ func (p *PaymentRepository) CreatePayment(
 		payment.ID = uint32(pp.ID)
 		payment.PaidAt = pp.PaidAt
 
+		event := paymentEventFromGenerated(pp)
+		if err := publishEvent(ctx, q, services.EventPaymentReceived, event); err != nil {
+			return err
+		}
+
 		if pp.Assigned {
 			customer, err := q.ReduceCustomerLoaned(ctx, generated.ReduceCustomerLoanedParams{
 				ID:     pp.AssignedTo.Int64,
-				Loaned: pp.Amount,
+				Amount: pp.Amount,
 			})
 			if err != nil {
 				return pkg.Errorf(
@@ -83,10 +101,21 @@ func (p *PaymentRepository) CreatePayment(
 				)
 			}
 
-			mesages, err := pkg.GenerateMessages(services.PaymentSMS, []pkg.CustomerTemplateParams{
+			event.Customer = customerEventFromGenerated(customer)
+			if err := publishEvent(ctx, q, services.EventPaymentAssigned, event); err != nil {
+				return err
+			}
+
+			tmpl, err := smsTemplateBody(ctx, q, services.PaymentSMSTemplate)
+			if err != nil {
+				return err
+			}
+
+			mesages, err := pkg.GenerateMessages(tmpl, []pkg.CustomerTemplateParams{
 				{
 					Name:     customer.Name,
 					Loaned:   numericToFloat64(customer.Loaned),
+					Credit:   numericToFloat64(customer.Credit),
 					Paid:     payment.Amount,
 					PaidDate: payment.PaidAt.Format("02 Jan 2006"),
 				},
@@ -112,9 +141,9 @@ func (p *PaymentRepository) CreatePayment(
 			}
 
 			ps := services.SendSMSPayload{
-				Messages:     mesages,
-				PhoneNumbers: []string{customer.PhoneNumber},
-				RefIds:       []string{pb.RefID},
+				Message:     mesages[0],
+				PhoneNumber: customer.PhoneNumber,
+				RefID:       pb.RefID,
 			}
 
 			opts := []asynq.Option{
@@ -123,13 +152,24 @@ func (p *PaymentRepository) CreatePayment(
 				asynq.Queue(services.QueueCritical),
 			}
 
-			return afterCreate(ctx, ps, opts...)
+			return enqueueSendSMS(ctx, q, ps, opts...)
 		}
 
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
+	if duplicate {
+		return nil, pkg.Errorf(
+			pkg.ALREADY_EXISTS_ERROR,
+			"payment %s from %s already exists",
+			payment.TransactionNumber,
+			payment.TransactionSource,
+		)
+	}
 
-	return payment, err
+	return payment, nil
 }
 
 func (p *PaymentRepository) ListPayments(
@@ -157,9 +197,15 @@ func (p *PaymentRepository) ListPayments(
 			TransactionNumber: payment.TransactionNumber,
 			TransactionSource: payment.TransactionSource,
 			PayingName:        payment.PayingName,
+			PayingPhone:       payment.PayingPhone,
 			Amount:            numericToFloat64(payment.Amount),
 			Assigned:          payment.Assigned,
 			PaidAt:            payment.PaidAt,
+			Reversed:          payment.Reversed,
+			ReversalOf:        uint32(payment.ReversalOf.Int64),
+			ReversalReason:    payment.ReversalReason,
+			Ignored:           payment.Ignored,
+			IgnoredReason:     payment.IgnoredReason,
 		}
 		if payment.Assigned {
 			pp.AssignedTo = uint32(payment.AssignedTo.Int64)
@@ -222,9 +268,15 @@ func (p *PaymentRepository) ListCustomerPayments(
 			TransactionNumber: payment.TransactionNumber,
 			TransactionSource: payment.TransactionSource,
 			PayingName:        payment.PayingName,
+			PayingPhone:       payment.PayingPhone,
 			Amount:            numericToFloat64(payment.Amount),
 			Assigned:          payment.Assigned,
 			PaidAt:            payment.PaidAt,
+			Reversed:          payment.Reversed,
+			ReversalOf:        uint32(payment.ReversalOf.Int64),
+			ReversalReason:    payment.ReversalReason,
+			Ignored:           payment.Ignored,
+			IgnoredReason:     payment.IgnoredReason,
 		}
 		if payment.Assigned {
 			pp.AssignedTo = uint32(payment.AssignedTo.Int64)
@@ -253,6 +305,59 @@ func (p *PaymentRepository) ListCustomerPayments(
 	), nil
 }
 
+// ListUnassignedPayments lists the payments still waiting to be matched to a
+// customer, oldest first.
+func (p *PaymentRepository) ListUnassignedPayments(
+	ctx context.Context,
+	pgData *pkg.PaginationMetadata,
+) ([]*repository.Payment, pkg.PaginationMetadata, error) {
+	rslt, err := p.queries.ListUnassignedPayments(ctx, generated.ListUnassignedPaymentsParams{
+		Limit:  int32(pgData.PageSize),
+		Offset: pkg.CalculateOffset(pgData.CurrentPage, pgData.PageSize),
+	})
+	if err != nil {
+		return nil, pkg.PaginationMetadata{}, pkg.Errorf(
+			pkg.INTERNAL_ERROR,
+			"error listing unassigned payments: %s",
+			err.Error(),
+		)
+	}
+
+	payments := make([]*repository.Payment, len(rslt))
+	for i, payment := range rslt {
+		payments[i] = &repository.Payment{
+			ID:                uint32(payment.ID),
+			TransactionNumber: payment.TransactionNumber,
+			TransactionSource: payment.TransactionSource,
+			PayingName:        payment.PayingName,
+			PayingPhone:       payment.PayingPhone,
+			Amount:            numericToFloat64(payment.Amount),
+			Assigned:          payment.Assigned,
+			PaidAt:            payment.PaidAt,
+			Reversed:          payment.Reversed,
+			ReversalOf:        uint32(payment.ReversalOf.Int64),
+			ReversalReason:    payment.ReversalReason,
+			Ignored:           payment.Ignored,
+			IgnoredReason:     payment.IgnoredReason,
+		}
+	}
+
+	totalPayments, err := p.queries.CountUnassignedPayments(ctx)
+	if err != nil {
+		return nil, pkg.PaginationMetadata{}, pkg.Errorf(
+			pkg.INTERNAL_ERROR,
+			"failed to count unassigned payments: %s",
+			err.Error(),
+		)
+	}
+
+	return payments, pkg.CreatePaginationMetadata(
+		uint32(totalPayments),
+		pgData.PageSize,
+		pgData.CurrentPage,
+	), nil
+}
+
 func (p *PaymentRepository) GetPayment(
 	ctx context.Context,
 	id uint32,
@@ -271,9 +376,15 @@ func (p *PaymentRepository) GetPayment(
 		TransactionNumber: payment.TransactionNumber,
 		TransactionSource: payment.TransactionSource,
 		PayingName:        payment.PayingName,
+		PayingPhone:       payment.PayingPhone,
 		Amount:            numericToFloat64(payment.Amount),
 		Assigned:          payment.Assigned,
 		PaidAt:            payment.PaidAt,
+		Reversed:          payment.Reversed,
+		ReversalOf:        uint32(payment.ReversalOf.Int64),
+		ReversalReason:    payment.ReversalReason,
+		Ignored:           payment.Ignored,
+		IgnoredReason:     payment.IgnoredReason,
 	}
 	if payment.Assigned {
 		pp.AssignedTo = uint32(payment.AssignedTo.Int64)
@@ -285,23 +396,109 @@ func (p *PaymentRepository) GetPayment(
 	return pp, nil
 }
 
+// ListPaymentsByTransactionNumbers lists the original (non reversal) payments from
+// a source with the given numbers, reversed ones included.
+func (p *PaymentRepository) ListPaymentsByTransactionNumbers(
+	ctx context.Context,
+	source string,
+	numbers []string,
+) ([]*repository.Payment, error) {
+	rslt, err := p.queries.ListPaymentsByTransactionNumbers(
+		ctx,
+		generated.ListPaymentsByTransactionNumbersParams{
+			TransactionSource:  source,
+			TransactionNumbers: numbers,
+		},
+	)
+	if err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error listing payments: %s", err.Error())
+	}
+
+	payments := make([]*repository.Payment, len(rslt))
+	for i, payment := range rslt {
+		payments[i] = &repository.Payment{
+			ID:                uint32(payment.ID),
+			TransactionNumber: payment.TransactionNumber,
+			TransactionSource: payment.TransactionSource,
+			PayingName:        payment.PayingName,
+			PayingPhone:       payment.PayingPhone,
+			Amount:            numericToFloat64(payment.Amount),
+			Assigned:          payment.Assigned,
+			AssignedTo:        uint32(payment.AssignedTo.Int64),
+			PaidAt:            payment.PaidAt,
+			Reversed:          payment.Reversed,
+			ReversalOf:        uint32(payment.ReversalOf.Int64),
+			ReversalReason:    payment.ReversalReason,
+			Ignored:           payment.Ignored,
+			IgnoredReason:     payment.IgnoredReason,
+		}
+	}
+
+	return payments, nil
+}
+
+// ListSourcePayments lists the original (non reversal) payments from a source that
+// were paid between from and to.
+func (p *PaymentRepository) ListSourcePayments(
+	ctx context.Context,
+	source string,
+	from time.Time,
+	to time.Time,
+) ([]*repository.Payment, error) {
+	rslt, err := p.queries.ListSourcePayments(ctx, generated.ListSourcePaymentsParams{
+		TransactionSource: source,
+		StartDate:         from,
+		EndDate:           to,
+	})
+	if err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error listing payments: %s", err.Error())
+	}
+
+	payments := make([]*repository.Payment, len(rslt))
+	for i, payment := range rslt {
+		payments[i] = &repository.Payment{
+			ID:                uint32(payment.ID),
+			TransactionNumber: payment.TransactionNumber,
+			TransactionSource: payment.TransactionSource,
+			PayingName:        payment.PayingName,
+			PayingPhone:       payment.PayingPhone,
+			Amount:            numericToFloat64(payment.Amount),
+			Assigned:          payment.Assigned,
+			AssignedTo:        uint32(payment.AssignedTo.Int64),
+			PaidAt:            payment.PaidAt,
+			Reversed:          payment.Reversed,
+			ReversalOf:        uint32(payment.ReversalOf.Int64),
+			ReversalReason:    payment.ReversalReason,
+			Ignored:           payment.Ignored,
+			IgnoredReason:     payment.IgnoredReason,
+		}
+	}
+
+	return payments, nil
+}
+
 // pass the callback functions
 func (p *PaymentRepository) AssignPayment(
 	ctx context.Context,
 	paymentId uint32,
 	customerId uint32,
-	afterAssign func(context.Context, services.SendSMSPayload, ...asynq.Option) error,
 ) error {
-	ok, err := p.queries.CheckPaymentAssigned(ctx, int64(paymentId))
+	current, err := p.queries.GetPayment(ctx, int64(paymentId))
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			return pkg.Errorf(pkg.NOT_FOUND_ERROR, "payment not found")
 		}
 		return pkg.Errorf(pkg.INTERNAL_ERROR, "error checking payment: %s", err.Error())
 	}
-	if ok {
+	if current.Assigned {
 		return pkg.Errorf(pkg.INVALID_ERROR, "payment already assigned")
 	}
+	if current.Reversed || current.ReversalOf.Valid {
+		return pkg.Errorf(pkg.INVALID_ERROR, "reversed payments cannot be assigned")
+	}
+	if current.Ignored {
+		return pkg.Errorf(pkg.INVALID_ERROR, "ignored payments cannot be assigned")
+	}
 
 	err = p.db.ExecTx(ctx, func(q *generated.Queries) error {
 		payment, err := q.AssignPayment(ctx, generated.AssignPaymentParams{
@@ -321,7 +518,7 @@ func (p *PaymentRepository) AssignPayment(
 
 		customer, err := q.ReduceCustomerLoaned(ctx, generated.ReduceCustomerLoanedParams{
 			ID:     int64(customerId),
-			Loaned: payment.Amount,
+			Amount: payment.Amount,
 		})
 		if err != nil {
 			return pkg.Errorf(
@@ -331,10 +528,22 @@ func (p *PaymentRepository) AssignPayment(
 			)
 		}
 
-		mesages, err := pkg.GenerateMessages(services.PaymentSMS, []pkg.CustomerTemplateParams{
+		event := paymentEventFromGenerated(payment)
+		event.Customer = customerEventFromGenerated(customer)
+		if err := publishEvent(ctx, q, services.EventPaymentAssigned, event); err != nil {
+			return err
+		}
+
+		tmpl, err := smsTemplateBody(ctx, q, services.PaymentSMSTemplate)
+		if err != nil {
+			return err
+		}
+
+		mesages, err := pkg.GenerateMessages(tmpl, []pkg.CustomerTemplateParams{
 			{
 				Name:     customer.Name,
 				Loaned:   numericToFloat64(customer.Loaned),
+				Credit:   numericToFloat64(customer.Credit),
 				Paid:     numericToFloat64(payment.Amount),
 				PaidDate: payment.PaidAt.Format("02 Jan 2006"),
 			},
@@ -359,9 +568,9 @@ func (p *PaymentRepository) AssignPayment(
 		}
 
 		ps := services.SendSMSPayload{
-			Messages:     mesages,
-			PhoneNumbers: []string{customer.PhoneNumber},
-			RefIds:       []string{id},
+			Message:     mesages[0],
+			PhoneNumber: customer.PhoneNumber,
+			RefID:       id,
 		}
 
 		opts := []asynq.Option{
@@ -370,8 +579,161 @@ func (p *PaymentRepository) AssignPayment(
 			asynq.Queue(services.QueueCritical),
 		}
 
-		return afterAssign(ctx, ps, opts...)
+		return enqueueSendSMS(ctx, q, ps, opts...)
 	})
 
 	return err
 }
+
+// ReversePayment records a negative counter-entry for the payment and gives the
+// amount back to the customer it was assigned to.
+func (p *PaymentRepository) ReversePayment(
+	ctx context.Context,
+	paymentId uint32,
+	reason string,
+) (*repository.Payment, error) {
+	var rsp repository.Payment
+
+	err := p.db.ExecTx(ctx, func(q *generated.Queries) error {
+		payment, err := q.GetPaymentForUpdate(ctx, int64(paymentId))
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return pkg.Errorf(pkg.NOT_FOUND_ERROR, "payment not found")
+			}
+
+			return pkg.Errorf(pkg.INTERNAL_ERROR, "error getting payment: %s", err.Error())
+		}
+		if payment.Reversed {
+			return pkg.Errorf(pkg.INVALID_ERROR, "payment already reversed")
+		}
+		if payment.ReversalOf.Valid {
+			return pkg.Errorf(pkg.INVALID_ERROR, "a reversal entry cannot be reversed")
+		}
+
+		var amount pgtype.Numeric
+		if err := amount.Scan(pkg.Float64ToString(-numericToFloat64(payment.Amount))); err != nil {
+			return pkg.Errorf(
+				pkg.INTERNAL_ERROR,
+				"failed to scan float to numeric: %s",
+				err.Error(),
+			)
+		}
+
+		reversal, err := q.CreatePaymentReversal(ctx, generated.CreatePaymentReversalParams{
+			TransactionNumber: payment.TransactionNumber + "-REV",
+			TransactionSource: payment.TransactionSource,
+			PayingName:        payment.PayingName,
+			Amount:            amount,
+			Assigned:          payment.Assigned,
+			AssignedTo:        payment.AssignedTo,
+			PaidAt:            time.Now(),
+			ReversalOf: pgtype.Int8{
+				Valid: true,
+				Int64: payment.ID,
+			},
+			ReversalReason: reason,
+		})
+		if err != nil {
+			return pkg.Errorf(pkg.INTERNAL_ERROR, "error creating reversal: %s", err.Error())
+		}
+
+		if err := q.MarkPaymentReversed(ctx, generated.MarkPaymentReversedParams{
+			ReversalReason: reason,
+			ID:             payment.ID,
+		}); err != nil {
+			return pkg.Errorf(pkg.INTERNAL_ERROR, "error marking payment reversed: %s", err.Error())
+		}
+
+		if payment.Assigned {
+			if err := q.AddCustomerLoaned(ctx, generated.AddCustomerLoanedParams{
+				Amount: payment.Amount,
+				ID:     payment.AssignedTo.Int64,
+			}); err != nil {
+				return pkg.Errorf(
+					pkg.INTERNAL_ERROR,
+					"error restoring customer loaned amount: %s",
+					err.Error(),
+				)
+			}
+		}
+
+		rsp = repository.Payment{
+			ID:                uint32(reversal.ID),
+			TransactionNumber: reversal.TransactionNumber,
+			TransactionSource: reversal.TransactionSource,
+			PayingName:        reversal.PayingName,
+			PayingPhone:       reversal.PayingPhone,
+			Amount:            numericToFloat64(reversal.Amount),
+			Assigned:          reversal.Assigned,
+			AssignedTo:        uint32(reversal.AssignedTo.Int64),
+			PaidAt:            reversal.PaidAt,
+			Reversed:          reversal.Reversed,
+			ReversalOf:        uint32(reversal.ReversalOf.Int64),
+			ReversalReason:    reversal.ReversalReason,
+			Ignored:           reversal.Ignored,
+			IgnoredReason:     reversal.IgnoredReason,
+		}
+
+		return nil
+	})
+
+	return &rsp, err
+}
+
+// IgnorePayment marks an unassigned payment as not being a loan repayment so it
+// leaves the review queue.
+func (p *PaymentRepository) IgnorePayment(
+	ctx context.Context,
+	paymentId uint32,
+	reason string,
+) (*repository.Payment, error) {
+	var rsp repository.Payment
+
+	err := p.db.ExecTx(ctx, func(q *generated.Queries) error {
+		payment, err := q.GetPaymentForUpdate(ctx, int64(paymentId))
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return pkg.Errorf(pkg.NOT_FOUND_ERROR, "payment not found")
+			}
+
+			return pkg.Errorf(pkg.INTERNAL_ERROR, "error getting payment: %s", err.Error())
+		}
+		if payment.Assigned {
+			return pkg.Errorf(pkg.INVALID_ERROR, "assigned payments cannot be ignored")
+		}
+		if payment.Reversed || payment.ReversalOf.Valid {
+			return pkg.Errorf(pkg.INVALID_ERROR, "reversed payments cannot be ignored")
+		}
+		if payment.Ignored {
+			return pkg.Errorf(pkg.INVALID_ERROR, "payment already ignored")
+		}
+
+		ignored, err := q.IgnorePayment(ctx, generated.IgnorePaymentParams{
+			IgnoredReason: reason,
+			ID:            payment.ID,
+		})
+		if err != nil {
+			return pkg.Errorf(pkg.INTERNAL_ERROR, "error ignoring payment: %s", err.Error())
+		}
+
+		rsp = repository.Payment{
+			ID:                uint32(ignored.ID),
+			TransactionNumber: ignored.TransactionNumber,
+			TransactionSource: ignored.TransactionSource,
+			PayingName:        ignored.PayingName,
+			PayingPhone:       ignored.PayingPhone,
+			Amount:            numericToFloat64(ignored.Amount),
+			Assigned:          ignored.Assigned,
+			PaidAt:            ignored.PaidAt,
+			Reversed:          ignored.Reversed,
+			ReversalOf:        uint32(ignored.ReversalOf.Int64),
+			ReversalReason:    ignored.ReversalReason,
+			Ignored:           ignored.Ignored,
+			IgnoredReason:     ignored.IgnoredReason,
+		}
+
+		return nil
+	})
+
+	return &rsp, err
+}
@@ -29,6 +29,12 @@ WHERE
   (id = sqlc.narg('id') OR phone_number = sqlc.narg('phone_number'))
 LIMIT 1;
 
+-- name: GetCustomerByPhoneSuffix :one
+SELECT * FROM customers
+WHERE right(regexp_replace(phone_number, '\D', '', 'g'), 9) = sqlc.arg('phone_suffix')
+ORDER BY id
+LIMIT 1;
+
 -- name: GetCustomerFullData :one
 WITH customer_data AS (
   SELECT customers.* FROM customers
@@ -72,15 +78,23 @@ SELECT
 
 -- name: AddCustomerLoaned :exec
 UPDATE customers
-SET loaned = loaned + $1
-WHERE id = $2; 
+SET loaned = loaned + GREATEST(sqlc.arg('amount') - credit, 0),
+    credit = GREATEST(credit - sqlc.arg('amount'), 0)
+WHERE id = sqlc.arg('id'); 
 
 -- name: ReduceCustomerLoaned :one
 UPDATE customers
-SET loaned = loaned - $1
-WHERE id = $2
+SET loaned = GREATEST(loaned - sqlc.arg('amount'), 0),
+    credit = credit + GREATEST(sqlc.arg('amount') - loaned, 0)
+WHERE id = sqlc.arg('id')
 RETURNING *; 
 
+-- name: DeductCustomerCredit :one
+UPDATE customers
+SET credit = credit - sqlc.arg('amount')
+WHERE id = sqlc.arg('id') AND credit >= sqlc.arg('amount')
+RETURNING *;
+
 -- name: UpdateCustomer :one
 UPDATE customers
 SET name = coalesce(sqlc.narg('name'), name),
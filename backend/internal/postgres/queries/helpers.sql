@@ -16,8 +16,11 @@ WITH
     SELECT 
       SUM(amount) AS total_payments_received,
       SUM(amount) FILTER (WHERE assigned = TRUE) AS assigned_total,
-      SUM(amount) FILTER (WHERE assigned = FALSE) AS unassigned_total
+      SUM(amount) FILTER (WHERE assigned = FALSE) AS unassigned_total,
+      COUNT(*) FILTER (WHERE assigned = FALSE) AS unassigned_count,
+      MIN(paid_at) FILTER (WHERE assigned = FALSE) AS oldest_unassigned_at
     FROM payments
+    WHERE reversed = FALSE AND reversal_of IS NULL AND ignored = FALSE
   ),
   sms_stats AS (
     SELECT 
@@ -40,6 +43,9 @@ SELECT
   tp.total_payments_received,
   tp.assigned_total,
   tp.unassigned_total,
+  tp.unassigned_count,
+  tp.oldest_unassigned_at,
+  COALESCE(EXTRACT(DAY FROM now() - tp.oldest_unassigned_at), 0)::int AS oldest_unassigned_days,
 
   -- SMS
   ss.delivered AS sms_delivered,
@@ -64,6 +70,7 @@ FROM (
         0 AS loans_amount,
         amount AS payments_amount
     FROM payments
+    WHERE reversed = FALSE AND reversal_of IS NULL AND ignored = FALSE
 ) AS combined
 GROUP BY date_trunc('month', date_val)
 ORDER BY date_trunc('month', date_val);
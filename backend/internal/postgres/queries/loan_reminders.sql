@@ -0,0 +1,52 @@
+-- name: ListDueInstalments :many
+WITH allocated AS (
+  SELECT
+    loans.id,
+    loans.customer_id,
+    loans.amount,
+    loans.due_date,
+    SUM(loans.amount) OVER (PARTITION BY loans.customer_id ORDER BY loans.created_at, loans.id) AS running_total,
+    SUM(loans.amount) OVER (PARTITION BY loans.customer_id) AS customer_total
+  FROM loans
+)
+SELECT
+  allocated.id AS loan_id,
+  allocated.due_date::date AS due_date,
+  LEAST(allocated.amount, allocated.running_total - (allocated.customer_total - customers.loaned))::numeric AS outstanding,
+  customers.id AS customer_id,
+  customers.name,
+  customers.phone_number,
+  customers.loaned,
+  customers.credit
+FROM allocated
+JOIN customers ON customers.id = allocated.customer_id
+WHERE customers.status = true
+  AND allocated.due_date BETWEEN sqlc.arg('from_date')::date AND sqlc.arg('to_date')::date
+  AND allocated.running_total > allocated.customer_total - customers.loaned
+ORDER BY allocated.due_date, allocated.id;
+
+-- name: CreateLoanReminder :one
+INSERT INTO loan_reminders (
+    loan_id, customer_id, stage, sms_ref_id
+) VALUES (
+    $1, $2, $3, $4
+)
+ON CONFLICT (loan_id, stage) DO NOTHING
+RETURNING *;
+
+-- name: CountCustomerRemindersSince :one
+SELECT COUNT(*) AS total_reminders FROM loan_reminders
+WHERE customer_id = $1 AND sent_at >= $2;
+
+-- name: ListLoanReminders :many
+SELECT
+  loan_reminders.*,
+  customers.name AS customer_name,
+  customers.phone_number AS customer_phone_number
+FROM loan_reminders
+JOIN customers ON customers.id = loan_reminders.customer_id
+ORDER BY loan_reminders.sent_at DESC
+LIMIT $1 OFFSET $2;
+
+-- name: CountLoanReminders :one
+SELECT COUNT(*) AS total_reminders FROM loan_reminders;
\ No newline at end of file
@@ -1,8 +1,8 @@
 -- name: CreateLoan :one
 INSERT INTO loans (
-    customer_id, description, amount
+    customer_id, description, amount, due_date
 ) VALUES (
-    $1, $2, $3
+    $1, $2, $3, $4
 )
 RETURNING *;
 
@@ -45,3 +45,9 @@ ORDER BY loans.created_at DESC;
 
 -- name: DeleteLoan :exec
 DELETE FROM loans WHERE id = $1;
+
+-- name: UpdateLoanDueDate :one
+UPDATE loans
+SET due_date = sqlc.narg('due_date')
+WHERE id = sqlc.arg('id')
+RETURNING *;
\ No newline at end of file
@@ -0,0 +1,33 @@
+-- name: CreateOutboxTask :exec
+INSERT INTO task_outbox (
+    task_type, payload, queue, max_retry, process_at, task_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+);
+
+-- name: ListPendingOutboxTasks :many
+SELECT * FROM task_outbox
+WHERE published_at IS NULL
+  AND failed_at IS NULL
+  AND next_attempt_at <= now()
+ORDER BY id
+LIMIT $1
+FOR UPDATE SKIP LOCKED;
+
+-- name: MarkOutboxTaskPublished :exec
+UPDATE task_outbox
+SET published_at = now(),
+    attempts = attempts + 1
+WHERE id = $1;
+
+-- name: MarkOutboxTaskFailed :exec
+UPDATE task_outbox
+SET attempts = attempts + 1,
+    last_error = sqlc.arg('last_error'),
+    next_attempt_at = now() + make_interval(secs => LEAST(power(2, attempts), 3600)),
+    failed_at = CASE WHEN sqlc.arg('give_up')::bool THEN now() END
+WHERE id = sqlc.arg('id');
+
+-- name: DeletePublishedOutboxTasks :execrows
+DELETE FROM task_outbox
+WHERE published_at < $1;
\ No newline at end of file
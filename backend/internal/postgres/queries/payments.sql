@@ -1,9 +1,10 @@
 -- name: CreatePayment :one
 INSERT INTO payments (
-    transaction_number, transaction_source, paying_name, amount, assigned, assigned_to, paid_at
+    transaction_number, transaction_source, paying_name, paying_phone, amount, assigned, assigned_to, paid_at
 ) VALUES (
-    sqlc.arg('transaction_number'), sqlc.arg('transaction_source'), sqlc.arg('paying_name'), sqlc.arg('amount'), sqlc.arg('assigned'), COALESCE(sqlc.narg('assigned_to'), 0), sqlc.arg('paid_at')
+    sqlc.arg('transaction_number'), sqlc.arg('transaction_source'), sqlc.arg('paying_name'), sqlc.arg('paying_phone'), sqlc.arg('amount'), sqlc.arg('assigned'), COALESCE(sqlc.narg('assigned_to'), 0), sqlc.arg('paid_at')
 )
+ON CONFLICT (transaction_number, transaction_source) DO NOTHING
 RETURNING *;
 
 -- name: ListPayments :many
@@ -39,9 +40,38 @@ WHERE payments.assigned_to = sqlc.arg('customer_id')
 ORDER BY payments.paid_at DESC
 LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
 
+-- name: ListUnassignedPayments :many
+SELECT * FROM payments
+WHERE assigned = FALSE
+  AND ignored = FALSE
+  AND reversed = FALSE
+  AND reversal_of IS NULL
+ORDER BY paid_at
+LIMIT $1 OFFSET $2;
+
+-- name: CountUnassignedPayments :one
+SELECT COUNT(*) AS total_payments FROM payments
+WHERE assigned = FALSE
+  AND ignored = FALSE
+  AND reversed = FALSE
+  AND reversal_of IS NULL;
+
 -- name: CountCustomerPayments :one
 SELECT COUNT(*) AS total_payments FROM payments WHERE assigned_to = $1;
 
+-- name: ListPaymentsByTransactionNumbers :many
+SELECT * FROM payments
+WHERE transaction_source = sqlc.arg('transaction_source')
+  AND transaction_number = ANY(sqlc.arg('transaction_numbers')::text[])
+  AND reversal_of IS NULL;
+
+-- name: ListSourcePayments :many
+SELECT * FROM payments
+WHERE transaction_source = sqlc.arg('transaction_source')
+  AND reversal_of IS NULL
+  AND paid_at BETWEEN sqlc.arg('start_date') AND sqlc.arg('end_date')
+ORDER BY paid_at;
+
 -- name: CheckPaymentAssigned :one
 SELECT 
   CASE 
@@ -67,9 +97,35 @@ LEFT JOIN customers ON customers.id = payments.assigned_to
 WHERE payments.id = $1
 ORDER BY payments.paid_at DESC;
 
+-- name: GetPaymentForUpdate :one
+SELECT * FROM payments
+WHERE id = $1
+FOR UPDATE;
+
+-- name: CreatePaymentReversal :one
+INSERT INTO payments (
+    transaction_number, transaction_source, paying_name, amount, assigned, assigned_to, paid_at, reversal_of, reversal_reason
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+RETURNING *;
+
+-- name: MarkPaymentReversed :exec
+UPDATE payments
+SET reversed = true,
+    reversal_reason = $1
+WHERE id = $2;
+
 -- name: AssignPayment :one
 UPDATE payments
 SET assigned = true,
     assigned_to = $1
 WHERE id = $2
+RETURNING *;
+
+-- name: IgnorePayment :one
+UPDATE payments
+SET ignored = true,
+    ignored_reason = $1
+WHERE id = $2
 RETURNING *;
\ No newline at end of file
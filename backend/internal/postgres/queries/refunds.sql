@@ -0,0 +1,16 @@
+-- name: CreateRefund :one
+INSERT INTO refunds (
+    customer_id, amount, method, reference, description
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+RETURNING *;
+
+-- name: ListCustomerRefunds :many
+SELECT * FROM refunds
+WHERE customer_id = sqlc.arg('customer_id')
+ORDER BY created_at DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: CountCustomerRefunds :one
+SELECT COUNT(*) AS total_refunds FROM refunds WHERE customer_id = $1;
\ No newline at end of file
@@ -7,18 +7,33 @@ INSERT INTO sms (
 RETURNING *;
 
 -- name: ListCustomerSMS :many
-SELECT 
-  sms.*, 
-  customers.name AS customer_name, 
+SELECT
+  thread.id, thread.customer_id, thread.message, thread.type, thread.status, thread.created_at,
+  thread.ref_id, thread.cost, thread.description, thread.callback_status, thread.direction,
+  customers.name AS customer_name,
   customers.phone_number AS customer_phone_number
-FROM sms
-JOIN customers ON customers.id = sms.customer_id
-WHERE sms.customer_id = sqlc.arg('customer_id')
-ORDER BY sms.created_at DESC
+FROM (
+  SELECT
+    sms.id, sms.customer_id, sms.message, sms.type, sms.status, sms.created_at,
+    sms.ref_id, sms.cost, sms.description, sms.callback_status, 'outbound'::varchar AS direction
+  FROM sms
+  WHERE sms.customer_id = sqlc.arg('customer_id')
+  UNION ALL
+  SELECT
+    sms_inbound.id, sms_inbound.customer_id, sms_inbound.message, 'reply', 'received', sms_inbound.received_at,
+    sms_inbound.provider_message_id, 0, '', '', 'inbound'::varchar
+  FROM sms_inbound
+  WHERE sms_inbound.customer_id = sqlc.arg('customer_id')
+) AS thread
+JOIN customers ON customers.id = thread.customer_id
+ORDER BY thread.created_at DESC
 LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
 
 -- name: CountCustomerSMS :one
-SELECT COUNT(*) AS total_sms FROM sms WHERE customer_id = $1;
+SELECT (
+  (SELECT COUNT(*) FROM sms WHERE sms.customer_id = $1) +
+  (SELECT COUNT(*) FROM sms_inbound WHERE sms_inbound.customer_id = $1)
+)::bigint AS total_sms;
 
 -- name: ListSMS :many
 SELECT 
@@ -42,12 +57,18 @@ SELECT
 FROM sms
 WHERE id = $1;
 
+-- name: ListSMSByRefIDs :many
+SELECT id, ref_id FROM sms
+WHERE ref_id = ANY(sqlc.arg('ref_ids')::text[]);
+
 -- name: UpdateSMS :exec
 UPDATE sms
 SET cost = coalesce(sqlc.narg('cost'), cost),
     description = coalesce(sqlc.narg('description'), description),
     callback_status = coalesce(sqlc.narg('callback_status'), callback_status),
-    status = coalesce(sqlc.narg('status'), status)
+    status = coalesce(sqlc.narg('status'), status),
+    provider = coalesce(sqlc.narg('provider'), provider),
+    provider_message_id = coalesce(sqlc.narg('provider_message_id'), provider_message_id)
 WHERE ref_id = sqlc.arg('ref_id');
 
 -- name: GetSMS :one
@@ -63,4 +84,25 @@ ORDER BY sms.created_at DESC;
 -- name: DeliverSMS :exec
 UPDATE sms
 SET status = $1
-WHERE id = $2;
\ No newline at end of file
+WHERE id = $2;
+
+-- name: CreateInboundSMS :one
+INSERT INTO sms_inbound (
+    customer_id, phone_number, message, keyword, provider_message_id
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+ON CONFLICT (provider_message_id) WHERE provider_message_id != '' DO NOTHING
+RETURNING *;
+
+-- name: ListInboundSMS :many
+SELECT
+  sms_inbound.*,
+  COALESCE(customers.name, '')::varchar AS customer_name
+FROM sms_inbound
+LEFT JOIN customers ON customers.id = sms_inbound.customer_id
+ORDER BY sms_inbound.received_at DESC
+LIMIT $1 OFFSET $2;
+
+-- name: CountInboundSMS :one
+SELECT COUNT(*) AS total_sms FROM sms_inbound;
\ No newline at end of file
@@ -0,0 +1,19 @@
+-- name: UpsertSMSProviderBalance :one
+INSERT INTO sms_provider_balances (
+    provider, balance
+) VALUES (
+    $1, $2
+)
+ON CONFLICT (provider) DO UPDATE
+SET balance = EXCLUDED.balance,
+    updated_at = now()
+RETURNING *;
+
+-- name: SetSMSProviderBalanceAlerted :exec
+UPDATE sms_provider_balances
+SET alerted = $1
+WHERE provider = $2;
+
+-- name: ListSMSProviderBalances :many
+SELECT * FROM sms_provider_balances
+ORDER BY provider;
\ No newline at end of file
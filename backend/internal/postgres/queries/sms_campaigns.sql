@@ -0,0 +1,41 @@
+-- name: CreateSMSCampaign :one
+INSERT INTO sms_campaigns (
+    name, message, template_id, min_balance, send_at, cron_spec
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+)
+RETURNING *;
+
+-- name: ListSMSCampaigns :many
+SELECT * FROM sms_campaigns
+ORDER BY created_at DESC
+LIMIT $1 OFFSET $2;
+
+-- name: CountSMSCampaigns :one
+SELECT COUNT(*) AS total_campaigns FROM sms_campaigns;
+
+-- name: GetSMSCampaign :one
+SELECT * FROM sms_campaigns
+WHERE id = $1;
+
+-- name: ListRecurringSMSCampaigns :many
+SELECT * FROM sms_campaigns
+WHERE status = 'scheduled' AND cron_spec != '';
+
+-- name: UpdateSMSCampaignStatus :one
+UPDATE sms_campaigns
+SET status = $1
+WHERE id = $2
+RETURNING *;
+
+-- name: MarkSMSCampaignRun :exec
+UPDATE sms_campaigns
+SET last_run_at = now(),
+    status = CASE WHEN cron_spec = '' THEN 'completed' ELSE status END
+WHERE id = $1;
+
+-- name: ListCampaignRecipients :many
+SELECT id FROM customers
+WHERE status = TRUE
+  AND (sqlc.narg('min_balance')::numeric IS NULL OR loaned > sqlc.narg('min_balance')::numeric)
+ORDER BY id;
\ No newline at end of file
@@ -0,0 +1,37 @@
+-- name: UpsertSMSConsent :one
+INSERT INTO sms_consents (
+    customer_id, category, opted_out, source, reason
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+ON CONFLICT (customer_id, category) DO UPDATE
+SET opted_out = EXCLUDED.opted_out,
+    source = EXCLUDED.source,
+    reason = EXCLUDED.reason,
+    updated_at = now()
+RETURNING *;
+
+-- name: ListCustomerSMSConsents :many
+SELECT * FROM sms_consents
+WHERE customer_id = $1
+ORDER BY category;
+
+-- name: IsCustomerOptedOut :one
+SELECT EXISTS (
+  SELECT 1 FROM sms_consents
+  WHERE customer_id = $1 AND category = $2 AND opted_out = true
+) AS opted_out;
+
+-- name: ListOptedOutCustomers :many
+SELECT
+  sms_consents.*,
+  customers.name AS customer_name,
+  customers.phone_number AS customer_phone_number
+FROM sms_consents
+JOIN customers ON customers.id = sms_consents.customer_id
+WHERE sms_consents.opted_out = true
+ORDER BY sms_consents.updated_at DESC
+LIMIT $1 OFFSET $2;
+
+-- name: CountOptedOutCustomers :one
+SELECT COUNT(*) AS total_consents FROM sms_consents WHERE opted_out = true;
\ No newline at end of file
@@ -0,0 +1,43 @@
+-- name: GetSMSForDeliveryReport :one
+SELECT
+    sms.id, sms.ref_id, sms.customer_id, sms.message, sms.status, sms.resend_count,
+    sms.provider, sms.provider_message_id,
+    customers.phone_number AS customer_phone_number
+FROM sms
+JOIN customers ON customers.id = sms.customer_id
+WHERE (sqlc.arg('ref_id')::text != '' AND sms.ref_id = sqlc.arg('ref_id')::text)
+   OR (sqlc.arg('provider_message_id')::text != '' AND sms.provider = sqlc.arg('provider')::text AND sms.provider_message_id = sqlc.arg('provider_message_id')::text)
+ORDER BY sms.created_at DESC
+LIMIT 1
+FOR UPDATE OF sms;
+
+-- name: MarkSMSResend :exec
+UPDATE sms
+SET status = 'pending',
+    resend_count = resend_count + 1,
+    provider_message_id = ''
+WHERE id = $1;
+
+-- name: CreateSMSStatusHistory :one
+INSERT INTO sms_status_history (
+    sms_id, ref_id, provider, provider_message_id, status, provider_status, description
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+RETURNING *;
+
+-- name: ClaimSMSStatusHistory :many
+UPDATE sms_status_history
+SET sms_id = sms.id
+FROM sms
+WHERE sms.ref_id = sqlc.arg('ref_id')
+  AND sms_status_history.sms_id IS NULL
+  AND sms.provider_message_id != ''
+  AND sms_status_history.provider = sms.provider
+  AND sms_status_history.provider_message_id = sms.provider_message_id
+RETURNING sms_status_history.*;
+
+-- name: ListSMSStatusHistory :many
+SELECT * FROM sms_status_history
+WHERE sms_id = $1
+ORDER BY created_at, id;
\ No newline at end of file
@@ -0,0 +1,29 @@
+-- name: BeginSMSSubmission :one
+INSERT INTO sms_submissions (
+    ref_id, resend
+) VALUES (
+    $1, $2
+)
+ON CONFLICT (ref_id, resend) DO UPDATE
+SET attempts = sms_submissions.attempts + 1,
+    status = CASE WHEN sms_submissions.status = 'accepted' THEN 'accepted' ELSE 'submitting' END,
+    updated_at = now()
+RETURNING *;
+
+-- name: AcceptSMSSubmission :exec
+UPDATE sms_submissions
+SET status = 'accepted',
+    provider = $3,
+    provider_message_id = $4,
+    cost = $5,
+    description = $6,
+    error = '',
+    updated_at = now()
+WHERE ref_id = $1 AND resend = $2;
+
+-- name: FailSMSSubmission :exec
+UPDATE sms_submissions
+SET status = 'failed',
+    error = $3,
+    updated_at = now()
+WHERE ref_id = $1 AND resend = $2 AND status <> 'accepted';
\ No newline at end of file
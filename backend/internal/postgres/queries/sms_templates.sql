@@ -0,0 +1,30 @@
+-- name: CreateSMSTemplate :one
+INSERT INTO sms_templates (
+    name, body
+) VALUES (
+    $1, $2
+)
+RETURNING *;
+
+-- name: ListSMSTemplates :many
+SELECT * FROM sms_templates
+ORDER BY name;
+
+-- name: GetSMSTemplate :one
+SELECT * FROM sms_templates
+WHERE id = $1;
+
+-- name: GetSMSTemplateByName :one
+SELECT * FROM sms_templates
+WHERE name = $1;
+
+-- name: UpdateSMSTemplate :one
+UPDATE sms_templates
+SET name = coalesce(sqlc.narg('name'), name),
+    body = coalesce(sqlc.narg('body'), body),
+    updated_at = now()
+WHERE id = sqlc.arg('id')
+RETURNING *;
+
+-- name: DeleteSMSTemplate :exec
+DELETE FROM sms_templates WHERE id = $1;
\ No newline at end of file
@@ -0,0 +1,77 @@
+-- name: CreateWebhookSubscription :one
+INSERT INTO webhook_subscriptions (
+    url, events, secret, description
+) VALUES (
+    $1, $2, $3, $4
+)
+RETURNING *;
+
+-- name: ListWebhookSubscriptions :many
+SELECT * FROM webhook_subscriptions
+ORDER BY id;
+
+-- name: GetWebhookSubscription :one
+SELECT * FROM webhook_subscriptions
+WHERE id = $1;
+
+-- name: UpdateWebhookSubscription :one
+UPDATE webhook_subscriptions
+SET url = coalesce(sqlc.narg('url'), url),
+    events = coalesce(sqlc.narg('events'), events),
+    secret = coalesce(sqlc.narg('secret'), secret),
+    description = coalesce(sqlc.narg('description'), description),
+    active = coalesce(sqlc.narg('active'), active),
+    updated_at = now()
+WHERE id = sqlc.arg('id')
+RETURNING *;
+
+-- name: DeleteWebhookSubscription :execrows
+DELETE FROM webhook_subscriptions WHERE id = $1;
+
+-- name: CreateWebhookDeliveries :many
+INSERT INTO webhook_deliveries (
+    subscription_id, event_id, event_type, payload
+)
+SELECT id, sqlc.arg('event_id')::text, sqlc.arg('event_type')::text, sqlc.arg('payload')::jsonb
+FROM webhook_subscriptions
+WHERE active AND sqlc.arg('event_type')::text = ANY(events)
+ON CONFLICT (event_id, subscription_id) DO NOTHING
+RETURNING id;
+
+-- name: GetWebhookDelivery :one
+SELECT
+    webhook_deliveries.*,
+    webhook_subscriptions.url,
+    webhook_subscriptions.secret,
+    webhook_subscriptions.active
+FROM webhook_deliveries
+JOIN webhook_subscriptions ON webhook_subscriptions.id = webhook_deliveries.subscription_id
+WHERE webhook_deliveries.id = $1;
+
+-- name: ListWebhookDeliveries :many
+SELECT * FROM webhook_deliveries
+WHERE subscription_id = $1
+ORDER BY id DESC
+LIMIT $2 OFFSET $3;
+
+-- name: CountWebhookDeliveries :one
+SELECT COUNT(*) AS total_deliveries FROM webhook_deliveries
+WHERE subscription_id = $1;
+
+-- name: RecordWebhookAttempt :exec
+UPDATE webhook_deliveries
+SET status = sqlc.arg('status'),
+    attempts = attempts + 1,
+    response_status = sqlc.arg('response_status'),
+    response_body = sqlc.arg('response_body'),
+    error = sqlc.arg('error'),
+    duration_ms = sqlc.arg('duration_ms'),
+    delivered_at = CASE WHEN sqlc.arg('status') = 'succeeded' THEN now() ELSE delivered_at END,
+    updated_at = now()
+WHERE id = sqlc.arg('id');
+
+-- name: ResetWebhookDelivery :exec
+UPDATE webhook_deliveries
+SET status = 'pending',
+    updated_at = now()
+WHERE id = $1;
\ No newline at end of file
@@ -0,0 +1,204 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/EmilioCliff/jonche/internal/postgres/generated"
+	"github.com/EmilioCliff/jonche/internal/repository"
+	"github.com/EmilioCliff/jonche/internal/services"
+	"github.com/EmilioCliff/jonche/pkg"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var _ repository.ReminderRepository = (*ReminderRepository)(nil)
+
+type ReminderRepository struct {
+	db      *Store
+	queries generated.Querier
+}
+
+func NewReminderRepository(db *Store) *ReminderRepository {
+	return &ReminderRepository{
+		db:      db,
+		queries: generated.New(db.pool),
+	}
+}
+
+func (r *ReminderRepository) ListDueInstalments(
+	ctx context.Context,
+	from, to time.Time,
+) ([]*repository.DueInstalment, error) {
+	rslt, err := r.queries.ListDueInstalments(ctx, generated.ListDueInstalmentsParams{
+		FromDate: pgtype.Date{Valid: true, Time: from},
+		ToDate:   pgtype.Date{Valid: true, Time: to},
+	})
+	if err != nil {
+		return nil, pkg.Errorf(
+			pkg.INTERNAL_ERROR,
+			"error listing due instalments: %s",
+			err.Error(),
+		)
+	}
+
+	instalments := make([]*repository.DueInstalment, len(rslt))
+	for i, instalment := range rslt {
+		instalments[i] = &repository.DueInstalment{
+			LoanID:      uint32(instalment.LoanID),
+			CustomerID:  uint32(instalment.CustomerID),
+			Name:        instalment.Name,
+			PhoneNumber: instalment.PhoneNumber,
+			Loaned:      numericToFloat64(instalment.Loaned),
+			Credit:      numericToFloat64(instalment.Credit),
+			DueDate:     instalment.DueDate.Time,
+			Outstanding: numericToFloat64(instalment.Outstanding),
+		}
+	}
+
+	return instalments, nil
+}
+
+func (r *ReminderRepository) CountCustomerRemindersSince(
+	ctx context.Context,
+	customerID uint32,
+	since time.Time,
+) (int64, error) {
+	count, err := r.queries.CountCustomerRemindersSince(
+		ctx,
+		generated.CountCustomerRemindersSinceParams{
+			CustomerID: int64(customerID),
+			SentAt:     since,
+		},
+	)
+	if err != nil {
+		return 0, pkg.Errorf(
+			pkg.INTERNAL_ERROR,
+			"error counting customer reminders: %s",
+			err.Error(),
+		)
+	}
+
+	return count, nil
+}
+
+func (r *ReminderRepository) CreateLoanReminder(
+	ctx context.Context,
+	reminder *repository.CreateLoanReminder,
+) (bool, error) {
+	created := false
+
+	err := r.db.ExecTx(ctx, func(q *generated.Queries) error {
+		optedOut, err := q.IsCustomerOptedOut(ctx, generated.IsCustomerOptedOutParams{
+			CustomerID: int64(reminder.CustomerID),
+			Category:   repository.SMSCategoryTransactional,
+		})
+		if err != nil {
+			return pkg.Errorf(pkg.INTERNAL_ERROR, "error checking sms consent: %s", err.Error())
+		}
+		if optedOut {
+			return nil
+		}
+
+		refId := uuid.NewString()
+
+		_, err = q.CreateLoanReminder(ctx, generated.CreateLoanReminderParams{
+			LoanID:     int64(reminder.LoanID),
+			CustomerID: int64(reminder.CustomerID),
+			Stage:      reminder.Stage,
+			SmsRefID:   refId,
+		})
+		if err != nil {
+			// the stage was already sent for this loan
+			if err == pgx.ErrNoRows {
+				return nil
+			}
+
+			return pkg.Errorf(pkg.INTERNAL_ERROR, "error creating loan reminder: %s", err.Error())
+		}
+
+		tmpl, err := smsTemplateBody(ctx, q, reminder.Template)
+		if err != nil {
+			return err
+		}
+
+		messages, err := pkg.GenerateMessages(tmpl, []pkg.CustomerTemplateParams{reminder.Params})
+		if err != nil {
+			return err
+		}
+
+		_, err = q.CreateSMS(ctx, generated.CreateSMSParams{
+			CustomerID: int64(reminder.CustomerID),
+			Message:    messages[0],
+			Type:       "automated",
+			RefID:      refId,
+		})
+		if err != nil {
+			return pkg.Errorf(pkg.INTERNAL_ERROR, "error creating sms: %s", err.Error())
+		}
+
+		created = true
+
+		return enqueueSendSMS(ctx, q, services.SendSMSPayload{
+			Message:     messages[0],
+			PhoneNumber: reminder.PhoneNumber,
+			RefID:       refId,
+		}, asynq.MaxRetry(2), asynq.Queue(services.QueueDefault))
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return created, nil
+}
+
+func (r *ReminderRepository) ListLoanReminders(
+	ctx context.Context,
+	pgData *pkg.PaginationMetadata,
+) ([]*repository.LoanReminder, pkg.PaginationMetadata, error) {
+	rslt, err := r.queries.ListLoanReminders(ctx, generated.ListLoanRemindersParams{
+		Limit:  int32(pgData.PageSize),
+		Offset: pkg.CalculateOffset(pgData.CurrentPage, pgData.PageSize),
+	})
+	if err != nil {
+		return nil, pkg.PaginationMetadata{}, pkg.Errorf(
+			pkg.INTERNAL_ERROR,
+			"error listing loan reminders: %s",
+			err.Error(),
+		)
+	}
+
+	reminders := make([]*repository.LoanReminder, len(rslt))
+	for i, reminder := range rslt {
+		reminders[i] = &repository.LoanReminder{
+			ID:         uint32(reminder.ID),
+			LoanID:     uint32(reminder.LoanID),
+			CustomerID: uint32(reminder.CustomerID),
+			Stage:      reminder.Stage,
+			SMSRefID:   reminder.SmsRefID,
+			SentAt:     reminder.SentAt,
+			CustomerDetails: &repository.Customer{
+				ID:          uint32(reminder.CustomerID),
+				Name:        reminder.CustomerName,
+				PhoneNumber: reminder.CustomerPhoneNumber,
+			},
+		}
+	}
+
+	totalReminders, err := r.queries.CountLoanReminders(ctx)
+	if err != nil {
+		return nil, pkg.PaginationMetadata{}, pkg.Errorf(
+			pkg.INTERNAL_ERROR,
+			"failed to count loan reminders: %s",
+			err.Error(),
+		)
+	}
+
+	return reminders, pkg.CreatePaginationMetadata(
+		uint32(totalReminders),
+		pgData.PageSize,
+		pgData.CurrentPage,
+	), nil
+}
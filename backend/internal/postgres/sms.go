@@ -32,72 +32,161 @@ func (s *SMSRepository) CreateSMS(
 	ctx context.Context,
 	sms *repository.SMS,
 	ids []uint32,
-	afterCreate func(context.Context, services.SendSMSPayload, ...asynq.Option) error,
-) error {
+	category string,
+) ([]uint32, error) {
+	var skipped []uint32
+
 	err := s.db.ExecTx(ctx, func(q *generated.Queries) error {
-		var smsTmplParams []pkg.CustomerTemplateParams
-		var phoneNumbers []string
-		var refIds []string
-		for _, id := range ids {
-			refId := uuid.NewString()
-			_, err := q.CreateSMS(ctx, generated.CreateSMSParams{
-				CustomerID: int64(id),
-				Message:    sms.Message,
-				Type:       "manual",
-				RefID:      refId,
-			})
-			if err != nil {
-				if pkg.PgxErrorCode(err) == pkg.FOREIGN_KEY_VIOLATION {
-					return pkg.Errorf(pkg.INVALID_ERROR, "foreign key violation: %s", err.Error())
-				}
-
-				return pkg.Errorf(pkg.INTERNAL_ERROR, "error creating sms: %s", err.Error())
-			}
-			customer, err := q.GetCustomer(ctx, generated.GetCustomerParams{
-				ID: pgtype.Int8{
-					Valid: true,
-					Int64: int64(id),
-				},
-			})
-			if err != nil {
-				if err == pgx.ErrNoRows {
-					return pkg.Errorf(pkg.NOT_FOUND_ERROR, "customer not found")
-				}
-
-				return pkg.Errorf(pkg.INTERNAL_ERROR, "error getting customer: %s", err.Error())
-			}
+		var err error
+		skipped, err = createSMS(ctx, q, sms, ids, category)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return skipped, nil
+}
+
+// createSMS stores and enqueues the message for every customer that has not
+// opted out of the category, and returns those that have.
+func createSMS(
+	ctx context.Context,
+	q *generated.Queries,
+	sms *repository.SMS,
+	ids []uint32,
+	category string,
+) ([]uint32, error) {
+	// marketing sends wait out a lack of credit, transactional ones keep trying
+	queue := services.QueueSMS
+	if category == repository.SMSCategoryTransactional {
+		queue = services.QueueCritical
+	}
 
-			smsTmplParams = append(smsTmplParams, pkg.CustomerTemplateParams{
-				Name:        customer.Name,
-				Loaned:      numericToFloat64(customer.Loaned),
-				PhoneNumber: customer.PhoneNumber,
-			})
+	opts := []asynq.Option{
+		asynq.MaxRetry(2),
+		// asynq.ProcessIn(5 * time.Second),
+		asynq.Queue(queue),
+	}
+
+	smsType := sms.Type
+	if smsType == "" {
+		smsType = "manual"
+	}
 
-			phoneNumbers = append(phoneNumbers, customer.PhoneNumber)
-			refIds = append(refIds, refId)
+	var skipped []uint32
+	for _, id := range ids {
+		recipient, err := renderCustomerSMS(ctx, q, sms.Message, id, category)
+		if err != nil {
+			return nil, err
+		}
+		if recipient.OptedOut {
+			skipped = append(skipped, id)
+
+			continue
 		}
 
-		messages, err := pkg.GenerateMessages(sms.Message, smsTmplParams)
+		// store what the customer actually receives, not the template
+		refId := uuid.NewString()
+		_, err = q.CreateSMS(ctx, generated.CreateSMSParams{
+			CustomerID: int64(id),
+			Message:    recipient.Message,
+			Type:       smsType,
+			RefID:      refId,
+		})
 		if err != nil {
-			return err
+			if pkg.PgxErrorCode(err) == pkg.FOREIGN_KEY_VIOLATION {
+				return nil, pkg.Errorf(pkg.INVALID_ERROR, "foreign key violation: %s", err.Error())
+			}
+
+			return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error creating sms: %s", err.Error())
 		}
 
 		p := services.SendSMSPayload{
-			Messages:     messages,
-			PhoneNumbers: phoneNumbers,
-			RefIds:       refIds,
+			Message:     recipient.Message,
+			PhoneNumber: recipient.PhoneNumber,
+			RefID:       refId,
 		}
 
-		opts := []asynq.Option{
-			asynq.MaxRetry(2),
-			// asynq.ProcessIn(5 * time.Second),
-			asynq.Queue(services.QueueCritical),
+		if err := enqueueSendSMS(ctx, q, p, opts...); err != nil {
+			return nil, err
 		}
+	}
+
+	return skipped, nil
+}
+
+func (s *SMSRepository) RenderSMS(
+	ctx context.Context,
+	message string,
+	ids []uint32,
+	category string,
+) ([]*repository.SMSRecipient, error) {
+	recipients := make([]*repository.SMSRecipient, len(ids))
+	for i, id := range ids {
+		recipient, err := renderCustomerSMS(ctx, s.queries, message, id, category)
+		if err != nil {
+			return nil, err
+		}
+
+		recipients[i] = recipient
+	}
+
+	return recipients, nil
+}
 
-		return afterCreate(ctx, p, opts...)
+// renderCustomerSMS fills the message in for one customer. A customer who opted
+// out of the category is returned without a message.
+func renderCustomerSMS(
+	ctx context.Context,
+	q generated.Querier,
+	message string,
+	id uint32,
+	category string,
+) (*repository.SMSRecipient, error) {
+	optedOut, err := q.IsCustomerOptedOut(ctx, generated.IsCustomerOptedOutParams{
+		CustomerID: int64(id),
+		Category:   category,
 	})
+	if err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error checking sms consent: %s", err.Error())
+	}
+	if optedOut {
+		return &repository.SMSRecipient{CustomerID: id, OptedOut: true}, nil
+	}
 
-	return err
+	customer, err := q.GetCustomer(ctx, generated.GetCustomerParams{
+		ID: pgtype.Int8{
+			Valid: true,
+			Int64: int64(id),
+		},
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "customer not found")
+		}
+
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error getting customer: %s", err.Error())
+	}
+
+	messages, err := pkg.GenerateMessages(message, []pkg.CustomerTemplateParams{
+		{
+			Name:        customer.Name,
+			Loaned:      numericToFloat64(customer.Loaned),
+			Credit:      numericToFloat64(customer.Credit),
+			PhoneNumber: customer.PhoneNumber,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &repository.SMSRecipient{
+		CustomerID:  id,
+		PhoneNumber: customer.PhoneNumber,
+		Message:     messages[0],
+	}, nil
 }
 
 func (s *SMSRepository) ListSMS(
@@ -119,12 +208,15 @@ func (s *SMSRepository) ListSMS(
 	sms := make([]*repository.SMS, len(rslt))
 	for i, sm := range rslt {
 		sms[i] = &repository.SMS{
-			ID:         uint32(sm.ID),
-			CustomerID: uint32(sm.CustomerID),
-			Message:    sm.Message,
-			Type:       sm.Type,
-			Status:     sm.Status,
-			CreatedAt:  sm.CreatedAt,
+			ID:          uint32(sm.ID),
+			CustomerID:  uint32(sm.CustomerID),
+			Message:     sm.Message,
+			Type:        sm.Type,
+			Status:      sm.Status,
+			Cost:        numericToFloat64(sm.Cost),
+			CreatedAt:   sm.CreatedAt,
+			Provider:    sm.Provider,
+			ResendCount: uint32(sm.ResendCount),
 			CustomerDetails: &repository.Customer{
 				ID:          uint32(sm.CustomerID),
 				Name:        sm.CustomerName,
@@ -179,7 +271,9 @@ func (s *SMSRepository) ListCustomerSMS(
 			Message:    sm.Message,
 			Type:       sm.Type,
 			Status:     sm.Status,
+			Cost:       numericToFloat64(sm.Cost),
 			CreatedAt:  sm.CreatedAt,
+			Direction:  sm.Direction,
 			CustomerDetails: &repository.Customer{
 				ID:          uint32(sm.CustomerID),
 				Name:        sm.CustomerName,
@@ -209,9 +303,8 @@ func (s *SMSRepository) UpdateSMS(ctx context.Context, sms *repository.UpdateSMS
 		RefID: sms.RefID.String(),
 	}
 	if sms.Cost != nil {
-		params.Cost = pgtype.Text{
-			Valid:  true,
-			String: *sms.Cost,
+		if err := params.Cost.Scan(pkg.Float64ToString(*sms.Cost)); err != nil {
+			return pkg.Errorf(pkg.INTERNAL_ERROR, "error converting sms cost: %s", err.Error())
 		}
 	}
 	if sms.Description != nil {
@@ -232,12 +325,44 @@ func (s *SMSRepository) UpdateSMS(ctx context.Context, sms *repository.UpdateSMS
 			String: *sms.DeliveryStatus,
 		}
 	}
+	if sms.Provider != nil {
+		params.Provider = pgtype.Text{
+			Valid:  true,
+			String: *sms.Provider,
+		}
+	}
+	if sms.ProviderMessageID == nil && sms.DeliveryStatus == nil {
+		if err := s.queries.UpdateSMS(ctx, params); err != nil {
+			return pkg.Errorf(pkg.INTERNAL_ERROR, "error updating sms: %s", err.Error())
+		}
+
+		return nil
+	}
 
-	if err := s.queries.UpdateSMS(ctx, params); err != nil {
-		return pkg.Errorf(pkg.INTERNAL_ERROR, "error updating sms: %s", err.Error())
+	if sms.ProviderMessageID != nil {
+		params.ProviderMessageID = pgtype.Text{
+			Valid:  true,
+			String: *sms.ProviderMessageID,
+		}
 	}
 
-	return nil
+	return s.db.ExecTx(ctx, func(q *generated.Queries) error {
+		if err := q.UpdateSMS(ctx, params); err != nil {
+			return pkg.Errorf(pkg.INTERNAL_ERROR, "error updating sms: %s", err.Error())
+		}
+
+		if sms.ProviderMessageID != nil {
+			if err := claimSMSStatusHistory(ctx, q, params.RefID); err != nil {
+				return err
+			}
+		}
+
+		if sms.DeliveryStatus != nil {
+			return publishSMSStatusChanged(ctx, q, params.RefID)
+		}
+
+		return nil
+	})
 }
 
 func (s *SMSRepository) GetSMS(ctx context.Context, id uint32) (*repository.SMS, error) {
@@ -251,12 +376,15 @@ func (s *SMSRepository) GetSMS(ctx context.Context, id uint32) (*repository.SMS,
 	}
 
 	return &repository.SMS{
-		ID:         uint32(sms.ID),
-		CustomerID: uint32(sms.CustomerID),
-		Message:    sms.Message,
-		Type:       sms.Type,
-		Status:     sms.Status,
-		CreatedAt:  sms.CreatedAt,
+		ID:          uint32(sms.ID),
+		CustomerID:  uint32(sms.CustomerID),
+		Message:     sms.Message,
+		Type:        sms.Type,
+		Status:      sms.Status,
+		Cost:        numericToFloat64(sms.Cost),
+		CreatedAt:   sms.CreatedAt,
+		Provider:    sms.Provider,
+		ResendCount: uint32(sms.ResendCount),
 		CustomerDetails: &repository.Customer{
 			ID:          uint32(sms.CustomerID),
 			Name:        sms.CustomerName,
@@ -265,6 +393,23 @@ func (s *SMSRepository) GetSMS(ctx context.Context, id uint32) (*repository.SMS,
 	}, nil
 }
 
+func (s *SMSRepository) ListSMSIDsByRefIDs(
+	ctx context.Context,
+	refIDs []string,
+) (map[string]uint32, error) {
+	rows, err := s.queries.ListSMSByRefIDs(ctx, refIDs)
+	if err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error listing sms by ref id: %s", err.Error())
+	}
+
+	ids := make(map[string]uint32, len(rows))
+	for _, row := range rows {
+		ids[row.RefID] = uint32(row.ID)
+	}
+
+	return ids, nil
+}
+
 func (s *SMSRepository) DeliverSMS(ctx context.Context, id uint32) error {
 	ok, err := s.queries.CheckSMSDelivered(ctx, int64(id))
 	if err != nil {
@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/EmilioCliff/jonche/internal/postgres/generated"
+	"github.com/EmilioCliff/jonche/internal/repository"
+	"github.com/EmilioCliff/jonche/pkg"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var _ repository.SMSBalanceRepository = (*SMSBalanceRepository)(nil)
+
+type SMSBalanceRepository struct {
+	db      *Store
+	queries generated.Querier
+}
+
+func NewSMSBalanceRepository(db *Store) *SMSBalanceRepository {
+	return &SMSBalanceRepository{
+		db:      db,
+		queries: generated.New(db.pool),
+	}
+}
+
+func (s *SMSBalanceRepository) UpdateSMSProviderBalance(
+	ctx context.Context,
+	provider string,
+	balance float64,
+) (*repository.SMSProviderBalance, error) {
+	var amount pgtype.Numeric
+	if err := amount.Scan(pkg.Float64ToString(balance)); err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to scan float to numeric: %s", err.Error())
+	}
+
+	rslt, err := s.queries.UpsertSMSProviderBalance(ctx, generated.UpsertSMSProviderBalanceParams{
+		Provider: provider,
+		Balance:  amount,
+	})
+	if err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error updating sms provider balance: %s", err.Error())
+	}
+
+	return smsProviderBalanceFromGenerated(rslt), nil
+}
+
+func (s *SMSBalanceRepository) SetSMSProviderBalanceAlerted(
+	ctx context.Context,
+	provider string,
+	alerted bool,
+) error {
+	if err := s.queries.SetSMSProviderBalanceAlerted(ctx, generated.SetSMSProviderBalanceAlertedParams{
+		Alerted:  alerted,
+		Provider: provider,
+	}); err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "error updating sms provider balance: %s", err.Error())
+	}
+
+	return nil
+}
+
+func (s *SMSBalanceRepository) ListSMSProviderBalances(
+	ctx context.Context,
+) ([]*repository.SMSProviderBalance, error) {
+	rslt, err := s.queries.ListSMSProviderBalances(ctx)
+	if err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error listing sms provider balances: %s", err.Error())
+	}
+
+	balances := make([]*repository.SMSProviderBalance, len(rslt))
+	for i, balance := range rslt {
+		balances[i] = smsProviderBalanceFromGenerated(balance)
+	}
+
+	return balances, nil
+}
+
+func smsProviderBalanceFromGenerated(balance generated.SmsProviderBalance) *repository.SMSProviderBalance {
+	return &repository.SMSProviderBalance{
+		Provider:  balance.Provider,
+		Balance:   numericToFloat64(balance.Balance),
+		Alerted:   balance.Alerted,
+		UpdatedAt: balance.UpdatedAt,
+	}
+}
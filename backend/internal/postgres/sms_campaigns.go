@@ -0,0 +1,273 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/EmilioCliff/jonche/internal/postgres/generated"
+	"github.com/EmilioCliff/jonche/internal/repository"
+	"github.com/EmilioCliff/jonche/pkg"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var _ repository.SMSCampaignRepository = (*SMSCampaignRepository)(nil)
+
+// campaignTransitions lists the statuses a campaign may move to from each status.
+var campaignTransitions = map[string][]string{
+	repository.CampaignScheduled: {repository.CampaignPaused, repository.CampaignCancelled},
+	repository.CampaignPaused:    {repository.CampaignScheduled, repository.CampaignCancelled},
+}
+
+type SMSCampaignRepository struct {
+	db      *Store
+	queries generated.Querier
+}
+
+func NewSMSCampaignRepository(db *Store) *SMSCampaignRepository {
+	return &SMSCampaignRepository{
+		db:      db,
+		queries: generated.New(db.pool),
+	}
+}
+
+func (s *SMSCampaignRepository) CreateSMSCampaign(
+	ctx context.Context,
+	campaign *repository.SMSCampaign,
+) (*repository.SMSCampaign, error) {
+	params := generated.CreateSMSCampaignParams{
+		Name:     campaign.Name,
+		Message:  campaign.Message,
+		CronSpec: campaign.CronSpec,
+	}
+	if campaign.TemplateID != 0 {
+		params.TemplateID = pgtype.Int8{
+			Valid: true,
+			Int64: int64(campaign.TemplateID),
+		}
+	}
+	if campaign.MinBalance != nil {
+		if err := params.MinBalance.Scan(pkg.Float64ToString(*campaign.MinBalance)); err != nil {
+			return nil, pkg.Errorf(
+				pkg.INTERNAL_ERROR,
+				"failed to scan float to numeric: %s",
+				err.Error(),
+			)
+		}
+	}
+	if campaign.SendAt != nil {
+		params.SendAt = pgtype.Timestamptz{
+			Valid: true,
+			Time:  *campaign.SendAt,
+		}
+	}
+
+	rslt, err := s.queries.CreateSMSCampaign(ctx, params)
+	if err != nil {
+		if pkg.PgxErrorCode(err) == pkg.FOREIGN_KEY_VIOLATION {
+			return nil, pkg.Errorf(pkg.INVALID_ERROR, "sms template not found")
+		}
+
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error creating sms campaign: %s", err.Error())
+	}
+
+	return smsCampaignFromGenerated(rslt), nil
+}
+
+func (s *SMSCampaignRepository) ListSMSCampaigns(
+	ctx context.Context,
+	pgData *pkg.PaginationMetadata,
+) ([]*repository.SMSCampaign, pkg.PaginationMetadata, error) {
+	rslt, err := s.queries.ListSMSCampaigns(ctx, generated.ListSMSCampaignsParams{
+		Limit:  int32(pgData.PageSize),
+		Offset: pkg.CalculateOffset(pgData.CurrentPage, pgData.PageSize),
+	})
+	if err != nil {
+		return nil, pkg.PaginationMetadata{}, pkg.Errorf(
+			pkg.INTERNAL_ERROR,
+			"error listing sms campaigns: %s",
+			err.Error(),
+		)
+	}
+
+	campaigns := make([]*repository.SMSCampaign, len(rslt))
+	for i, campaign := range rslt {
+		campaigns[i] = smsCampaignFromGenerated(campaign)
+	}
+
+	totalCampaigns, err := s.queries.CountSMSCampaigns(ctx)
+	if err != nil {
+		return nil, pkg.PaginationMetadata{}, pkg.Errorf(
+			pkg.INTERNAL_ERROR,
+			"failed to count sms campaigns: %s",
+			err.Error(),
+		)
+	}
+
+	return campaigns, pkg.CreatePaginationMetadata(
+		uint32(totalCampaigns),
+		pgData.PageSize,
+		pgData.CurrentPage,
+	), nil
+}
+
+func (s *SMSCampaignRepository) ListRecurringSMSCampaigns(
+	ctx context.Context,
+) ([]*repository.SMSCampaign, error) {
+	rslt, err := s.queries.ListRecurringSMSCampaigns(ctx)
+	if err != nil {
+		return nil, pkg.Errorf(
+			pkg.INTERNAL_ERROR,
+			"error listing recurring sms campaigns: %s",
+			err.Error(),
+		)
+	}
+
+	campaigns := make([]*repository.SMSCampaign, len(rslt))
+	for i, campaign := range rslt {
+		campaigns[i] = smsCampaignFromGenerated(campaign)
+	}
+
+	return campaigns, nil
+}
+
+func (s *SMSCampaignRepository) GetSMSCampaign(
+	ctx context.Context,
+	id uint32,
+) (*repository.SMSCampaign, error) {
+	rslt, err := s.queries.GetSMSCampaign(ctx, int64(id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "sms campaign not found")
+		}
+
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error getting sms campaign: %s", err.Error())
+	}
+
+	return smsCampaignFromGenerated(rslt), nil
+}
+
+// UpdateSMSCampaignStatus pauses, resumes or cancels a campaign. Cancelled and
+// completed campaigns are final.
+func (s *SMSCampaignRepository) UpdateSMSCampaignStatus(
+	ctx context.Context,
+	id uint32,
+	status string,
+) (*repository.SMSCampaign, error) {
+	current, err := s.GetSMSCampaign(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := false
+	for _, next := range campaignTransitions[current.Status] {
+		if next == status {
+			allowed = true
+		}
+	}
+	if !allowed {
+		return nil, pkg.Errorf(
+			pkg.INVALID_ERROR,
+			"a %s campaign cannot be changed to %s",
+			current.Status,
+			status,
+		)
+	}
+
+	rslt, err := s.queries.UpdateSMSCampaignStatus(ctx, generated.UpdateSMSCampaignStatusParams{
+		Status: status,
+		ID:     int64(id),
+	})
+	if err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error updating sms campaign: %s", err.Error())
+	}
+
+	return smsCampaignFromGenerated(rslt), nil
+}
+
+// DispatchSMSCampaign sends the message to the customers and records the run in
+// one transaction, so a retry of a one-off campaign finds it completed instead of
+// texting everyone again. It returns the customers that opted out.
+func (s *SMSCampaignRepository) DispatchSMSCampaign(
+	ctx context.Context,
+	id uint32,
+	sms *repository.SMS,
+	ids []uint32,
+) ([]uint32, error) {
+	var skipped []uint32
+
+	err := s.db.ExecTx(ctx, func(q *generated.Queries) error {
+		var err error
+		if skipped, err = createSMS(ctx, q, sms, ids, repository.SMSCategoryMarketing); err != nil {
+			return err
+		}
+
+		if err := q.MarkSMSCampaignRun(ctx, int64(id)); err != nil {
+			return pkg.Errorf(pkg.INTERNAL_ERROR, "error marking sms campaign run: %s", err.Error())
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return skipped, nil
+}
+
+// ListCampaignRecipients returns the active customers owing more than minBalance,
+// or every active customer when minBalance is nil.
+func (s *SMSCampaignRepository) ListCampaignRecipients(
+	ctx context.Context,
+	minBalance *float64,
+) ([]uint32, error) {
+	var balance pgtype.Numeric
+	if minBalance != nil {
+		if err := balance.Scan(pkg.Float64ToString(*minBalance)); err != nil {
+			return nil, pkg.Errorf(
+				pkg.INTERNAL_ERROR,
+				"failed to scan float to numeric: %s",
+				err.Error(),
+			)
+		}
+	}
+
+	rslt, err := s.queries.ListCampaignRecipients(ctx, balance)
+	if err != nil {
+		return nil, pkg.Errorf(
+			pkg.INTERNAL_ERROR,
+			"error listing campaign recipients: %s",
+			err.Error(),
+		)
+	}
+
+	ids := make([]uint32, len(rslt))
+	for i, id := range rslt {
+		ids[i] = uint32(id)
+	}
+
+	return ids, nil
+}
+
+func smsCampaignFromGenerated(campaign generated.SmsCampaign) *repository.SMSCampaign {
+	rsp := &repository.SMSCampaign{
+		ID:         uint32(campaign.ID),
+		Name:       campaign.Name,
+		Message:    campaign.Message,
+		TemplateID: uint32(campaign.TemplateID.Int64),
+		CronSpec:   campaign.CronSpec,
+		Status:     campaign.Status,
+		CreatedAt:  campaign.CreatedAt,
+	}
+	if campaign.MinBalance.Valid {
+		minBalance := numericToFloat64(campaign.MinBalance)
+		rsp.MinBalance = &minBalance
+	}
+	if campaign.SendAt.Valid {
+		rsp.SendAt = &campaign.SendAt.Time
+	}
+	if campaign.LastRunAt.Valid {
+		rsp.LastRunAt = &campaign.LastRunAt.Time
+	}
+
+	return rsp
+}
@@ -0,0 +1,158 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/EmilioCliff/jonche/internal/postgres/generated"
+	"github.com/EmilioCliff/jonche/internal/repository"
+	"github.com/EmilioCliff/jonche/pkg"
+)
+
+var _ repository.SMSConsentRepository = (*SMSConsentRepository)(nil)
+
+type SMSConsentRepository struct {
+	db      *Store
+	queries generated.Querier
+}
+
+func NewSMSConsentRepository(db *Store) *SMSConsentRepository {
+	return &SMSConsentRepository{
+		db:      db,
+		queries: generated.New(db.pool),
+	}
+}
+
+func (s *SMSConsentRepository) SetSMSConsents(
+	ctx context.Context,
+	consents []*repository.SMSConsent,
+) ([]*repository.SMSConsent, error) {
+	rsp := make([]*repository.SMSConsent, 0, len(consents))
+	// set inside the transaction, its errors all come out as internal
+	var customerNotFound bool
+
+	err := s.db.ExecTx(ctx, func(q *generated.Queries) error {
+		for _, consent := range consents {
+			rslt, err := q.UpsertSMSConsent(ctx, generated.UpsertSMSConsentParams{
+				CustomerID: int64(consent.CustomerID),
+				Category:   consent.Category,
+				OptedOut:   consent.OptedOut,
+				Source:     consent.Source,
+				Reason:     consent.Reason,
+			})
+			if err != nil {
+				if pkg.PgxErrorCode(err) == pkg.FOREIGN_KEY_VIOLATION {
+					customerNotFound = true
+				}
+
+				return pkg.Errorf(pkg.INTERNAL_ERROR, "error setting sms consent: %s", err.Error())
+			}
+
+			rsp = append(rsp, smsConsentFromGenerated(rslt))
+		}
+
+		return nil
+	})
+	if customerNotFound {
+		return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "customer not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return rsp, nil
+}
+
+func (s *SMSConsentRepository) ListCustomerSMSConsents(
+	ctx context.Context,
+	customerID uint32,
+) ([]*repository.SMSConsent, error) {
+	rslt, err := s.queries.ListCustomerSMSConsents(ctx, int64(customerID))
+	if err != nil {
+		return nil, pkg.Errorf(
+			pkg.INTERNAL_ERROR,
+			"error listing customer sms consents: %s",
+			err.Error(),
+		)
+	}
+
+	stored := make(map[string]generated.SmsConsent, len(rslt))
+	for _, consent := range rslt {
+		stored[consent.Category] = consent
+	}
+
+	consents := make([]*repository.SMSConsent, len(repository.SMSCategories))
+	for i, category := range repository.SMSCategories {
+		if consent, ok := stored[category]; ok {
+			consents[i] = smsConsentFromGenerated(consent)
+
+			continue
+		}
+
+		consents[i] = &repository.SMSConsent{
+			CustomerID: customerID,
+			Category:   category,
+		}
+	}
+
+	return consents, nil
+}
+
+func (s *SMSConsentRepository) ListOptedOutCustomers(
+	ctx context.Context,
+	pgData *pkg.PaginationMetadata,
+) ([]*repository.SMSConsent, pkg.PaginationMetadata, error) {
+	rslt, err := s.queries.ListOptedOutCustomers(ctx, generated.ListOptedOutCustomersParams{
+		Limit:  int32(pgData.PageSize),
+		Offset: pkg.CalculateOffset(pgData.CurrentPage, pgData.PageSize),
+	})
+	if err != nil {
+		return nil, pkg.PaginationMetadata{}, pkg.Errorf(
+			pkg.INTERNAL_ERROR,
+			"error listing opted out customers: %s",
+			err.Error(),
+		)
+	}
+
+	consents := make([]*repository.SMSConsent, len(rslt))
+	for i, consent := range rslt {
+		consents[i] = &repository.SMSConsent{
+			CustomerID: uint32(consent.CustomerID),
+			Category:   consent.Category,
+			OptedOut:   consent.OptedOut,
+			Source:     consent.Source,
+			Reason:     consent.Reason,
+			UpdatedAt:  consent.UpdatedAt,
+			CustomerDetails: &repository.Customer{
+				ID:          uint32(consent.CustomerID),
+				Name:        consent.CustomerName,
+				PhoneNumber: consent.CustomerPhoneNumber,
+			},
+		}
+	}
+
+	totalConsents, err := s.queries.CountOptedOutCustomers(ctx)
+	if err != nil {
+		return nil, pkg.PaginationMetadata{}, pkg.Errorf(
+			pkg.INTERNAL_ERROR,
+			"failed to count opted out customers: %s",
+			err.Error(),
+		)
+	}
+
+	return consents, pkg.CreatePaginationMetadata(
+		uint32(totalConsents),
+		pgData.PageSize,
+		pgData.CurrentPage,
+	), nil
+}
+
+func smsConsentFromGenerated(consent generated.SmsConsent) *repository.SMSConsent {
+	return &repository.SMSConsent{
+		CustomerID: uint32(consent.CustomerID),
+		Category:   consent.Category,
+		OptedOut:   consent.OptedOut,
+		Source:     consent.Source,
+		Reason:     consent.Reason,
+		UpdatedAt:  consent.UpdatedAt,
+	}
+}
@@ -0,0 +1,258 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/EmilioCliff/jonche/internal/postgres/generated"
+	"github.com/EmilioCliff/jonche/internal/repository"
+	"github.com/EmilioCliff/jonche/internal/services"
+	"github.com/EmilioCliff/jonche/pkg"
+	"github.com/hibiken/asynq"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func (s *SMSRepository) RecordDeliveryReport(
+	ctx context.Context,
+	report *services.DeliveryReport,
+	policy repository.SMSResendPolicy,
+) (*repository.SMSStatusHistory, error) {
+	var entry generated.SmsStatusHistory
+
+	err := s.db.ExecTx(ctx, func(q *generated.Queries) error {
+		sms, err := q.GetSMSForDeliveryReport(ctx, generated.GetSMSForDeliveryReportParams{
+			RefID:             report.RefID,
+			ProviderMessageID: report.ProviderMessageID,
+			Provider:          report.Provider,
+		})
+		if err != nil {
+			if err != pgx.ErrNoRows {
+				return pkg.Errorf(pkg.INTERNAL_ERROR, "error getting sms: %s", err.Error())
+			}
+
+			// the report can beat the worker saving the provider's message id, it is
+			// claimed by the message once the id is saved
+			entry, err = q.CreateSMSStatusHistory(ctx, generated.CreateSMSStatusHistoryParams{
+				RefID:             report.RefID,
+				Provider:          report.Provider,
+				ProviderMessageID: report.ProviderMessageID,
+				Status:            report.Status,
+				ProviderStatus:    report.ProviderStatus,
+				Description:       report.FailureReason,
+			})
+			if err != nil {
+				return pkg.Errorf(pkg.INTERNAL_ERROR, "error creating sms status history: %s", err.Error())
+			}
+
+			return nil
+		}
+
+		// resends keep the ref id, a late report for an earlier send only goes in the
+		// history. Until the worker saves the id of the current send a report is not
+		// attached, it is claimed if the id turns out to be this send's.
+		if report.ProviderMessageID != "" && report.ProviderMessageID != sms.ProviderMessageID {
+			params := generated.CreateSMSStatusHistoryParams{
+				RefID:             sms.RefID,
+				Provider:          report.Provider,
+				ProviderMessageID: report.ProviderMessageID,
+				Status:            report.Status,
+				ProviderStatus:    report.ProviderStatus,
+				Description:       report.FailureReason,
+			}
+			if sms.ProviderMessageID != "" {
+				params.SmsID = pgtype.Int8{
+					Valid: true,
+					Int64: sms.ID,
+				}
+			}
+
+			entry, err = q.CreateSMSStatusHistory(ctx, params)
+			if err != nil {
+				return pkg.Errorf(pkg.INTERNAL_ERROR, "error creating sms status history: %s", err.Error())
+			}
+
+			return nil
+		}
+
+		entry, err = q.CreateSMSStatusHistory(ctx, generated.CreateSMSStatusHistoryParams{
+			SmsID: pgtype.Int8{
+				Valid: true,
+				Int64: sms.ID,
+			},
+			RefID:             sms.RefID,
+			Provider:          report.Provider,
+			ProviderMessageID: report.ProviderMessageID,
+			Status:            report.Status,
+			ProviderStatus:    report.ProviderStatus,
+			Description:       report.FailureReason,
+		})
+		if err != nil {
+			return pkg.Errorf(pkg.INTERNAL_ERROR, "error creating sms status history: %s", err.Error())
+		}
+
+		if !smsStatusChangeAllowed(sms.Status, report.Status) {
+			return nil
+		}
+
+		params := generated.UpdateSMSParams{
+			RefID: sms.RefID,
+			Status: pgtype.Text{
+				Valid:  true,
+				String: report.Status,
+			},
+			CallbackStatus: pgtype.Text{
+				Valid:  true,
+				String: report.ProviderStatus,
+			},
+		}
+		if report.FailureReason != "" {
+			params.Description = pgtype.Text{
+				Valid:  true,
+				String: report.FailureReason,
+			}
+		}
+		if err := q.UpdateSMS(ctx, params); err != nil {
+			return pkg.Errorf(pkg.INTERNAL_ERROR, "error updating sms: %s", err.Error())
+		}
+
+		event := smsEventFromGenerated(sms)
+		event.Status = report.Status
+		if err := publishEvent(ctx, q, services.EventSMSStatusChanged, event); err != nil {
+			return err
+		}
+		if report.Status == services.SMSStatusDelivered {
+			if err := publishEvent(ctx, q, services.EventSMSDelivered, event); err != nil {
+				return err
+			}
+		}
+
+		if !smsStatusResendable(report.Status) || uint32(sms.ResendCount) >= policy.MaxResends {
+			return nil
+		}
+
+		if err := q.MarkSMSResend(ctx, sms.ID); err != nil {
+			return pkg.Errorf(pkg.INTERNAL_ERROR, "error marking sms resend: %s", err.Error())
+		}
+
+		if _, err := q.CreateSMSStatusHistory(ctx, generated.CreateSMSStatusHistoryParams{
+			SmsID: pgtype.Int8{
+				Valid: true,
+				Int64: sms.ID,
+			},
+			RefID:       sms.RefID,
+			Status:      services.SMSStatusPending,
+			Description: fmt.Sprintf("resend %d of %d", sms.ResendCount+1, policy.MaxResends),
+		}); err != nil {
+			return pkg.Errorf(pkg.INTERNAL_ERROR, "error creating sms status history: %s", err.Error())
+		}
+
+		return enqueueSendSMS(ctx, q, services.SendSMSPayload{
+			PhoneNumber: sms.CustomerPhoneNumber,
+			Message:     sms.Message,
+			RefID:       sms.RefID,
+			Resend:      uint32(sms.ResendCount) + 1,
+		},
+			asynq.ProcessIn(policy.Delay),
+			asynq.Queue(services.QueueDefault),
+			asynq.MaxRetry(2),
+		)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return smsStatusHistoryFromGenerated(entry), nil
+}
+
+func (s *SMSRepository) ListSMSStatusHistory(
+	ctx context.Context,
+	id uint32,
+) ([]*repository.SMSStatusHistory, error) {
+	rslt, err := s.queries.ListSMSStatusHistory(ctx, pgtype.Int8{
+		Valid: true,
+		Int64: int64(id),
+	})
+	if err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error listing sms status history: %s", err.Error())
+	}
+
+	history := make([]*repository.SMSStatusHistory, len(rslt))
+	for i, entry := range rslt {
+		history[i] = smsStatusHistoryFromGenerated(entry)
+	}
+
+	return history, nil
+}
+
+// claimSMSStatusHistory attaches reports that arrived before the provider's message
+// id was saved and applies the latest one.
+func claimSMSStatusHistory(ctx context.Context, q *generated.Queries, refID string) error {
+	claimed, err := q.ClaimSMSStatusHistory(ctx, refID)
+	if err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "error claiming sms status history: %s", err.Error())
+	}
+	if len(claimed) == 0 {
+		return nil
+	}
+
+	latest := claimed[0]
+	for _, entry := range claimed[1:] {
+		if entry.CreatedAt.After(latest.CreatedAt) {
+			latest = entry
+		}
+	}
+	if latest.Status == services.SMSStatusPending {
+		return nil
+	}
+
+	// not resent, a message this quick to fail is left for staff to look at
+	if err := q.UpdateSMS(ctx, generated.UpdateSMSParams{
+		RefID: refID,
+		Status: pgtype.Text{
+			Valid:  true,
+			String: latest.Status,
+		},
+		CallbackStatus: pgtype.Text{
+			Valid:  true,
+			String: latest.ProviderStatus,
+		},
+	}); err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "error updating sms: %s", err.Error())
+	}
+
+	return nil
+}
+
+// smsStatusChangeAllowed keeps delivered final and stops a late pending report
+// from undoing a failure. Resends move a message back to pending themselves.
+func smsStatusChangeAllowed(from, to string) bool {
+	switch {
+	case from == to:
+		return false
+	case from == services.SMSStatusDelivered:
+		return false
+	case to == services.SMSStatusPending:
+		return false
+	default:
+		return true
+	}
+}
+
+func smsStatusResendable(status string) bool {
+	return status == services.SMSStatusFailed || status == services.SMSStatusExpired
+}
+
+func smsStatusHistoryFromGenerated(entry generated.SmsStatusHistory) *repository.SMSStatusHistory {
+	return &repository.SMSStatusHistory{
+		ID:                uint32(entry.ID),
+		SMSID:             uint32(entry.SmsID.Int64),
+		RefID:             entry.RefID,
+		Provider:          entry.Provider,
+		ProviderMessageID: entry.ProviderMessageID,
+		Status:            entry.Status,
+		ProviderStatus:    entry.ProviderStatus,
+		Description:       entry.Description,
+		CreatedAt:         entry.CreatedAt,
+	}
+}
@@ -0,0 +1,157 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/EmilioCliff/jonche/internal/postgres/generated"
+	"github.com/EmilioCliff/jonche/internal/repository"
+	"github.com/EmilioCliff/jonche/internal/services"
+	"github.com/EmilioCliff/jonche/pkg"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func (s *SMSRepository) CreateInboundSMS(
+	ctx context.Context,
+	sms *repository.InboundSMS,
+) (*repository.InboundSMS, error) {
+	var rsp *repository.InboundSMS
+
+	err := s.db.ExecTx(ctx, func(q *generated.Queries) error {
+		var customerID pgtype.Int8
+
+		customer, err := q.GetCustomerByPhoneSuffix(ctx, pkg.PhoneSuffix(sms.PhoneNumber))
+		if err != nil && err != pgx.ErrNoRows {
+			return pkg.Errorf(pkg.INTERNAL_ERROR, "error getting customer: %s", err.Error())
+		}
+		if err == nil {
+			customerID = pgtype.Int8{Valid: true, Int64: customer.ID}
+		}
+
+		rslt, err := q.CreateInboundSMS(ctx, generated.CreateInboundSMSParams{
+			CustomerID:        customerID,
+			PhoneNumber:       sms.PhoneNumber,
+			Message:           sms.Message,
+			Keyword:           sms.Keyword,
+			ProviderMessageID: sms.ProviderMessageID,
+		})
+		if err != nil {
+			// providers resend a message until they get a 200
+			if err == pgx.ErrNoRows {
+				return pkg.Errorf(pkg.ALREADY_EXISTS_ERROR, "inbound sms already received")
+			}
+
+			return pkg.Errorf(pkg.INTERNAL_ERROR, "error creating inbound sms: %s", err.Error())
+		}
+
+		rsp = inboundSMSFromGenerated(rslt)
+
+		if !customerID.Valid || sms.Keyword != repository.SMSKeywordBalance {
+			return nil
+		}
+
+		// the customer asked for this reply so it is sent even when they opted out
+		tmpl, err := smsTemplateBody(ctx, q, services.BalanceReplyTemplate)
+		if err != nil {
+			return err
+		}
+
+		messages, err := pkg.GenerateMessages(tmpl, []pkg.CustomerTemplateParams{
+			{
+				Name:        customer.Name,
+				PhoneNumber: customer.PhoneNumber,
+				Loaned:      numericToFloat64(customer.Loaned),
+				Credit:      numericToFloat64(customer.Credit),
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		refId := uuid.NewString()
+		if _, err := q.CreateSMS(ctx, generated.CreateSMSParams{
+			CustomerID: customer.ID,
+			Message:    messages[0],
+			Type:       "automated",
+			RefID:      refId,
+		}); err != nil {
+			return pkg.Errorf(pkg.INTERNAL_ERROR, "error creating sms: %s", err.Error())
+		}
+
+		return enqueueSendSMS(ctx, q, services.SendSMSPayload{
+			Message:     messages[0],
+			PhoneNumber: customer.PhoneNumber,
+			RefID:       refId,
+		}, asynq.MaxRetry(2), asynq.Queue(services.QueueCritical))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rsp, nil
+}
+
+func (s *SMSRepository) ListInboundSMS(
+	ctx context.Context,
+	pgData *pkg.PaginationMetadata,
+) ([]*repository.InboundSMS, pkg.PaginationMetadata, error) {
+	rslt, err := s.queries.ListInboundSMS(ctx, generated.ListInboundSMSParams{
+		Limit:  int32(pgData.PageSize),
+		Offset: pkg.CalculateOffset(pgData.CurrentPage, pgData.PageSize),
+	})
+	if err != nil {
+		return nil, pkg.PaginationMetadata{}, pkg.Errorf(
+			pkg.INTERNAL_ERROR,
+			"error listing inbound sms: %s",
+			err.Error(),
+		)
+	}
+
+	sms := make([]*repository.InboundSMS, len(rslt))
+	for i, sm := range rslt {
+		sms[i] = &repository.InboundSMS{
+			ID:                uint32(sm.ID),
+			CustomerID:        uint32(sm.CustomerID.Int64),
+			PhoneNumber:       sm.PhoneNumber,
+			Message:           sm.Message,
+			Keyword:           sm.Keyword,
+			ProviderMessageID: sm.ProviderMessageID,
+			ReceivedAt:        sm.ReceivedAt,
+		}
+		if sm.CustomerID.Valid {
+			sms[i].CustomerDetails = &repository.Customer{
+				ID:   uint32(sm.CustomerID.Int64),
+				Name: sm.CustomerName,
+			}
+		}
+	}
+
+	totalSMS, err := s.queries.CountInboundSMS(ctx)
+	if err != nil {
+		return nil, pkg.PaginationMetadata{}, pkg.Errorf(
+			pkg.INTERNAL_ERROR,
+			"failed to count inbound sms: %s",
+			err.Error(),
+		)
+	}
+
+	return sms, pkg.CreatePaginationMetadata(
+		uint32(totalSMS),
+		pgData.PageSize,
+		pgData.CurrentPage,
+	), nil
+}
+
+func inboundSMSFromGenerated(sms generated.SmsInbound) *repository.InboundSMS {
+	return &repository.InboundSMS{
+		ID:                uint32(sms.ID),
+		CustomerID:        uint32(sms.CustomerID.Int64),
+		PhoneNumber:       sms.PhoneNumber,
+		Message:           sms.Message,
+		Keyword:           sms.Keyword,
+		ProviderMessageID: sms.ProviderMessageID,
+		ReceivedAt:        sms.ReceivedAt,
+	}
+}
@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/EmilioCliff/jonche/internal/postgres/generated"
+	"github.com/EmilioCliff/jonche/internal/repository"
+	"github.com/EmilioCliff/jonche/pkg"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func (s *SMSRepository) BeginSMSSubmission(
+	ctx context.Context,
+	refID string,
+	resend uint32,
+) (*repository.SMSSubmission, error) {
+	submission, err := s.queries.BeginSMSSubmission(ctx, generated.BeginSMSSubmissionParams{
+		RefID:  refID,
+		Resend: int32(resend),
+	})
+	if err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error recording sms submission: %s", err.Error())
+	}
+
+	return smsSubmissionFromGenerated(submission), nil
+}
+
+func (s *SMSRepository) AcceptSMSSubmission(
+	ctx context.Context,
+	submission *repository.SMSSubmission,
+) error {
+	var cost pgtype.Numeric
+	if err := cost.Scan(pkg.Float64ToString(submission.Cost)); err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to scan float to numeric: %s", err.Error())
+	}
+
+	if err := s.queries.AcceptSMSSubmission(ctx, generated.AcceptSMSSubmissionParams{
+		RefID:             submission.RefID,
+		Resend:            int32(submission.Resend),
+		Provider:          submission.Provider,
+		ProviderMessageID: submission.ProviderMessageID,
+		Cost:              cost,
+		Description:       submission.Description,
+	}); err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "error updating sms submission: %s", err.Error())
+	}
+
+	return nil
+}
+
+func (s *SMSRepository) FailSMSSubmission(
+	ctx context.Context,
+	refID string,
+	resend uint32,
+	reason string,
+) error {
+	if err := s.queries.FailSMSSubmission(ctx, generated.FailSMSSubmissionParams{
+		RefID:  refID,
+		Resend: int32(resend),
+		Error:  reason,
+	}); err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "error updating sms submission: %s", err.Error())
+	}
+
+	return nil
+}
+
+func smsSubmissionFromGenerated(submission generated.SmsSubmission) *repository.SMSSubmission {
+	return &repository.SMSSubmission{
+		RefID:             submission.RefID,
+		Resend:            uint32(submission.Resend),
+		Status:            submission.Status,
+		Provider:          submission.Provider,
+		ProviderMessageID: submission.ProviderMessageID,
+		Cost:              numericToFloat64(submission.Cost),
+		Description:       submission.Description,
+		Attempts:          uint32(submission.Attempts),
+		Error:             submission.Error,
+		CreatedAt:         submission.CreatedAt,
+		UpdatedAt:         submission.UpdatedAt,
+	}
+}
@@ -0,0 +1,193 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/EmilioCliff/jonche/internal/postgres/generated"
+	"github.com/EmilioCliff/jonche/internal/repository"
+	"github.com/EmilioCliff/jonche/internal/services"
+	"github.com/EmilioCliff/jonche/pkg"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var _ repository.SMSTemplateRepository = (*SMSTemplateRepository)(nil)
+
+type SMSTemplateRepository struct {
+	db      *Store
+	queries generated.Querier
+}
+
+func NewSMSTemplateRepository(db *Store) *SMSTemplateRepository {
+	return &SMSTemplateRepository{
+		db:      db,
+		queries: generated.New(db.pool),
+	}
+}
+
+func (s *SMSTemplateRepository) CreateSMSTemplate(
+	ctx context.Context,
+	template *repository.SMSTemplate,
+) (*repository.SMSTemplate, error) {
+	rslt, err := s.queries.CreateSMSTemplate(ctx, generated.CreateSMSTemplateParams{
+		Name: template.Name,
+		Body: template.Body,
+	})
+	if err != nil {
+		if pkg.PgxErrorCode(err) == pkg.UNIQUE_VIOLATION {
+			return nil, pkg.Errorf(pkg.ALREADY_EXISTS_ERROR, "template %q already exists", template.Name)
+		}
+
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error creating sms template: %s", err.Error())
+	}
+
+	return smsTemplateFromGenerated(rslt), nil
+}
+
+func (s *SMSTemplateRepository) ListSMSTemplates(
+	ctx context.Context,
+) ([]*repository.SMSTemplate, error) {
+	rslt, err := s.queries.ListSMSTemplates(ctx)
+	if err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error listing sms templates: %s", err.Error())
+	}
+
+	templates := make([]*repository.SMSTemplate, len(rslt))
+	for i, template := range rslt {
+		templates[i] = smsTemplateFromGenerated(template)
+	}
+
+	return templates, nil
+}
+
+func (s *SMSTemplateRepository) GetSMSTemplate(
+	ctx context.Context,
+	id uint32,
+) (*repository.SMSTemplate, error) {
+	rslt, err := s.queries.GetSMSTemplate(ctx, int64(id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "sms template not found")
+		}
+
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error getting sms template: %s", err.Error())
+	}
+
+	return smsTemplateFromGenerated(rslt), nil
+}
+
+func (s *SMSTemplateRepository) GetSMSTemplateByName(
+	ctx context.Context,
+	name string,
+) (*repository.SMSTemplate, error) {
+	rslt, err := s.queries.GetSMSTemplateByName(ctx, name)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "sms template not found")
+		}
+
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error getting sms template: %s", err.Error())
+	}
+
+	return smsTemplateFromGenerated(rslt), nil
+}
+
+func (s *SMSTemplateRepository) UpdateSMSTemplate(
+	ctx context.Context,
+	template *repository.UpdateSMSTemplate,
+) (*repository.SMSTemplate, error) {
+	current, err := s.GetSMSTemplate(ctx, template.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	params := generated.UpdateSMSTemplateParams{
+		ID: int64(template.ID),
+	}
+	if template.Name != nil && *template.Name != current.Name {
+		if current.System {
+			return nil, pkg.Errorf(pkg.INVALID_ERROR, "system templates cannot be renamed")
+		}
+
+		params.Name = pgtype.Text{
+			Valid:  true,
+			String: *template.Name,
+		}
+	}
+	if template.Body != nil {
+		params.Body = pgtype.Text{
+			Valid:  true,
+			String: *template.Body,
+		}
+	}
+
+	rslt, err := s.queries.UpdateSMSTemplate(ctx, params)
+	if err != nil {
+		if pkg.PgxErrorCode(err) == pkg.UNIQUE_VIOLATION {
+			return nil, pkg.Errorf(pkg.ALREADY_EXISTS_ERROR, "template %q already exists", *template.Name)
+		}
+
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error updating sms template: %s", err.Error())
+	}
+
+	return smsTemplateFromGenerated(rslt), nil
+}
+
+func (s *SMSTemplateRepository) DeleteSMSTemplate(ctx context.Context, id uint32) error {
+	current, err := s.GetSMSTemplate(ctx, id)
+	if err != nil {
+		return err
+	}
+	if current.System {
+		return pkg.Errorf(pkg.INVALID_ERROR, "system templates cannot be deleted")
+	}
+
+	if err := s.queries.DeleteSMSTemplate(ctx, int64(id)); err != nil {
+		if pkg.PgxErrorCode(err) == pkg.FOREIGN_KEY_VIOLATION {
+			return pkg.Errorf(pkg.INVALID_ERROR, "sms template is used by a campaign")
+		}
+
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "error deleting sms template: %s", err.Error())
+	}
+
+	return nil
+}
+
+// defaultSMSTemplates are the built in system templates, used when one has been
+// removed from the database by hand.
+var defaultSMSTemplates = map[string]string{
+	services.PaymentSMSTemplate:      services.PaymentSMS,
+	services.ReminderBeforeTemplate:  services.ReminderBeforeSMS,
+	services.ReminderDueTemplate:     services.ReminderDueSMS,
+	services.ReminderOverdueTemplate: services.ReminderOverdueSMS,
+	services.BalanceReplyTemplate:    services.BalanceReplySMS,
+}
+
+// smsTemplateBody returns the editable template with the given name.
+func smsTemplateBody(ctx context.Context, q *generated.Queries, name string) (string, error) {
+	template, err := q.GetSMSTemplateByName(ctx, name)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			if body, ok := defaultSMSTemplates[name]; ok {
+				return body, nil
+			}
+
+			return "", pkg.Errorf(pkg.NOT_FOUND_ERROR, "sms template %s not found", name)
+		}
+
+		return "", pkg.Errorf(pkg.INTERNAL_ERROR, "error getting %s sms template: %s", name, err.Error())
+	}
+
+	return template.Body, nil
+}
+
+func smsTemplateFromGenerated(template generated.SmsTemplate) *repository.SMSTemplate {
+	return &repository.SMSTemplate{
+		ID:        uint32(template.ID),
+		Name:      template.Name,
+		Body:      template.Body,
+		System:    template.System,
+		CreatedAt: template.CreatedAt,
+		UpdatedAt: template.UpdatedAt,
+	}
+}
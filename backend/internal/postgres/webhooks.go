@@ -0,0 +1,292 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/EmilioCliff/jonche/internal/postgres/generated"
+	"github.com/EmilioCliff/jonche/internal/repository"
+	"github.com/EmilioCliff/jonche/internal/services"
+	"github.com/EmilioCliff/jonche/pkg"
+	"github.com/hibiken/asynq"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var _ repository.WebhookRepository = (*WebhookRepository)(nil)
+
+type WebhookRepository struct {
+	db      *Store
+	queries generated.Querier
+}
+
+func NewWebhookRepository(db *Store) *WebhookRepository {
+	return &WebhookRepository{
+		db:      db,
+		queries: generated.New(db.pool),
+	}
+}
+
+func (w *WebhookRepository) CreateWebhookSubscription(
+	ctx context.Context,
+	subscription *repository.WebhookSubscription,
+) (*repository.WebhookSubscription, error) {
+	rslt, err := w.queries.CreateWebhookSubscription(ctx, generated.CreateWebhookSubscriptionParams{
+		Url:         subscription.URL,
+		Events:      subscription.Events,
+		Secret:      subscription.Secret,
+		Description: subscription.Description,
+	})
+	if err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error creating webhook subscription: %s", err.Error())
+	}
+
+	return webhookSubscriptionFromGenerated(rslt), nil
+}
+
+func (w *WebhookRepository) ListWebhookSubscriptions(
+	ctx context.Context,
+) ([]*repository.WebhookSubscription, error) {
+	rslt, err := w.queries.ListWebhookSubscriptions(ctx)
+	if err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error listing webhook subscriptions: %s", err.Error())
+	}
+
+	subscriptions := make([]*repository.WebhookSubscription, len(rslt))
+	for i, subscription := range rslt {
+		subscriptions[i] = webhookSubscriptionFromGenerated(subscription)
+	}
+
+	return subscriptions, nil
+}
+
+func (w *WebhookRepository) GetWebhookSubscription(
+	ctx context.Context,
+	id uint32,
+) (*repository.WebhookSubscription, error) {
+	rslt, err := w.queries.GetWebhookSubscription(ctx, int64(id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "webhook subscription not found")
+		}
+
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error getting webhook subscription: %s", err.Error())
+	}
+
+	return webhookSubscriptionFromGenerated(rslt), nil
+}
+
+func (w *WebhookRepository) UpdateWebhookSubscription(
+	ctx context.Context,
+	subscription *repository.UpdateWebhookSubscription,
+) (*repository.WebhookSubscription, error) {
+	params := generated.UpdateWebhookSubscriptionParams{
+		ID:     int64(subscription.ID),
+		Events: subscription.Events,
+	}
+	if subscription.URL != nil {
+		params.Url = pgtype.Text{
+			Valid:  true,
+			String: *subscription.URL,
+		}
+	}
+	if subscription.Secret != nil {
+		params.Secret = pgtype.Text{
+			Valid:  true,
+			String: *subscription.Secret,
+		}
+	}
+	if subscription.Description != nil {
+		params.Description = pgtype.Text{
+			Valid:  true,
+			String: *subscription.Description,
+		}
+	}
+	if subscription.Active != nil {
+		params.Active = pgtype.Bool{
+			Valid: true,
+			Bool:  *subscription.Active,
+		}
+	}
+
+	rslt, err := w.queries.UpdateWebhookSubscription(ctx, params)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "webhook subscription not found")
+		}
+
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error updating webhook subscription: %s", err.Error())
+	}
+
+	return webhookSubscriptionFromGenerated(rslt), nil
+}
+
+func (w *WebhookRepository) DeleteWebhookSubscription(ctx context.Context, id uint32) error {
+	deleted, err := w.queries.DeleteWebhookSubscription(ctx, int64(id))
+	if err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "error deleting webhook subscription: %s", err.Error())
+	}
+	if deleted == 0 {
+		return pkg.Errorf(pkg.NOT_FOUND_ERROR, "webhook subscription not found")
+	}
+
+	return nil
+}
+
+func (w *WebhookRepository) ListWebhookDeliveries(
+	ctx context.Context,
+	subscriptionID uint32,
+	pgData *pkg.PaginationMetadata,
+) ([]*repository.WebhookDelivery, pkg.PaginationMetadata, error) {
+	rslt, err := w.queries.ListWebhookDeliveries(ctx, generated.ListWebhookDeliveriesParams{
+		SubscriptionID: int64(subscriptionID),
+		Limit:          int32(pgData.PageSize),
+		Offset:         pkg.CalculateOffset(pgData.CurrentPage, pgData.PageSize),
+	})
+	if err != nil {
+		return nil, pkg.PaginationMetadata{}, pkg.Errorf(
+			pkg.INTERNAL_ERROR,
+			"error listing webhook deliveries: %s",
+			err.Error(),
+		)
+	}
+
+	deliveries := make([]*repository.WebhookDelivery, len(rslt))
+	for i, delivery := range rslt {
+		deliveries[i] = webhookDeliveryFromGenerated(delivery)
+	}
+
+	total, err := w.queries.CountWebhookDeliveries(ctx, int64(subscriptionID))
+	if err != nil {
+		return nil, pkg.PaginationMetadata{}, pkg.Errorf(
+			pkg.INTERNAL_ERROR,
+			"failed to count webhook deliveries: %s",
+			err.Error(),
+		)
+	}
+
+	return deliveries, pkg.CreatePaginationMetadata(
+		uint32(total),
+		pgData.PageSize,
+		pgData.CurrentPage,
+	), nil
+}
+
+func (w *WebhookRepository) GetWebhookDelivery(
+	ctx context.Context,
+	id uint32,
+) (*repository.WebhookDelivery, *repository.WebhookSubscription, error) {
+	rslt, err := w.queries.GetWebhookDelivery(ctx, int64(id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "webhook delivery not found")
+		}
+
+		return nil, nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error getting webhook delivery: %s", err.Error())
+	}
+
+	delivery := webhookDeliveryFromGenerated(generated.WebhookDelivery{
+		ID:             rslt.ID,
+		SubscriptionID: rslt.SubscriptionID,
+		EventID:        rslt.EventID,
+		EventType:      rslt.EventType,
+		Payload:        rslt.Payload,
+		Status:         rslt.Status,
+		Attempts:       rslt.Attempts,
+		ResponseStatus: rslt.ResponseStatus,
+		ResponseBody:   rslt.ResponseBody,
+		Error:          rslt.Error,
+		DurationMs:     rslt.DurationMs,
+		CreatedAt:      rslt.CreatedAt,
+		UpdatedAt:      rslt.UpdatedAt,
+		DeliveredAt:    rslt.DeliveredAt,
+	})
+
+	return delivery, &repository.WebhookSubscription{
+		ID:     uint32(rslt.SubscriptionID),
+		URL:    rslt.Url,
+		Secret: rslt.Secret,
+		Active: rslt.Active,
+	}, nil
+}
+
+func (w *WebhookRepository) RecordWebhookAttempt(
+	ctx context.Context,
+	attempt *repository.WebhookAttempt,
+) error {
+	if err := w.queries.RecordWebhookAttempt(ctx, generated.RecordWebhookAttemptParams{
+		ID:             int64(attempt.DeliveryID),
+		Status:         attempt.Status,
+		ResponseStatus: int32(attempt.ResponseStatus),
+		ResponseBody:   attempt.ResponseBody,
+		Error:          attempt.Error,
+		DurationMs:     int32(attempt.Duration.Milliseconds()),
+	}); err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "error recording webhook attempt: %s", err.Error())
+	}
+
+	return nil
+}
+
+func (w *WebhookRepository) RedeliverWebhook(ctx context.Context, id uint32) error {
+	if _, _, err := w.GetWebhookDelivery(ctx, id); err != nil {
+		return err
+	}
+
+	return w.db.ExecTx(ctx, func(q *generated.Queries) error {
+		if err := q.ResetWebhookDelivery(ctx, int64(id)); err != nil {
+			return pkg.Errorf(pkg.INTERNAL_ERROR, "error resetting webhook delivery: %s", err.Error())
+		}
+
+		return enqueueWebhookDelivery(ctx, q, id)
+	})
+}
+
+func enqueueWebhookDelivery(ctx context.Context, q *generated.Queries, id uint32) error {
+	return enqueueTask(
+		ctx,
+		q,
+		services.DeliverWebhookTask,
+		services.DeliverWebhookPayload{DeliveryID: id},
+		asynq.Queue(services.QueueWebhooks),
+		asynq.MaxRetry(services.WebhookMaxRetry),
+	)
+}
+
+func webhookSubscriptionFromGenerated(
+	subscription generated.WebhookSubscription,
+) *repository.WebhookSubscription {
+	return &repository.WebhookSubscription{
+		ID:          uint32(subscription.ID),
+		URL:         subscription.Url,
+		Events:      subscription.Events,
+		Secret:      subscription.Secret,
+		Description: subscription.Description,
+		Active:      subscription.Active,
+		CreatedAt:   subscription.CreatedAt,
+		UpdatedAt:   subscription.UpdatedAt,
+	}
+}
+
+func webhookDeliveryFromGenerated(delivery generated.WebhookDelivery) *repository.WebhookDelivery {
+	rslt := &repository.WebhookDelivery{
+		ID:             uint32(delivery.ID),
+		SubscriptionID: uint32(delivery.SubscriptionID),
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		Payload:        json.RawMessage(delivery.Payload),
+		Status:         delivery.Status,
+		Attempts:       uint32(delivery.Attempts),
+		ResponseStatus: int(delivery.ResponseStatus),
+		ResponseBody:   delivery.ResponseBody,
+		Error:          delivery.Error,
+		DurationMs:     uint32(delivery.DurationMs),
+		CreatedAt:      delivery.CreatedAt,
+		UpdatedAt:      delivery.UpdatedAt,
+	}
+	if delivery.DeliveredAt.Valid {
+		rslt.DeliveredAt = &delivery.DeliveredAt.Time
+	}
+
+	return rslt
+}
@@ -12,6 +12,7 @@ type Customer struct {
 	Name        string    `json:"name"`
 	PhoneNumber string    `json:"phone_number"`
 	Loaned      float64   `json:"loaned"`
+	Credit      float64   `json:"credit"`
 	Status      bool      `json:"status"`
 	CreatedAt   time.Time `json:"created_at"`
 }
@@ -39,6 +40,16 @@ type CustomerFullData struct {
 	} `json:"payment"`
 }
 
+type Refund struct {
+	ID          uint32    `json:"id"`
+	CustomerID  uint32    `json:"customer_id"`
+	Amount      float64   `json:"amount"`
+	Method      string    `json:"method"`
+	Reference   string    `json:"reference"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
 type SearchCustomer struct {
 	SearchValue *string `json:"search_value"`
 	Status      *bool   `json:"status"`
@@ -51,6 +62,8 @@ type CustomerRepository interface {
 		pgData *pkg.PaginationMetadata,
 	) ([]*Customer, pkg.PaginationMetadata, error)
 	GetCustomer(ctx context.Context, id uint32, phoneNumber string) (*Customer, error)
+	// GetCustomerByPhoneNumber matches on the last nine digits so any number format works.
+	GetCustomerByPhoneNumber(ctx context.Context, phoneNumber string) (*Customer, error)
 	GetCustomerFullData(
 		ctx context.Context,
 		id uint32,
@@ -61,4 +74,11 @@ type CustomerRepository interface {
 
 	GetCustomerList(ctx context.Context) ([]*Customer, error)
 	GetCustomerIDByName(ctx context.Context, name string) (uint32, error)
+
+	RefundCustomerCredit(ctx context.Context, refund *Refund) (*Refund, error)
+	ListCustomerRefunds(
+		ctx context.Context,
+		id uint32,
+		pgData *pkg.PaginationMetadata,
+	) ([]*Refund, pkg.PaginationMetadata, error)
 }
@@ -13,6 +13,7 @@ type Loan struct {
 	Description     string    `json:"description"`
 	Amount          float64   `json:"amount"`
 	CreatedAt       time.Time `json:"created_at"`
+	DueDate         string    `json:"due_date,omitempty"`
 	CustomerDetails *Customer `json:"customer_details,omitempty"`
 }
 
@@ -29,6 +30,7 @@ type LoanRepository interface {
 	) ([]*Loan, pkg.PaginationMetadata, error)
 	GetLoan(ctx context.Context, id uint32) (*Loan, error)
 	DeleteLoan(ctx context.Context, id uint32) error
+	UpdateLoanDueDate(ctx context.Context, id uint32, dueDate *time.Time) (*Loan, error)
 
 	// SearchLoan(ctx context.Context, search *string) ([]*Loan, error)
 }
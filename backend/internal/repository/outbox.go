@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// OutboxTask is a task saved in the same transaction as the rows it belongs to.
+// MaxRetry is -1 and ProcessAt zero when the task uses the queue defaults.
+type OutboxTask struct {
+	ID        uint32    `json:"id"`
+	TaskType  string    `json:"task_type"`
+	Payload   []byte    `json:"payload"`
+	Queue     string    `json:"queue"`
+	MaxRetry  int       `json:"max_retry"`
+	ProcessAt time.Time `json:"process_at"`
+	TaskID    string    `json:"task_id"`
+	Attempts  uint32    `json:"attempts"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type OutboxRepository interface {
+	// RelayOutboxTasks publishes up to limit unpublished tasks that are due, in
+	// order. A task is marked published only after publish succeeds so each one is
+	// published at least once, one that fails is backed off and given up on after
+	// a number of attempts while the rest of the batch goes on.
+	RelayOutboxTasks(
+		ctx context.Context,
+		limit int32,
+		publish func(context.Context, *OutboxTask) error,
+	) (int, error)
+	DeletePublishedOutboxTasks(ctx context.Context, before time.Time) (int64, error)
+}
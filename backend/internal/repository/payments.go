@@ -4,9 +4,7 @@ import (
 	"context"
 	"time"
 
-	"github.com/EmilioCliff/jonche/internal/services"
 	"github.com/EmilioCliff/jonche/pkg"
-	"github.com/hibiken/asynq"
 )
 
 type Payment struct {
@@ -14,10 +12,16 @@ type Payment struct {
 	TransactionNumber string    `json:"transaction_number"`
 	TransactionSource string    `json:"transaction_source"`
 	PayingName        string    `json:"paying_name"`
+	PayingPhone       string    `json:"paying_phone,omitempty"`
 	Amount            float64   `json:"amount"`
 	Assigned          bool      `json:"assigned"`
 	AssignedTo        uint32    `json:"assigned_to"`
 	PaidAt            time.Time `json:"paid_at"`
+	Reversed          bool      `json:"reversed"`
+	ReversalOf        uint32    `json:"reversal_of,omitempty"`
+	ReversalReason    string    `json:"reversal_reason,omitempty"`
+	Ignored           bool      `json:"ignored"`
+	IgnoredReason     string    `json:"ignored_reason,omitempty"`
 	CustomerDetails   Customer  `json:"customer_details,omitempty"`
 }
 
@@ -25,7 +29,6 @@ type PaymentRepository interface {
 	CreatePayment(
 		ctx context.Context,
 		payment *Payment,
-		afterCreate func(context.Context, services.SendSMSPayload, ...asynq.Option) error,
 	) (*Payment, error)
 	ListPayments(
 		ctx context.Context,
@@ -36,13 +39,29 @@ type PaymentRepository interface {
 		id uint32,
 		pgData *pkg.PaginationMetadata,
 	) ([]*Payment, pkg.PaginationMetadata, error)
+	ListUnassignedPayments(
+		ctx context.Context,
+		pgData *pkg.PaginationMetadata,
+	) ([]*Payment, pkg.PaginationMetadata, error)
 	GetPayment(ctx context.Context, id uint32) (*Payment, error)
+	ListPaymentsByTransactionNumbers(
+		ctx context.Context,
+		source string,
+		numbers []string,
+	) ([]*Payment, error)
+	ListSourcePayments(
+		ctx context.Context,
+		source string,
+		from time.Time,
+		to time.Time,
+	) ([]*Payment, error)
 	AssignPayment(
 		ctx context.Context,
 		paymentId uint32,
 		customerId uint32,
-		afterAssign func(context.Context, services.SendSMSPayload, ...asynq.Option) error,
 	) error
+	ReversePayment(ctx context.Context, paymentId uint32, reason string) (*Payment, error)
+	IgnorePayment(ctx context.Context, paymentId uint32, reason string) (*Payment, error)
 
 	// SearchPayment(ctx context.Context, search string) ([]*Payment, error)
 }
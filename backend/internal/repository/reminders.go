@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/EmilioCliff/jonche/pkg"
+)
+
+// DueInstalment is the unpaid part of a loan with a due date. Payments are
+// applied to a customer's loans oldest first.
+type DueInstalment struct {
+	LoanID      uint32    `json:"loan_id"`
+	CustomerID  uint32    `json:"customer_id"`
+	Name        string    `json:"name"`
+	PhoneNumber string    `json:"phone_number"`
+	Loaned      float64   `json:"loaned"`
+	Credit      float64   `json:"credit"`
+	DueDate     time.Time `json:"due_date"`
+	Outstanding float64   `json:"outstanding"`
+}
+
+type LoanReminder struct {
+	ID              uint32    `json:"id"`
+	LoanID          uint32    `json:"loan_id"`
+	CustomerID      uint32    `json:"customer_id"`
+	Stage           string    `json:"stage"`
+	SMSRefID        string    `json:"sms_ref_id"`
+	SentAt          time.Time `json:"sent_at"`
+	CustomerDetails *Customer `json:"customer_details,omitempty"`
+}
+
+type CreateLoanReminder struct {
+	LoanID      uint32
+	CustomerID  uint32
+	PhoneNumber string
+	Stage       string
+	// Template is the sms_templates name used for the stage.
+	Template string
+	Params   pkg.CustomerTemplateParams
+}
+
+type ReminderRepository interface {
+	ListDueInstalments(ctx context.Context, from, to time.Time) ([]*DueInstalment, error)
+	CountCustomerRemindersSince(ctx context.Context, customerID uint32, since time.Time) (int64, error)
+	// CreateLoanReminder records and sends a reminder. It returns false when the
+	// loan already had a reminder for the stage or the customer opted out of
+	// transactional messages.
+	CreateLoanReminder(
+		ctx context.Context,
+		reminder *CreateLoanReminder,
+	) (bool, error)
+	ListLoanReminders(
+		ctx context.Context,
+		pgData *pkg.PaginationMetadata,
+	) ([]*LoanReminder, pkg.PaginationMetadata, error)
+}
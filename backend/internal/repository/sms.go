@@ -7,7 +7,6 @@ import (
 	"github.com/EmilioCliff/jonche/internal/services"
 	"github.com/EmilioCliff/jonche/pkg"
 	"github.com/google/uuid"
-	"github.com/hibiken/asynq"
 )
 
 type SMS struct {
@@ -15,17 +14,97 @@ type SMS struct {
 	CustomerID      uint32    `json:"customer_id"`
 	Message         string    `json:"message"`
 	Type            string    `json:"type"                       binding:"oneof=automated manual"`
-	Status          string    `json:"status"                     binding:"oneof=delivered undelivered"`
+	Status          string    `json:"status"                     binding:"oneof=pending delivered failed expired rejected"`
 	CreatedAt       time.Time `json:"created_at"`
+	Cost            float64   `json:"cost"`
+	Provider        string    `json:"provider,omitempty"`
+	ResendCount     uint32    `json:"resend_count"`
+	Direction       string    `json:"direction,omitempty"`
 	CustomerDetails *Customer `json:"customer_details,omitempty"`
 }
 
+// Commands customers can text in. Keywords are matched on the first word of the
+// message.
+const (
+	SMSKeywordStop    = "STOP"
+	SMSKeywordStart   = "START"
+	SMSKeywordBalance = "BAL"
+)
+
+// InboundSMS is a message a customer sent us. CustomerID is 0 when the number
+// does not belong to a customer.
+type InboundSMS struct {
+	ID                uint32    `json:"id"`
+	CustomerID        uint32    `json:"customer_id"`
+	PhoneNumber       string    `json:"phone_number"`
+	Message           string    `json:"message"`
+	Keyword           string    `json:"keyword,omitempty"`
+	ProviderMessageID string    `json:"provider_message_id,omitempty"`
+	ReceivedAt        time.Time `json:"received_at"`
+	CustomerDetails   *Customer `json:"customer_details,omitempty"`
+}
+
+// SMSRecipient is a message filled in for one customer, as it would be sent.
+type SMSRecipient struct {
+	CustomerID  uint32           `json:"customer_id"`
+	PhoneNumber string           `json:"phone_number,omitempty"`
+	Message     string           `json:"message,omitempty"`
+	OptedOut    bool             `json:"opted_out"`
+	Estimate    *pkg.SMSEstimate `json:"estimate,omitempty"`
+}
+
 type UpdateSMS struct {
-	RefID          uuid.UUID `json:"ref_id"`
-	Cost           *string   `json:"cost"`
-	Description    *string   `json:"description"`
-	DeliveryStatus *string   `json:"delivery_status"`
-	CallbackStatus *string   `json:"callback_status"`
+	RefID             uuid.UUID `json:"ref_id"`
+	Cost              *float64  `json:"cost"`
+	Description       *string   `json:"description"`
+	DeliveryStatus    *string   `json:"delivery_status"`
+	CallbackStatus    *string   `json:"callback_status"`
+	Provider          *string   `json:"provider"`
+	ProviderMessageID *string   `json:"provider_message_id"`
+}
+
+// SMSStatusHistory is one status a message went through, from a delivery report
+// or a resend. SMSID is 0 for a report that did not match a message yet.
+type SMSStatusHistory struct {
+	ID                uint32    `json:"id"`
+	SMSID             uint32    `json:"sms_id"`
+	RefID             string    `json:"ref_id"`
+	Provider          string    `json:"provider"`
+	ProviderMessageID string    `json:"provider_message_id"`
+	Status            string    `json:"status"`
+	ProviderStatus    string    `json:"provider_status"`
+	Description       string    `json:"description"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// SMSResendPolicy decides when a failed or expired message is sent again.
+// Rejected messages, like those to invalid numbers, are never resent.
+type SMSResendPolicy struct {
+	MaxResends uint32
+	Delay      time.Duration
+}
+
+// Statuses of an SMSSubmission.
+const (
+	SMSSubmissionSubmitting = "submitting"
+	SMSSubmissionAccepted   = "accepted"
+	SMSSubmissionFailed     = "failed"
+)
+
+// SMSSubmission is one hand over of a message to the gateway, recorded before and
+// after the request. Resend is 0 for the first send.
+type SMSSubmission struct {
+	RefID             string    `json:"ref_id"`
+	Resend            uint32    `json:"resend"`
+	Status            string    `json:"status"`
+	Provider          string    `json:"provider"`
+	ProviderMessageID string    `json:"provider_message_id"`
+	Cost              float64   `json:"cost"`
+	Description       string    `json:"description"`
+	Attempts          uint32    `json:"attempts"`
+	Error             string    `json:"error"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
 }
 
 type SearchSMS struct {
@@ -35,25 +114,53 @@ type SearchSMS struct {
 }
 
 type SMSRepository interface {
-	// create bulk sms
+	// create bulk sms, customers opted out of the category are skipped and returned
 	CreateSMS(
 		ctx context.Context,
 		sms *SMS,
 		ids []uint32,
-		afterCreate func(context.Context, services.SendSMSPayload, ...asynq.Option) error,
-	) error
+		category string,
+	) ([]uint32, error)
+	// RenderSMS fills the message in for each customer without saving or sending it.
+	RenderSMS(ctx context.Context, message string, ids []uint32, category string) ([]*SMSRecipient, error)
 	ListSMS(
 		ctx context.Context,
 		pgData *pkg.PaginationMetadata,
 	) ([]*SMS, pkg.PaginationMetadata, error)
+	// CreateInboundSMS stores a reply and answers BAL with the customer's balance.
+	// A message the provider already delivered returns an ALREADY_EXISTS error.
+	CreateInboundSMS(
+		ctx context.Context,
+		sms *InboundSMS,
+	) (*InboundSMS, error)
+	ListInboundSMS(
+		ctx context.Context,
+		pgData *pkg.PaginationMetadata,
+	) ([]*InboundSMS, pkg.PaginationMetadata, error)
 	ListCustomerSMS(
 		ctx context.Context,
 		id uint32,
 		pgData *pkg.PaginationMetadata,
 	) ([]*SMS, pkg.PaginationMetadata, error)
 	GetSMS(ctx context.Context, id uint32) (*SMS, error)
+	// ListSMSIDsByRefIDs maps the ref ids carried by send tasks to their sms rows.
+	ListSMSIDsByRefIDs(ctx context.Context, refIDs []string) (map[string]uint32, error)
 	DeliverSMS(ctx context.Context, id uint32) error
 	UpdateSMS(ctx context.Context, sms *UpdateSMS) error
+	// RecordDeliveryReport moves the message to the reported status and keeps it in
+	// its history. Reports for unknown messages are kept until the message is
+	// recorded. Failed messages are resent under the policy.
+	RecordDeliveryReport(
+		ctx context.Context,
+		report *services.DeliveryReport,
+		policy SMSResendPolicy,
+	) (*SMSStatusHistory, error)
+	ListSMSStatusHistory(ctx context.Context, id uint32) ([]*SMSStatusHistory, error)
+	// BeginSMSSubmission records an attempt to send a message. A submission the
+	// gateway already accepted comes back accepted and must not be sent again.
+	BeginSMSSubmission(ctx context.Context, refID string, resend uint32) (*SMSSubmission, error)
+	AcceptSMSSubmission(ctx context.Context, submission *SMSSubmission) error
+	FailSMSSubmission(ctx context.Context, refID string, resend uint32, reason string) error
 
 	// SearchSMS(ctx context.Context, searchParams *SearchSMS) ([]*SMS, error)
 }
@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// SMSProviderBalance is the credit a gateway reported after its last send.
+type SMSProviderBalance struct {
+	Provider  string    `json:"provider"`
+	Balance   float64   `json:"balance"`
+	Alerted   bool      `json:"alerted"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type SMSBalanceRepository interface {
+	UpdateSMSProviderBalance(
+		ctx context.Context,
+		provider string,
+		balance float64,
+	) (*SMSProviderBalance, error)
+	// SetSMSProviderBalanceAlerted records that admins were told about a low
+	// balance so they are alerted once, not after every send.
+	SetSMSProviderBalanceAlerted(ctx context.Context, provider string, alerted bool) error
+	ListSMSProviderBalances(ctx context.Context) ([]*SMSProviderBalance, error)
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/EmilioCliff/jonche/pkg"
+)
+
+const (
+	CampaignScheduled = "scheduled"
+	CampaignPaused    = "paused"
+	CampaignCancelled = "cancelled"
+	CampaignCompleted = "completed"
+)
+
+type SMSCampaign struct {
+	ID         uint32     `json:"id"`
+	Name       string     `json:"name"`
+	Message    string     `json:"message,omitempty"`
+	TemplateID uint32     `json:"template_id,omitempty"`
+	MinBalance *float64   `json:"min_balance"`
+	SendAt     *time.Time `json:"send_at"`
+	CronSpec   string     `json:"cron_spec,omitempty"`
+	Status     string     `json:"status"`
+	LastRunAt  *time.Time `json:"last_run_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type SMSCampaignRepository interface {
+	CreateSMSCampaign(ctx context.Context, campaign *SMSCampaign) (*SMSCampaign, error)
+	ListSMSCampaigns(
+		ctx context.Context,
+		pgData *pkg.PaginationMetadata,
+	) ([]*SMSCampaign, pkg.PaginationMetadata, error)
+	ListRecurringSMSCampaigns(ctx context.Context) ([]*SMSCampaign, error)
+	GetSMSCampaign(ctx context.Context, id uint32) (*SMSCampaign, error)
+	UpdateSMSCampaignStatus(ctx context.Context, id uint32, status string) (*SMSCampaign, error)
+	DispatchSMSCampaign(ctx context.Context, id uint32, sms *SMS, ids []uint32) ([]uint32, error)
+	ListCampaignRecipients(ctx context.Context, minBalance *float64) ([]uint32, error)
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/EmilioCliff/jonche/pkg"
+)
+
+// Message categories a customer can opt out of. Payment receipts are legally
+// required and are sent regardless.
+const (
+	SMSCategoryTransactional = "transactional"
+	SMSCategoryMarketing     = "marketing"
+)
+
+const (
+	ConsentSourceKeyword = "keyword"
+	ConsentSourceManual  = "manual"
+)
+
+var SMSCategories = []string{SMSCategoryTransactional, SMSCategoryMarketing}
+
+type SMSConsent struct {
+	CustomerID      uint32    `json:"customer_id"`
+	Category        string    `json:"category"`
+	OptedOut        bool      `json:"opted_out"`
+	Source          string    `json:"source"`
+	Reason          string    `json:"reason"`
+	UpdatedAt       time.Time `json:"updated_at"`
+	CustomerDetails *Customer `json:"customer_details,omitempty"`
+}
+
+type SMSConsentRepository interface {
+	// SetSMSConsents stores the consents together, either all of them are saved or
+	// none is.
+	SetSMSConsents(ctx context.Context, consents []*SMSConsent) ([]*SMSConsent, error)
+	// ListCustomerSMSConsents returns every category, customers are opted in until
+	// they opt out.
+	ListCustomerSMSConsents(ctx context.Context, customerID uint32) ([]*SMSConsent, error)
+	ListOptedOutCustomers(
+		ctx context.Context,
+		pgData *pkg.PaginationMetadata,
+	) ([]*SMSConsent, pkg.PaginationMetadata, error)
+}
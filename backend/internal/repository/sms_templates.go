@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+type SMSTemplate struct {
+	ID        uint32    `json:"id"`
+	Name      string    `json:"name"`
+	Body      string    `json:"body"`
+	System    bool      `json:"system"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type UpdateSMSTemplate struct {
+	ID   uint32  `json:"id"`
+	Name *string `json:"name"`
+	Body *string `json:"body"`
+}
+
+type SMSTemplateRepository interface {
+	CreateSMSTemplate(ctx context.Context, template *SMSTemplate) (*SMSTemplate, error)
+	ListSMSTemplates(ctx context.Context) ([]*SMSTemplate, error)
+	GetSMSTemplate(ctx context.Context, id uint32) (*SMSTemplate, error)
+	GetSMSTemplateByName(ctx context.Context, name string) (*SMSTemplate, error)
+	UpdateSMSTemplate(ctx context.Context, template *UpdateSMSTemplate) (*SMSTemplate, error)
+	DeleteSMSTemplate(ctx context.Context, id uint32) error
+}
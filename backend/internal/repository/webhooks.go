@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/EmilioCliff/jonche/pkg"
+)
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// WebhookSubscription is a url told about the events it lists. Secret signs the
+// requests, it is only shown when the subscription is created.
+type WebhookSubscription struct {
+	ID          uint32    `json:"id"`
+	URL         string    `json:"url"`
+	Events      []string  `json:"events"`
+	Secret      string    `json:"secret,omitempty"`
+	Description string    `json:"description"`
+	Active      bool      `json:"active"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type UpdateWebhookSubscription struct {
+	ID          uint32   `json:"id"`
+	URL         *string  `json:"url"`
+	Events      []string `json:"events"`
+	Secret      *string  `json:"secret"`
+	Description *string  `json:"description"`
+	Active      *bool    `json:"active"`
+}
+
+// WebhookDelivery is one event sent to one subscription, the response fields are
+// from the latest attempt.
+type WebhookDelivery struct {
+	ID             uint32          `json:"id"`
+	SubscriptionID uint32          `json:"subscription_id"`
+	EventID        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       uint32          `json:"attempts"`
+	ResponseStatus int             `json:"response_status"`
+	ResponseBody   string          `json:"response_body"`
+	Error          string          `json:"error"`
+	DurationMs     uint32          `json:"duration_ms"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+}
+
+// WebhookAttempt is the outcome of posting a delivery once.
+type WebhookAttempt struct {
+	DeliveryID     uint32
+	Status         string
+	ResponseStatus int
+	ResponseBody   string
+	Error          string
+	Duration       time.Duration
+}
+
+type WebhookRepository interface {
+	CreateWebhookSubscription(
+		ctx context.Context,
+		subscription *WebhookSubscription,
+	) (*WebhookSubscription, error)
+	ListWebhookSubscriptions(ctx context.Context) ([]*WebhookSubscription, error)
+	GetWebhookSubscription(ctx context.Context, id uint32) (*WebhookSubscription, error)
+	UpdateWebhookSubscription(
+		ctx context.Context,
+		subscription *UpdateWebhookSubscription,
+	) (*WebhookSubscription, error)
+	DeleteWebhookSubscription(ctx context.Context, id uint32) error
+
+	ListWebhookDeliveries(
+		ctx context.Context,
+		subscriptionID uint32,
+		pgData *pkg.PaginationMetadata,
+	) ([]*WebhookDelivery, pkg.PaginationMetadata, error)
+	// GetWebhookDelivery returns the delivery and the subscription it goes to.
+	GetWebhookDelivery(ctx context.Context, id uint32) (*WebhookDelivery, *WebhookSubscription, error)
+	RecordWebhookAttempt(ctx context.Context, attempt *WebhookAttempt) error
+	// RedeliverWebhook queues the delivery to be sent again, whatever its status.
+	RedeliverWebhook(ctx context.Context, id uint32) error
+}
@@ -0,0 +1,76 @@
+package services
+
+import "time"
+
+const (
+	EventPaymentReceived  = "payment.received"
+	EventPaymentAssigned  = "payment.assigned"
+	EventLoanCreated      = "loan.created"
+	EventSMSStatusChanged = "sms.status_changed"
+	EventSMSDelivered     = "sms.delivered"
+	EventCustomerUpdated  = "customer.updated"
+
+	// PublishEventTask pushes an Event to the dashboards connected to every instance.
+	PublishEventTask = "task:publish_event"
+)
+
+// Event is posted to webhook subscribers and streamed to the dashboard. ID is the
+// same for every subscription and every retry so receivers can drop duplicates.
+type Event struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// LiveEvent is an Event on its way to the dashboard, Payload is the encoded Event.
+type LiveEvent struct {
+	ID      string
+	Type    string
+	Payload []byte
+}
+
+// PaymentEvent is the data of payment.received and payment.assigned, Customer
+// is set once the payment is assigned.
+type PaymentEvent struct {
+	ID                uint32         `json:"id"`
+	TransactionNumber string         `json:"transaction_number"`
+	TransactionSource string         `json:"transaction_source"`
+	PayingName        string         `json:"paying_name"`
+	PayingPhone       string         `json:"paying_phone"`
+	Amount            float64        `json:"amount"`
+	PaidAt            time.Time      `json:"paid_at"`
+	Assigned          bool           `json:"assigned"`
+	Customer          *CustomerEvent `json:"customer,omitempty"`
+}
+
+// CustomerEvent is the data of customer.updated, and the customer in other events.
+type CustomerEvent struct {
+	ID          uint32  `json:"id"`
+	Name        string  `json:"name"`
+	PhoneNumber string  `json:"phone_number"`
+	Status      bool    `json:"status"`
+	Loaned      float64 `json:"loaned"`
+	Credit      float64 `json:"credit"`
+}
+
+// LoanEvent is the data of loan.created.
+type LoanEvent struct {
+	ID          uint32    `json:"id"`
+	CustomerID  uint32    `json:"customer_id"`
+	Description string    `json:"description"`
+	Amount      float64   `json:"amount"`
+	DueDate     string    `json:"due_date,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// SMSEvent is the data of sms.status_changed and sms.delivered.
+type SMSEvent struct {
+	ID                uint32 `json:"id"`
+	RefID             string `json:"ref_id"`
+	CustomerID        uint32 `json:"customer_id"`
+	PhoneNumber       string `json:"phone_number"`
+	Provider          string `json:"provider"`
+	ProviderMessageID string `json:"provider_message_id"`
+	Status            string `json:"status"`
+}
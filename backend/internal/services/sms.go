@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"errors"
+)
+
+const (
+	SMSProviderTiara          = "tiara"
+	SMSProviderAfricasTalking = "africastalking"
+	SMSProviderConsole        = "console"
+)
+
+// Delivery statuses of an outgoing message. Gateways report many more, they are
+// mapped onto these and kept as they were in ProviderStatus.
+const (
+	SMSStatusPending   = "pending"
+	SMSStatusDelivered = "delivered"
+	SMSStatusFailed    = "failed"
+	SMSStatusExpired   = "expired"
+	SMSStatusRejected  = "rejected"
+)
+
+type SMSMessage struct {
+	PhoneNumber string `json:"phone_number"`
+	Message     string `json:"message"`
+	RefID       string `json:"ref_id"`
+}
+
+type SMSResult struct {
+	Provider    string `json:"provider"`
+	MessageID   string `json:"message_id"`
+	Status      string `json:"status"`
+	Description string `json:"description"`
+	Cost        string `json:"cost"`
+	// Balance is the credit left on the account, empty when the gateway does not
+	// report it
+	Balance string `json:"balance"`
+}
+
+// DeliveryReport is a gateway telling us what happened to a message. Tiara reports
+// on our RefID, Africa's Talking only on its own ProviderMessageID.
+type DeliveryReport struct {
+	Provider          string `json:"provider"`
+	RefID             string `json:"ref_id"`
+	ProviderMessageID string `json:"provider_message_id"`
+	Status            string `json:"status"`
+	ProviderStatus    string `json:"provider_status"`
+	FailureReason     string `json:"failure_reason"`
+}
+
+// ErrSMSThrottled is wrapped by the error an SMSThrottle returns.
+var ErrSMSThrottled = errors.New("sms provider throttled")
+
+// SMSThrottle holds a message back while the named provider is sending as fast
+// as it allows, returning an error that wraps ErrSMSThrottled.
+type SMSThrottle func(ctx context.Context, provider string) error
+
+// SMSProvider sends a single message through an sms gateway. An error means the
+// message was not accepted by the gateway.
+type SMSProvider interface {
+	Name() string
+	SendSMS(ctx context.Context, msg SMSMessage) (*SMSResult, error)
+}
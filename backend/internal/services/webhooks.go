@@ -0,0 +1,30 @@
+package services
+
+import "slices"
+
+const (
+	// DeliverWebhookTask posts one webhook delivery to its subscription's url.
+	DeliverWebhookTask = "task:deliver_webhook"
+
+	// WebhookMaxRetry is how many times a delivery is retried, with the default
+	// backoff it keeps trying for about six hours.
+	WebhookMaxRetry = 12
+)
+
+// WebhookEvents are the event types a subscription can receive.
+var WebhookEvents = []string{
+	EventPaymentReceived,
+	EventPaymentAssigned,
+	EventLoanCreated,
+	EventSMSDelivered,
+	EventCustomerUpdated,
+}
+
+func IsWebhookEvent(event string) bool {
+	return slices.Contains(WebhookEvents, event)
+}
+
+// DeliverWebhookPayload names the webhook_deliveries row to send.
+type DeliverWebhookPayload struct {
+	DeliveryID uint32 `json:"delivery_id"`
+}
@@ -2,7 +2,11 @@ package services
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"time"
 
+	"github.com/EmilioCliff/jonche/pkg"
 	"github.com/hibiken/asynq"
 )
 
@@ -10,10 +14,35 @@ const (
 	QueueCritical = "critical"
 	QueueDefault  = "default"
 	QueueLow      = "low"
+	// QueueWebhooks carries the webhook deliveries, it is never held
+	QueueWebhooks = "webhooks"
+	// QueueSMS carries the marketing and bulk messages, it is held while the
+	// gateway is out of credit
+	QueueSMS = "sms"
 
 	From = "CONNECT"
 
-	PaymentSMS = "Hello {{.Name}}, we have received your payment of KES {{.Paid}} on {{.PaidDate}}. Your new balance is KES {{.Loaned}}. Thank you!"
+	// SendSMSTask is the task type that sends a single SendSMSPayload.
+	SendSMSTask = "task:send_sms"
+
+	// PaymentSMSTemplate names the editable copy of PaymentSMS in sms_templates.
+	PaymentSMSTemplate = "payment_confirmation"
+
+	// reminder templates in sms_templates, one per stage
+	ReminderBeforeTemplate  = "reminder_before"
+	ReminderDueTemplate     = "reminder_due"
+	ReminderOverdueTemplate = "reminder_overdue"
+
+	ReminderBeforeSMS  = "Hello {{.Name}}, this is a reminder that KES {{.DueAmount}} is due on {{.DueDate}}. Your balance is KES {{.Loaned}}. Thank you!"
+	ReminderDueSMS     = "Hello {{.Name}}, KES {{.DueAmount}} is due today, {{.DueDate}}. Your balance is KES {{.Loaned}}. Thank you!"
+	ReminderOverdueSMS = "Hello {{.Name}}, your payment of KES {{.DueAmount}} due on {{.DueDate}} is {{.DaysOverdue}} days overdue. Please pay to clear your balance of KES {{.Loaned}}."
+
+	// BalanceReplyTemplate answers customers who text BAL.
+	BalanceReplyTemplate = "balance_reply"
+
+	BalanceReplySMS = "Hello {{.Name}}, your balance is KES {{.Loaned}}.{{if .Credit}} You have a credit of KES {{.Credit}}.{{end}} Thank you!"
+
+	PaymentSMS = "Hello {{.Name}}, we have received your payment of KES {{.Paid}} on {{.PaidDate}}. Your new balance is KES {{.Loaned}}.{{if .Credit}} You have a credit of KES {{.Credit}} which will be applied to your next loan.{{end}} Thank you!"
 )
 
 type RedisConfig struct {
@@ -26,12 +55,91 @@ type WorkerService interface {
 	// processes
 	StartProcessor() error
 	StopProcessor()
+	// ReleaseHeldQueues resumes the queues held while the sms gateway had no credit.
+	ReleaseHeldQueues()
+
+	// SubscribeEvents streams the events published on any instance until the
+	// returned func is called. A reader that falls behind misses events.
+	SubscribeEvents() (<-chan *LiveEvent, func())
+
+	// jobs are the tasks the worker runs on a schedule
+	ListJobs() []*Job
+	// TriggerJob runs a job now and returns the id of its task.
+	TriggerJob(ctx context.Context, name string) (string, error)
+
+	// dead tasks are the ones archived after their retries ran out
+	ListQueues() ([]*QueueInfo, error)
+	ListDeadTasks(queue string, pgData *pkg.PaginationMetadata) ([]*DeadTask, pkg.PaginationMetadata, error)
+	RetryDeadTask(queue, id string) error
+	DeleteDeadTask(queue, id string) error
+	// ReplayDeadTasks retries the tasks in ids, or every dead task in the queue when
+	// ids is empty, and returns how many were queued again.
+	ReplayDeadTasks(queue string, ids []string) (int, error)
 
 	DistributeTaskSendSMS(ctx context.Context, payload SendSMSPayload, opt ...asynq.Option) error
+	DistributeTaskDispatchCampaign(
+		ctx context.Context,
+		payload DispatchCampaignPayload,
+		opt ...asynq.Option,
+	) error
 }
 
+// SendSMSPayload is a single message. Each recipient gets its own task so a retry
+// only resends that recipient's message. Resend is 0 for the first send.
 type SendSMSPayload struct {
-	PhoneNumbers []string `json:"phone_numbers"`
-	Messages     []string `json:"messages"`
-	RefIds       []string `json:"ref_ids"`
+	PhoneNumber string `json:"phone_number"`
+	Message     string `json:"message"`
+	RefID       string `json:"ref_id"`
+	Resend      uint32 `json:"resend,omitempty"`
+}
+
+// SendSMSTaskID is the task id a message is enqueued under, so each send of a
+// message is queued once.
+func SendSMSTaskID(refID string, resend uint32) string {
+	return fmt.Sprintf("sms:%s:%d", refID, resend)
+}
+
+// Job is a task the worker runs on a schedule. NextRun is empty for jobs that
+// only run when triggered.
+type Job struct {
+	Name        string     `json:"name"`
+	TaskType    string     `json:"task_type"`
+	Cronspec    string     `json:"cronspec"`
+	Description string     `json:"description"`
+	NextRun     *time.Time `json:"next_run,omitempty"`
+}
+
+// QueueInfo is how many tasks a queue holds in each state. Processed and Failed
+// count today's tasks.
+type QueueInfo struct {
+	Queue     string `json:"queue"`
+	Paused    bool   `json:"paused"`
+	Size      int    `json:"size"`
+	Pending   int    `json:"pending"`
+	Active    int    `json:"active"`
+	Scheduled int    `json:"scheduled"`
+	Retry     int    `json:"retry"`
+	Archived  int    `json:"archived"`
+	Completed int    `json:"completed"`
+	Processed int    `json:"processed"`
+	Failed    int    `json:"failed"`
+}
+
+// DeadTask is a task archived after its retries ran out. SMS is its decoded
+// payload when it is a SendSMSTask.
+type DeadTask struct {
+	ID           string          `json:"id"`
+	Queue        string          `json:"queue"`
+	Type         string          `json:"type"`
+	Payload      json.RawMessage `json:"payload"`
+	SMS          *SendSMSPayload `json:"sms,omitempty"`
+	LastError    string          `json:"last_error"`
+	LastFailedAt time.Time       `json:"last_failed_at"`
+	Retried      int             `json:"retried"`
+	MaxRetry     int             `json:"max_retry"`
+}
+
+// DispatchCampaignPayload fans a campaign out to its recipients when it is due.
+type DispatchCampaignPayload struct {
+	CampaignID uint32 `json:"campaign_id"`
 }
@@ -0,0 +1,143 @@
+package sms
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/EmilioCliff/jonche/internal/services"
+	"github.com/EmilioCliff/jonche/pkg"
+)
+
+var _ services.SMSProvider = (*AfricasTalkingProvider)(nil)
+
+type africasTalkingResponse struct {
+	SMSMessageData struct {
+		Message    string `json:"Message"`
+		Recipients []struct {
+			StatusCode int    `json:"statusCode"`
+			Number     string `json:"number"`
+			Status     string `json:"status"`
+			Cost       string `json:"cost"`
+			MessageID  string `json:"messageId"`
+		} `json:"Recipients"`
+	} `json:"SMSMessageData"`
+}
+
+type AfricasTalkingProvider struct {
+	endpoint string
+	username string
+	apiKey   string
+	from     string
+	client   *http.Client
+}
+
+func NewAfricasTalkingProvider(
+	endpoint, username, apiKey, from string,
+	client *http.Client,
+) *AfricasTalkingProvider {
+	return &AfricasTalkingProvider{
+		endpoint: endpoint,
+		username: username,
+		apiKey:   apiKey,
+		from:     from,
+		client:   client,
+	}
+}
+
+func (a *AfricasTalkingProvider) Name() string {
+	return services.SMSProviderAfricasTalking
+}
+
+func (a *AfricasTalkingProvider) SendSMS(
+	ctx context.Context,
+	msg services.SMSMessage,
+) (*services.SMSResult, error) {
+	form := url.Values{}
+	form.Set("username", a.username)
+	form.Set("to", "+"+internationalPhoneNumber(msg.PhoneNumber))
+	form.Set("message", msg.Message)
+	if a.from != "" {
+		form.Set("from", a.from)
+	}
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		a.endpoint,
+		strings.NewReader(form.Encode()),
+	)
+	if err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to create request: %s", err.Error())
+	}
+
+	req.Header.Set("apiKey", a.apiKey)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := doRequest(a.client, a.Name(), req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	responseBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error reading resp body: %s", err.Error())
+	}
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return nil, rejectionError(
+			resp.StatusCode,
+			"africa's talking rejected sms with status %d: %s",
+			resp.StatusCode,
+			strings.TrimSpace(string(responseBody)),
+		)
+	}
+
+	var atRsp africasTalkingResponse
+	if err := json.Unmarshal(responseBody, &atRsp); err != nil {
+		return nil, pkg.Errorf(
+			pkg.INTERNAL_ERROR,
+			"error unmarshaling resp body: %s",
+			err.Error(),
+		)
+	}
+
+	if len(atRsp.SMSMessageData.Recipients) == 0 {
+		return nil, pkg.Errorf(
+			pkg.INTERNAL_ERROR,
+			"africa's talking did not accept sms: %s",
+			atRsp.SMSMessageData.Message,
+		)
+	}
+
+	// 100 processed, 101 sent and 102 queued are the accepted recipient codes,
+	// 403 invalid number, 404 unsupported number type and 406 blacklisted will
+	// never go through
+	recipient := atRsp.SMSMessageData.Recipients[0]
+	if recipient.StatusCode < 100 || recipient.StatusCode > 102 {
+		code := pkg.INTERNAL_ERROR
+		switch recipient.StatusCode {
+		case 403, 404, 406:
+			code = pkg.INVALID_ERROR
+		}
+
+		return nil, pkg.Errorf(
+			code,
+			"africa's talking rejected sms: %s",
+			recipient.Status,
+		)
+	}
+
+	return &services.SMSResult{
+		Provider:    a.Name(),
+		MessageID:   recipient.MessageID,
+		Status:      recipient.Status,
+		Description: atRsp.SMSMessageData.Message,
+		Cost:        recipient.Cost,
+	}, nil
+}
@@ -0,0 +1,68 @@
+package sms
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/EmilioCliff/jonche/internal/services"
+	"github.com/EmilioCliff/jonche/pkg"
+)
+
+var _ services.SMSProvider = (*ConsoleProvider)(nil)
+
+// ConsoleProvider is for development. It appends every message to a file, or to
+// the log when no file is set, instead of sending it.
+type ConsoleProvider struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewConsoleProvider(path string) *ConsoleProvider {
+	return &ConsoleProvider{path: path}
+}
+
+func (c *ConsoleProvider) Name() string {
+	return services.SMSProviderConsole
+}
+
+func (c *ConsoleProvider) SendSMS(
+	_ context.Context,
+	msg services.SMSMessage,
+) (*services.SMSResult, error) {
+	line := fmt.Sprintf(
+		"%s to=%s ref=%s message=%q\n",
+		time.Now().Format(time.RFC3339),
+		msg.PhoneNumber,
+		msg.RefID,
+		msg.Message,
+	)
+
+	if c.path == "" {
+		log.Print("sms: " + line)
+	} else {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		f, err := os.OpenFile(c.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to open sms file: %s", err.Error())
+		}
+		defer f.Close()
+
+		if _, err := f.WriteString(line); err != nil {
+			return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to write sms: %s", err.Error())
+		}
+	}
+
+	return &services.SMSResult{
+		Provider:    c.Name(),
+		MessageID:   msg.RefID,
+		Status:      "Success",
+		Description: "written to console",
+		Cost:        "0",
+	}, nil
+}
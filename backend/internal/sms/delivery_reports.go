@@ -0,0 +1,105 @@
+package sms
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/EmilioCliff/jonche/internal/services"
+	"github.com/EmilioCliff/jonche/pkg"
+)
+
+// tiaraDeliveryReport is posted as json to the callback url set on the Tiara
+// account.
+type tiaraDeliveryReport struct {
+	RefId      string `json:"refId"`
+	MsgId      string `json:"msgId"`
+	Status     string `json:"status"`
+	StatusCode string `json:"statusCode"`
+	Desc       string `json:"desc"`
+	To         string `json:"to"`
+}
+
+// ParseDeliveryReport reads the delivery report a gateway posted to its callback.
+func ParseDeliveryReport(provider string, r *http.Request) (*services.DeliveryReport, error) {
+	switch strings.ToLower(provider) {
+	case services.SMSProviderTiara:
+		return parseTiaraDeliveryReport(r)
+	case services.SMSProviderAfricasTalking:
+		return parseAfricasTalkingDeliveryReport(r)
+	default:
+		return nil, pkg.Errorf(pkg.INVALID_ERROR, "unknown sms provider: %q", provider)
+	}
+}
+
+func parseTiaraDeliveryReport(r *http.Request) (*services.DeliveryReport, error) {
+	var report tiaraDeliveryReport
+	if err := json.NewDecoder(r.Body).Decode(&report); err != nil {
+		return nil, pkg.Errorf(pkg.INVALID_ERROR, "invalid tiara delivery report: %s", err.Error())
+	}
+	if report.Status == "" || (report.RefId == "" && report.MsgId == "") {
+		return nil, pkg.Errorf(pkg.INVALID_ERROR, "tiara delivery report needs a status and refId or msgId")
+	}
+
+	return &services.DeliveryReport{
+		Provider:          services.SMSProviderTiara,
+		RefID:             report.RefId,
+		ProviderMessageID: report.MsgId,
+		Status:            tiaraDeliveryStatus(report.Status),
+		ProviderStatus:    report.Status,
+		FailureReason:     report.Desc,
+	}, nil
+}
+
+// tiaraDeliveryStatus maps both the Tiara names and the SMPP stat codes the
+// operators pass through.
+func tiaraDeliveryStatus(status string) string {
+	switch strings.ToUpper(status) {
+	case "DELIVEREDTOTERMINAL", "DELIVRD", "DELIVERED":
+		return services.SMSStatusDelivered
+	case "EXPIRED":
+		return services.SMSStatusExpired
+	case "REJECTD", "REJECTED":
+		return services.SMSStatusRejected
+	case "DELIVERYIMPOSSIBLE", "UNDELIV", "UNDELIVERED", "FAILED", "DELETED":
+		return services.SMSStatusFailed
+	default:
+		// MessageWaiting, DeliveredToNetwork, DeliveryUncertain, ACCEPTD, ENROUTE, UNKNOWN
+		return services.SMSStatusPending
+	}
+}
+
+// parseAfricasTalkingDeliveryReport reads the form Africa's Talking posts, it
+// identifies the message by the id returned when it was sent.
+func parseAfricasTalkingDeliveryReport(r *http.Request) (*services.DeliveryReport, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, pkg.Errorf(pkg.INVALID_ERROR, "invalid africa's talking delivery report: %s", err.Error())
+	}
+
+	id, status := r.PostForm.Get("id"), r.PostForm.Get("status")
+	if id == "" || status == "" {
+		return nil, pkg.Errorf(pkg.INVALID_ERROR, "africa's talking delivery report needs an id and status")
+	}
+
+	return &services.DeliveryReport{
+		Provider:          services.SMSProviderAfricasTalking,
+		ProviderMessageID: id,
+		Status:            africasTalkingDeliveryStatus(status),
+		ProviderStatus:    status,
+		FailureReason:     r.PostForm.Get("failureReason"),
+	}, nil
+}
+
+func africasTalkingDeliveryStatus(status string) string {
+	switch status {
+	case "Success":
+		return services.SMSStatusDelivered
+	case "Failed":
+		return services.SMSStatusFailed
+	case "Rejected":
+		return services.SMSStatusRejected
+	default:
+		// Sent, Submitted and Buffered are still on their way
+		return services.SMSStatusPending
+	}
+}
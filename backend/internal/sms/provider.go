@@ -0,0 +1,249 @@
+package sms
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EmilioCliff/jonche/internal/metrics"
+	"github.com/EmilioCliff/jonche/internal/services"
+	"github.com/EmilioCliff/jonche/pkg"
+)
+
+var _ services.SMSProvider = (*FailoverProvider)(nil)
+
+// NewProvider builds the provider named by SMS_PROVIDER. When SMS_FAILOVER_PROVIDER
+// is set the result falls back to it whenever the primary fails.
+func NewProvider(config pkg.Config) (services.SMSProvider, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+
+	primary, err := newProvider(config.SMS_PROVIDER, config, client)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.SMS_FAILOVER_PROVIDER == "" || config.SMS_FAILOVER_PROVIDER == config.SMS_PROVIDER {
+		return primary, nil
+	}
+
+	secondary, err := newProvider(config.SMS_FAILOVER_PROVIDER, config, client)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewFailoverProvider(primary, secondary), nil
+}
+
+func newProvider(
+	name string,
+	config pkg.Config,
+	client *http.Client,
+) (services.SMSProvider, error) {
+	switch strings.ToLower(name) {
+	case services.SMSProviderTiara:
+		return NewTiaraProvider(
+			config.TIARA_ENDPOINT,
+			config.TIARA_API_KEY,
+			services.From,
+			client,
+		), nil
+	case services.SMSProviderAfricasTalking:
+		return NewAfricasTalkingProvider(
+			config.AT_ENDPOINT,
+			config.AT_USERNAME,
+			config.AT_API_KEY,
+			config.AT_SENDER_ID,
+			client,
+		), nil
+	case services.SMSProviderConsole:
+		return NewConsoleProvider(config.SMS_CONSOLE_FILE), nil
+	default:
+		return nil, pkg.Errorf(pkg.INVALID_ERROR, "unknown sms provider: %q", name)
+	}
+}
+
+// FailoverProvider sends through the primary provider and retries the same
+// message on the secondary when the primary did not take it. Any other error,
+// like a timeout after the request went out, may have sent the message so it
+// is retried on the primary.
+type FailoverProvider struct {
+	primary   services.SMSProvider
+	secondary services.SMSProvider
+}
+
+func NewFailoverProvider(primary, secondary services.SMSProvider) *FailoverProvider {
+	return &FailoverProvider{
+		primary:   primary,
+		secondary: secondary,
+	}
+}
+
+func (f *FailoverProvider) Name() string {
+	return f.primary.Name() + "," + f.secondary.Name()
+}
+
+func (f *FailoverProvider) SendSMS(
+	ctx context.Context,
+	msg services.SMSMessage,
+) (*services.SMSResult, error) {
+	rslt, err := f.primary.SendSMS(ctx, msg)
+	if err == nil {
+		return rslt, nil
+	}
+
+	// a busy primary has not failed, the message waits for it
+	if errors.Is(err, services.ErrSMSThrottled) {
+		return nil, err
+	}
+
+	var notSent *notSentError
+	if !errors.As(err, &notSent) {
+		return nil, err
+	}
+
+	log.Printf(
+		"sms provider %s failed for %s, failing over to %s: %s",
+		f.primary.Name(),
+		msg.RefID,
+		f.secondary.Name(),
+		pkg.ErrorMessage(err),
+	)
+
+	rslt, secondaryErr := f.secondary.SendSMS(ctx, msg)
+	if secondaryErr != nil {
+		if errors.Is(secondaryErr, services.ErrSMSThrottled) {
+			return nil, secondaryErr
+		}
+
+		// the primary never saw the message, the secondary's answer decides
+		// whether it is worth retrying
+		return nil, pkg.Errorf(
+			pkg.ErrorCode(secondaryErr),
+			"%s: %s; %s: %s",
+			f.primary.Name(),
+			pkg.ErrorMessage(err),
+			f.secondary.Name(),
+			pkg.ErrorMessage(secondaryErr),
+		)
+	}
+
+	return rslt, nil
+}
+
+// Throttle takes a token from throttle before each message a gateway sends, the
+// gateways behind a FailoverProvider are throttled on their own so the one that
+// sends is the one that is limited.
+func Throttle(provider services.SMSProvider, throttle services.SMSThrottle) services.SMSProvider {
+	if failover, ok := provider.(*FailoverProvider); ok {
+		return NewFailoverProvider(
+			Throttle(failover.primary, throttle),
+			Throttle(failover.secondary, throttle),
+		)
+	}
+
+	return &throttledProvider{
+		provider: provider,
+		throttle: throttle,
+	}
+}
+
+type throttledProvider struct {
+	provider services.SMSProvider
+	throttle services.SMSThrottle
+}
+
+func (t *throttledProvider) Name() string {
+	return t.provider.Name()
+}
+
+func (t *throttledProvider) SendSMS(
+	ctx context.Context,
+	msg services.SMSMessage,
+) (*services.SMSResult, error) {
+	if err := t.throttle(ctx, t.provider.Name()); err != nil {
+		return nil, err
+	}
+
+	return t.provider.SendSMS(ctx, msg)
+}
+
+// notSentError is a send the gateway never took, the only kind of failure the
+// same message can go to another gateway after.
+type notSentError struct {
+	err *pkg.Error
+}
+
+func (e *notSentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *notSentError) Unwrap() error {
+	return e.err
+}
+
+// doRequest sends a request to a gateway, recording how long it took to answer
+// and the status it answered with. A request that could not reach the gateway,
+// like a failed dns lookup or a refused connection, is a notSentError.
+func doRequest(client *http.Client, provider string, req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := client.Do(req)
+	metrics.SMSProviderLatency.WithLabelValues(provider).Observe(time.Since(start).Seconds())
+	if err != nil {
+		metrics.SMSProviderRequests.WithLabelValues(provider, "error").Inc()
+
+		rsp := pkg.Errorf(pkg.INTERNAL_ERROR, "failed sending request: %s", err.Error())
+
+		var dnsErr *net.DNSError
+		var opErr *net.OpError
+		if errors.As(err, &dnsErr) || (errors.As(err, &opErr) && opErr.Op == "dial") {
+			return nil, &notSentError{err: rsp}
+		}
+
+		return nil, rsp
+	}
+	metrics.SMSProviderRequests.WithLabelValues(provider, strconv.Itoa(resp.StatusCode)).Inc()
+
+	return resp, nil
+}
+
+// rejectionError classifies a gateway's error response. A bad request, like an
+// invalid number, fails the same way every time so it is an INVALID_ERROR. A
+// gateway that refused our credentials or was unavailable did not take the
+// message.
+func rejectionError(status int, format string, args ...any) error {
+	switch status {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return pkg.Errorf(pkg.INVALID_ERROR, format, args...)
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusServiceUnavailable:
+		return &notSentError{err: pkg.Errorf(pkg.INTERNAL_ERROR, format, args...)}
+	default:
+		return pkg.Errorf(pkg.INTERNAL_ERROR, format, args...)
+	}
+}
+
+var nonDigits = regexp.MustCompile(`\D`)
+
+// internationalPhoneNumber turns local kenyan numbers into the 254 form gateways expect.
+func internationalPhoneNumber(phoneNumber string) string {
+	phoneNumber = nonDigits.ReplaceAllString(phoneNumber, "")
+
+	if strings.HasPrefix(phoneNumber, "254") {
+		return phoneNumber
+	}
+
+	if strings.HasPrefix(phoneNumber, "0") {
+		return "254" + phoneNumber[1:]
+	}
+
+	if strings.HasPrefix(phoneNumber, "7") || strings.HasPrefix(phoneNumber, "1") {
+		return "254" + phoneNumber
+	}
+
+	return phoneNumber
+}
@@ -0,0 +1,142 @@
+package sms
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/EmilioCliff/jonche/internal/services"
+)
+
+func newTestGateway(t *testing.T, handler http.HandlerFunc) (string, *atomic.Int32) {
+	t.Helper()
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	return server.URL, &requests
+}
+
+func acceptSMS(w http.ResponseWriter, _ *http.Request) {
+	w.Write([]byte(`{"status":"SUCCESS","msgId":"1"}`))
+}
+
+// closedEndpoint is an address nothing listens on, connecting to it is refused.
+func closedEndpoint(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	return "http://" + addr
+}
+
+func TestFailoverProviderFailsOverOnlyWhenNothingWasSent(t *testing.T) {
+	client := &http.Client{Timeout: 200 * time.Millisecond}
+
+	tests := []struct {
+		name     string
+		primary  func(t *testing.T) string
+		failover bool
+	}{
+		{
+			name:     "connection refused",
+			primary:  closedEndpoint,
+			failover: true,
+		},
+		{
+			name: "unavailable",
+			primary: func(t *testing.T) string {
+				url, _ := newTestGateway(t, func(w http.ResponseWriter, _ *http.Request) {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					w.Write([]byte("<html>down</html>"))
+				})
+
+				return url
+			},
+			failover: true,
+		},
+		{
+			name: "unauthorized",
+			primary: func(t *testing.T) string {
+				url, _ := newTestGateway(t, func(w http.ResponseWriter, _ *http.Request) {
+					w.WriteHeader(http.StatusUnauthorized)
+					w.Write([]byte(`{"status":"FAILED","desc":"bad key"}`))
+				})
+
+				return url
+			},
+			failover: true,
+		},
+		{
+			name: "server error",
+			primary: func(t *testing.T) string {
+				url, _ := newTestGateway(t, func(w http.ResponseWriter, _ *http.Request) {
+					w.WriteHeader(http.StatusInternalServerError)
+					w.Write([]byte(`{"status":"FAILED"}`))
+				})
+
+				return url
+			},
+		},
+		{
+			name: "timeout",
+			primary: func(t *testing.T) string {
+				url, _ := newTestGateway(t, func(w http.ResponseWriter, r *http.Request) {
+					select {
+					case <-r.Context().Done():
+					case <-time.After(time.Second):
+					}
+				})
+
+				return url
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secondaryURL, secondaryRequests := newTestGateway(t, acceptSMS)
+			provider := NewFailoverProvider(
+				NewTiaraProvider(tt.primary(t), "key", services.From, client),
+				NewTiaraProvider(secondaryURL, "key", services.From, client),
+			)
+
+			_, err := provider.SendSMS(context.Background(), services.SMSMessage{
+				PhoneNumber: "0700000000",
+				Message:     "hello",
+				RefID:       "ref",
+			})
+
+			if tt.failover {
+				if err != nil {
+					t.Fatalf("SendSMS() error = %v, want it sent by the secondary", err)
+				}
+				if got := secondaryRequests.Load(); got != 1 {
+					t.Errorf("secondary requests = %d, want 1", got)
+				}
+
+				return
+			}
+
+			if err == nil {
+				t.Fatal("SendSMS() error = nil, want the primary's error")
+			}
+			if got := secondaryRequests.Load(); got != 0 {
+				t.Errorf("secondary requests = %d, want 0", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package sms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/EmilioCliff/jonche/internal/services"
+	"github.com/EmilioCliff/jonche/pkg"
+)
+
+var _ services.SMSProvider = (*TiaraProvider)(nil)
+
+type tiaraSMSPayload struct {
+	From        string `json:"from"`
+	To          string `json:"to"`
+	Message     string `json:"message"`
+	RefId       string `json:"refId"`
+	MessageType string `json:"messageType"`
+}
+
+type tiaraSMSResponse struct {
+	Cost       string `json:"cost,omitempty"`
+	Mnc        string `json:"mnc,omitempty"`
+	Balance    string `json:"balance,omitempty"`
+	MsgId      string `json:"msgId,omitempty"`
+	To         string `json:"to,omitempty"`
+	Mcc        string `json:"mcc,omitempty"`
+	Desc       string `json:"desc,omitempty"`
+	Status     string `json:"status"`
+	StatusCode string `json:"statusCode,omitempty"`
+
+	Timestamp string `json:"timestamp,omitempty"`
+	Error     string `json:"error,omitempty"`
+	Message   string `json:"message,omitempty"`
+	Path      string `json:"path,omitempty"`
+}
+
+type TiaraProvider struct {
+	endpoint string
+	apiKey   string
+	from     string
+	client   *http.Client
+}
+
+func NewTiaraProvider(endpoint, apiKey, from string, client *http.Client) *TiaraProvider {
+	return &TiaraProvider{
+		endpoint: endpoint,
+		apiKey:   apiKey,
+		from:     from,
+		client:   client,
+	}
+}
+
+func (t *TiaraProvider) Name() string {
+	return services.SMSProviderTiara
+}
+
+func (t *TiaraProvider) SendSMS(
+	ctx context.Context,
+	msg services.SMSMessage,
+) (*services.SMSResult, error) {
+	jsonBody, err := json.Marshal(tiaraSMSPayload{
+		From:        t.from,
+		To:          internationalPhoneNumber(msg.PhoneNumber),
+		Message:     msg.Message,
+		RefId:       msg.RefID,
+		MessageType: "2",
+	})
+	if err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed marshaling body: %s", err.Error())
+	}
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		t.endpoint,
+		bytes.NewBuffer(jsonBody),
+	)
+	if err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to create request: %s", err.Error())
+	}
+
+	req.Header.Set("Authorization", "Bearer "+t.apiKey)
+	req.Header.Add("Content-Type", "application/json")
+
+	resp, err := doRequest(t.client, t.Name(), req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	responseBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error reading resp body: %s", err.Error())
+	}
+
+	// an error response is not always json, like a 503 from the load balancer
+	var tiaraRsp tiaraSMSResponse
+	if err := json.Unmarshal(responseBody, &tiaraRsp); err != nil && resp.StatusCode == http.StatusOK {
+		return nil, pkg.Errorf(
+			pkg.INTERNAL_ERROR,
+			"error unmarshaling resp body: %s",
+			err.Error(),
+		)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		desc := tiaraRsp.Desc
+		if desc == "" {
+			desc = tiaraRsp.Error
+		}
+
+		return nil, rejectionError(
+			resp.StatusCode,
+			"tiara rejected sms with status %d: %s",
+			resp.StatusCode,
+			desc,
+		)
+	}
+
+	return &services.SMSResult{
+		Provider:    t.Name(),
+		MessageID:   tiaraRsp.MsgId,
+		Status:      tiaraRsp.Status,
+		Description: tiaraRsp.Desc,
+		Cost:        tiaraRsp.Cost,
+		Balance:     tiaraRsp.Balance,
+	}, nil
+}
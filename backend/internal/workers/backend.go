@@ -0,0 +1,76 @@
+package workers
+
+import (
+	"context"
+
+	"github.com/hibiken/asynq"
+)
+
+// The worker runs on asynq and redis, or on the in-process queue when
+// WORKER_BACKEND is "inprocess". These are the parts of asynq each backend
+// provides.
+
+type taskClient interface {
+	EnqueueContext(ctx context.Context, task *asynq.Task, opts ...asynq.Option) (*asynq.TaskInfo, error)
+	Close() error
+}
+
+type taskServer interface {
+	Start(handler asynq.Handler) error
+	Shutdown()
+}
+
+type taskScheduler interface {
+	Start() error
+	Shutdown()
+}
+
+type taskInspector interface {
+	Queues() ([]string, error)
+	GetQueueInfo(queue string) (*asynq.QueueInfo, error)
+	GetTaskInfo(queue, id string) (*asynq.TaskInfo, error)
+	ListArchived(queue string, page, pageSize int) ([]*asynq.TaskInfo, error)
+	RunTask(queue, id string) error
+	RunAllArchivedTasks(queue string) (int, error)
+	DeleteTask(queue, id string) error
+	PauseQueue(queue string) error
+	UnpauseQueue(queue string) error
+	Close() error
+}
+
+type redisInspector struct {
+	*asynq.Inspector
+}
+
+func (i redisInspector) ListArchived(queue string, page, pageSize int) ([]*asynq.TaskInfo, error) {
+	return i.ListArchivedTasks(queue, asynq.Page(page), asynq.PageSize(pageSize))
+}
+
+// taskMetadata is what a handler knows about the task it is running.
+type taskMetadata struct {
+	ID       string
+	Queue    string
+	Retried  int
+	MaxRetry int
+}
+
+type taskMetadataKey struct{}
+
+func withTaskMetadata(ctx context.Context, metadata taskMetadata) context.Context {
+	return context.WithValue(ctx, taskMetadataKey{}, metadata)
+}
+
+// getTaskMetadata reads the metadata the in-process queue set, or asynq's.
+func getTaskMetadata(ctx context.Context) taskMetadata {
+	if metadata, ok := ctx.Value(taskMetadataKey{}).(taskMetadata); ok {
+		return metadata
+	}
+
+	var metadata taskMetadata
+	metadata.ID, _ = asynq.GetTaskID(ctx)
+	metadata.Queue, _ = asynq.GetQueueName(ctx)
+	metadata.Retried, _ = asynq.GetRetryCount(ctx)
+	metadata.MaxRetry, _ = asynq.GetMaxRetry(ctx)
+
+	return metadata
+}
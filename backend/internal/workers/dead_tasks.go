@@ -0,0 +1,168 @@
+package workers
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/EmilioCliff/jonche/internal/services"
+	"github.com/EmilioCliff/jonche/pkg"
+	"github.com/hibiken/asynq"
+)
+
+func (processor *TaskProcessor) listQueues() ([]*services.QueueInfo, error) {
+	queues, err := processor.inspector.Queues()
+	if err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to list queues: %s", err.Error())
+	}
+
+	rslt := make([]*services.QueueInfo, 0, len(queues))
+	for _, queue := range queues {
+		info, err := processor.inspector.GetQueueInfo(queue)
+		if err != nil {
+			return nil, inspectorError(err)
+		}
+
+		rslt = append(rslt, &services.QueueInfo{
+			Queue:     info.Queue,
+			Paused:    info.Paused,
+			Size:      info.Size,
+			Pending:   info.Pending,
+			Active:    info.Active,
+			Scheduled: info.Scheduled,
+			Retry:     info.Retry,
+			Archived:  info.Archived,
+			Completed: info.Completed,
+			Processed: info.Processed,
+			Failed:    info.Failed,
+		})
+	}
+
+	return rslt, nil
+}
+
+func (processor *TaskProcessor) listDeadTasks(
+	queue string,
+	pgData *pkg.PaginationMetadata,
+) ([]*services.DeadTask, pkg.PaginationMetadata, error) {
+	info, err := processor.inspector.GetQueueInfo(queue)
+	if err != nil {
+		return nil, pkg.PaginationMetadata{}, inspectorError(err)
+	}
+
+	tasks, err := processor.inspector.ListArchived(
+		queue,
+		int(pgData.CurrentPage),
+		int(pgData.PageSize),
+	)
+	if err != nil {
+		return nil, pkg.PaginationMetadata{}, inspectorError(err)
+	}
+
+	rslt := make([]*services.DeadTask, len(tasks))
+	for i, task := range tasks {
+		rslt[i] = &services.DeadTask{
+			ID:           task.ID,
+			Queue:        task.Queue,
+			Type:         task.Type,
+			Payload:      json.RawMessage(task.Payload),
+			LastError:    task.LastErr,
+			LastFailedAt: task.LastFailedAt,
+			Retried:      task.Retried,
+			MaxRetry:     task.MaxRetry,
+		}
+
+		if task.Type == SendSMSTask {
+			var payload services.SendSMSPayload
+			if err := json.Unmarshal(task.Payload, &payload); err == nil {
+				rslt[i].SMS = &payload
+			}
+		}
+
+		if !json.Valid(task.Payload) {
+			// keep the response valid json for payloads that are not
+			rslt[i].Payload, _ = json.Marshal(string(task.Payload))
+		}
+	}
+
+	return rslt, pkg.CreatePaginationMetadata(
+		uint32(info.Archived),
+		pgData.PageSize,
+		pgData.CurrentPage,
+	), nil
+}
+
+func (processor *TaskProcessor) retryDeadTask(queue, id string) error {
+	if err := processor.archivedTask(queue, id); err != nil {
+		return err
+	}
+
+	if err := processor.inspector.RunTask(queue, id); err != nil {
+		return inspectorError(err)
+	}
+
+	return nil
+}
+
+func (processor *TaskProcessor) deleteDeadTask(queue, id string) error {
+	if err := processor.archivedTask(queue, id); err != nil {
+		return err
+	}
+
+	if err := processor.inspector.DeleteTask(queue, id); err != nil {
+		return inspectorError(err)
+	}
+
+	return nil
+}
+
+func (processor *TaskProcessor) replayDeadTasks(queue string, ids []string) (int, error) {
+	if len(ids) == 0 {
+		replayed, err := processor.inspector.RunAllArchivedTasks(queue)
+		if err != nil {
+			return 0, inspectorError(err)
+		}
+
+		return replayed, nil
+	}
+
+	replayed := 0
+	for _, id := range ids {
+		if err := processor.retryDeadTask(queue, id); err != nil {
+			// already replayed or deleted
+			if pkg.ErrorCode(err) == pkg.NOT_FOUND_ERROR {
+				continue
+			}
+
+			return replayed, err
+		}
+		replayed++
+	}
+
+	return replayed, nil
+}
+
+// archivedTask checks the task is dead, the inspector would otherwise run or
+// delete a task that is still pending or waiting to retry.
+func (processor *TaskProcessor) archivedTask(queue, id string) error {
+	info, err := processor.inspector.GetTaskInfo(queue, id)
+	if err != nil {
+		return inspectorError(err)
+	}
+
+	if info.State != asynq.TaskStateArchived {
+		return pkg.Errorf(pkg.INVALID_ERROR, "task %s is %s, not dead", id, info.State)
+	}
+
+	return nil
+}
+
+func inspectorError(err error) error {
+	switch {
+	case errors.Is(err, asynq.ErrQueueNotFound):
+		return pkg.Errorf(pkg.NOT_FOUND_ERROR, "queue not found")
+	case errors.Is(err, asynq.ErrTaskNotFound):
+		return pkg.Errorf(pkg.NOT_FOUND_ERROR, "task not found")
+	default:
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to inspect tasks: %s", err.Error())
+	}
+}
@@ -0,0 +1,115 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/EmilioCliff/jonche/internal/repository"
+	"github.com/EmilioCliff/jonche/internal/services"
+	"github.com/EmilioCliff/jonche/pkg"
+	"github.com/hibiken/asynq"
+)
+
+const (
+	DispatchCampaignTask = "task:dispatch_campaign"
+)
+
+// CampaignTaskID is the task id a one-off campaign is enqueued under, so a
+// campaign is never queued twice.
+func CampaignTaskID(id uint32) string {
+	return fmt.Sprintf("campaign:%d", id)
+}
+
+func (distributor *TaskDistributor) DistributeTaskDispatchCampaign(
+	ctx context.Context,
+	payload services.DispatchCampaignPayload,
+	opt ...asynq.Option,
+) error {
+	if payload.CampaignID == 0 {
+		return pkg.Errorf(pkg.INVALID_ERROR, "campaign id is required")
+	}
+
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to marshal payload: %s", err.Error())
+	}
+
+	task := asynq.NewTask(DispatchCampaignTask, jsonPayload)
+	_, err = distributor.client.EnqueueContext(ctx, task, opt...)
+	if err != nil {
+		// the campaign is already waiting in the queue
+		if errors.Is(err, asynq.ErrTaskIDConflict) {
+			return nil
+		}
+
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to enqueue task: %s", err.Error())
+	}
+
+	return nil
+}
+
+func (processor *TaskProcessor) ProcessTaskDispatchCampaign(
+	ctx context.Context,
+	task *asynq.Task,
+) error {
+	var payload services.DispatchCampaignPayload
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %s: %w", err.Error(), asynq.SkipRetry)
+	}
+
+	campaign, err := processor.repo.SMSCampaignRepo.GetSMSCampaign(ctx, payload.CampaignID)
+	if err != nil {
+		if pkg.ErrorCode(err) == pkg.NOT_FOUND_ERROR {
+			return fmt.Errorf(
+				"campaign %d: %s: %w",
+				payload.CampaignID,
+				pkg.ErrorMessage(err),
+				asynq.SkipRetry,
+			)
+		}
+
+		return err
+	}
+
+	// paused and cancelled campaigns stay in the queue until they are due, they
+	// are dropped here
+	if campaign.Status != repository.CampaignScheduled {
+		log.Printf("skipping %s campaign %d", campaign.Status, campaign.ID)
+
+		return nil
+	}
+
+	message := campaign.Message
+	if campaign.TemplateID != 0 {
+		template, err := processor.repo.SMSTemplateRepo.GetSMSTemplate(ctx, campaign.TemplateID)
+		if err != nil {
+			return err
+		}
+
+		message = template.Body
+	}
+
+	ids, err := processor.repo.SMSCampaignRepo.ListCampaignRecipients(ctx, campaign.MinBalance)
+	if err != nil {
+		return err
+	}
+
+	skipped, err := processor.repo.SMSCampaignRepo.DispatchSMSCampaign(
+		ctx,
+		campaign.ID,
+		&repository.SMS{Message: message, Type: "automated"},
+		ids,
+	)
+	if err != nil {
+		return err
+	}
+
+	if len(skipped) > 0 {
+		log.Printf("campaign %d skipped %d opted out customers", campaign.ID, len(skipped))
+	}
+
+	return nil
+}
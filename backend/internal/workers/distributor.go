@@ -3,7 +3,7 @@ package workers
 import "github.com/hibiken/asynq"
 
 type TaskDistributor struct {
-	client *asynq.Client
+	client taskClient
 }
 
 func NewTaskDistributor(redisOpt asynq.RedisClientOpt) *TaskDistributor {
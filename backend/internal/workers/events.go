@@ -0,0 +1,122 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/EmilioCliff/jonche/internal/services"
+	"github.com/hibiken/asynq"
+)
+
+const (
+	PublishEventTask = services.PublishEventTask
+
+	// every instance subscribes to the channel and passes the events on to the
+	// dashboards connected to it
+	liveEventsChannel = "jonche:events"
+	// a dashboard that falls this far behind misses events instead of holding
+	// up the others
+	liveEventsBuffer = 64
+)
+
+// eventHub hands events to the streams open on this instance.
+type eventHub struct {
+	mu          sync.Mutex
+	subscribers map[chan *services.LiveEvent]struct{}
+}
+
+func newEventHub() *eventHub {
+	return &eventHub{subscribers: make(map[chan *services.LiveEvent]struct{})}
+}
+
+func (h *eventHub) subscribe() (<-chan *services.LiveEvent, func()) {
+	ch := make(chan *services.LiveEvent, liveEventsBuffer)
+
+	h.mu.Lock()
+	h.subscribers[ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subscribers, ch)
+			h.mu.Unlock()
+			close(ch)
+		})
+	}
+
+	return ch, unsubscribe
+}
+
+func (h *eventHub) broadcast(event *services.LiveEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+func (processor *TaskProcessor) ProcessTaskPublishEvent(ctx context.Context, task *asynq.Task) error {
+	event, err := decodeLiveEvent(task.Payload())
+	if err != nil {
+		return fmt.Errorf("%s: %w", err.Error(), asynq.SkipRetry)
+	}
+
+	// the in-process backend has a single instance to tell
+	if processor.rdb == nil {
+		processor.events.broadcast(event)
+
+		return nil
+	}
+
+	return processor.rdb.Publish(ctx, liveEventsChannel, task.Payload()).Err()
+}
+
+// relayEvents passes the events published by any instance to the streams open
+// on this one, until ctx is cancelled.
+func (processor *TaskProcessor) relayEvents(ctx context.Context) {
+	// go-redis subscribes again by itself when the connection drops
+	pubsub := processor.rdb.Subscribe(ctx, liveEventsChannel)
+	defer pubsub.Close()
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+
+			event, err := decodeLiveEvent([]byte(msg.Payload))
+			if err != nil {
+				log.Println(err)
+
+				continue
+			}
+			processor.events.broadcast(event)
+		}
+	}
+}
+
+func decodeLiveEvent(payload []byte) (*services.LiveEvent, error) {
+	var event services.Event
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal event: %s", err.Error())
+	}
+
+	return &services.LiveEvent{
+		ID:      event.ID,
+		Type:    event.Type,
+		Payload: payload,
+	}, nil
+}
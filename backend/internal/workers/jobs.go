@@ -0,0 +1,140 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/EmilioCliff/jonche/internal/services"
+	"github.com/EmilioCliff/jonche/pkg"
+	"github.com/hibiken/asynq"
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	PruneOutboxTask = "task:prune_outbox"
+)
+
+// job is a named task the scheduler enqueues on its cron schedule. A job with
+// no schedule is only run when triggered.
+type job struct {
+	name        string
+	taskType    string
+	cronspec    string
+	description string
+	handler     asynq.HandlerFunc
+	opts        []asynq.Option
+}
+
+// jobs is the registry of scheduled jobs, schedules come from the JOB_*_CRON
+// settings. Penalty accrual, risk scores and reports have no tables or rules to
+// run yet, their jobs are registered here once they do.
+func (processor *TaskProcessor) jobs() []job {
+	return []job{
+		{
+			name:        "send_reminders",
+			taskType:    SendRemindersTask,
+			cronspec:    processor.config.JOB_SEND_REMINDERS_CRON,
+			description: "Text customers about loans due soon, due today or overdue",
+			handler:     processor.ProcessTaskSendReminders,
+			opts:        jobOptions(),
+		},
+		{
+			name:        "prune_outbox",
+			taskType:    PruneOutboxTask,
+			cronspec:    processor.config.JOB_PRUNE_OUTBOX_CRON,
+			description: "Delete outbox tasks published more than a week ago",
+			handler:     processor.ProcessTaskPruneOutbox,
+			opts:        jobOptions(),
+		},
+	}
+}
+
+// jobOptions queue a job once at a time, a trigger while the scheduled run is
+// still waiting is turned down.
+func jobOptions() []asynq.Option {
+	return []asynq.Option{
+		asynq.Queue(services.QueueLow),
+		asynq.MaxRetry(1),
+		asynq.Unique(time.Hour),
+	}
+}
+
+func (processor *TaskProcessor) findJob(name string) (job, error) {
+	for _, j := range processor.jobs() {
+		if j.name == name {
+			return j, nil
+		}
+	}
+
+	return job{}, pkg.Errorf(pkg.NOT_FOUND_ERROR, "job %q not found", name)
+}
+
+// validateJobs fails start up on a bad schedule instead of the job silently
+// never running.
+func (processor *TaskProcessor) validateJobs() error {
+	for _, j := range processor.jobs() {
+		if j.cronspec == "" {
+			continue
+		}
+
+		if _, err := cron.ParseStandard(j.cronspec); err != nil {
+			return pkg.Errorf(pkg.INVALID_ERROR, "invalid schedule for job %s: %s", j.name, err.Error())
+		}
+	}
+
+	return nil
+}
+
+func (processor *TaskProcessor) listJobs() []*services.Job {
+	jobs := processor.jobs()
+	rslt := make([]*services.Job, len(jobs))
+	for i, j := range jobs {
+		rslt[i] = &services.Job{
+			Name:        j.name,
+			TaskType:    j.taskType,
+			Cronspec:    j.cronspec,
+			Description: j.description,
+		}
+
+		if schedule, err := cron.ParseStandard(j.cronspec); err == nil {
+			next := schedule.Next(time.Now())
+			rslt[i].NextRun = &next
+		}
+	}
+
+	return rslt
+}
+
+// triggerJob queues a job to run now, outside its schedule.
+func (processor *TaskProcessor) triggerJob(ctx context.Context, name string) (string, error) {
+	j, err := processor.findJob(name)
+	if err != nil {
+		return "", err
+	}
+
+	info, err := processor.distributor.client.EnqueueContext(ctx, asynq.NewTask(j.taskType, nil), j.opts...)
+	if err != nil {
+		if errors.Is(err, asynq.ErrDuplicateTask) {
+			return "", pkg.Errorf(pkg.ALREADY_EXISTS_ERROR, "job %s is already queued", name)
+		}
+
+		return "", pkg.Errorf(pkg.INTERNAL_ERROR, "failed to enqueue task: %s", err.Error())
+	}
+
+	return info.ID, nil
+}
+
+func (processor *TaskProcessor) ProcessTaskPruneOutbox(ctx context.Context, _ *asynq.Task) error {
+	deleted, err := processor.repo.OutboxRepo.DeletePublishedOutboxTasks(ctx, time.Now().Add(-outboxKeep))
+	if err != nil {
+		return err
+	}
+
+	if deleted > 0 {
+		log.Printf("pruned %d published outbox tasks", deleted)
+	}
+
+	return nil
+}
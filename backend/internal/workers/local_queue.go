@@ -0,0 +1,535 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/EmilioCliff/jonche/internal/services"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+)
+
+const (
+	// asynq's defaults
+	localDefaultMaxRetry = 25
+	localDefaultTimeout  = 30 * time.Minute
+	localDefaultPageSize = 30
+
+	// longest an idle worker sleeps before looking for due tasks again
+	localPollInterval = time.Second
+)
+
+type localTask struct {
+	info        asynq.TaskInfo
+	uniqueUntil time.Time
+}
+
+// localQueue runs tasks on goroutines in this process, with asynq's options and
+// retry semantics, so the worker runs without redis. Queues are served in strict
+// priority order and tasks are lost on restart, it is meant for development and
+// tests.
+type localQueue struct {
+	queues       []string
+	concurrency  int
+	retryDelay   asynq.RetryDelayFunc
+	isFailure    func(error) bool
+	errorHandler asynq.ErrorHandler
+
+	mu        sync.Mutex
+	tasks     map[string]map[string]*localTask
+	paused    map[string]bool
+	processed map[string]int
+	failed    map[string]int
+
+	handler asynq.Handler
+	wake    chan struct{}
+	stop    chan struct{}
+	wg      sync.WaitGroup
+}
+
+var (
+	_ taskClient    = (*localQueue)(nil)
+	_ taskServer    = (*localQueue)(nil)
+	_ taskInspector = (*localQueue)(nil)
+)
+
+// newLocalQueue serves queues in the order given, highest priority first.
+func newLocalQueue(
+	queues []string,
+	concurrency int,
+	retryDelay asynq.RetryDelayFunc,
+	isFailure func(error) bool,
+	errorHandler asynq.ErrorHandler,
+) *localQueue {
+	q := &localQueue{
+		queues:       slices.Clone(queues),
+		concurrency:  concurrency,
+		retryDelay:   retryDelay,
+		isFailure:    isFailure,
+		errorHandler: errorHandler,
+		tasks:        make(map[string]map[string]*localTask),
+		paused:       make(map[string]bool),
+		processed:    make(map[string]int),
+		failed:       make(map[string]int),
+		wake:         make(chan struct{}, 1),
+	}
+	for _, queue := range queues {
+		q.tasks[queue] = make(map[string]*localTask)
+	}
+
+	return q
+}
+
+func (q *localQueue) EnqueueContext(
+	_ context.Context,
+	task *asynq.Task,
+	opts ...asynq.Option,
+) (*asynq.TaskInfo, error) {
+	now := time.Now()
+	t := &localTask{
+		info: asynq.TaskInfo{
+			ID:            uuid.NewString(),
+			Queue:         services.QueueDefault,
+			Type:          task.Type(),
+			Payload:       task.Payload(),
+			State:         asynq.TaskStatePending,
+			MaxRetry:      localDefaultMaxRetry,
+			Timeout:       localDefaultTimeout,
+			NextProcessAt: now,
+		},
+	}
+
+	for _, opt := range opts {
+		switch opt.Type() {
+		case asynq.QueueOpt:
+			t.info.Queue = opt.Value().(string)
+		case asynq.MaxRetryOpt:
+			t.info.MaxRetry = max(opt.Value().(int), 0)
+		case asynq.TaskIDOpt:
+			t.info.ID = opt.Value().(string)
+		case asynq.TimeoutOpt:
+			t.info.Timeout = opt.Value().(time.Duration)
+		case asynq.RetentionOpt:
+			t.info.Retention = opt.Value().(time.Duration)
+		case asynq.UniqueOpt:
+			t.uniqueUntil = now.Add(opt.Value().(time.Duration))
+		case asynq.ProcessAtOpt:
+			t.info.NextProcessAt = opt.Value().(time.Time)
+		case asynq.ProcessInOpt:
+			t.info.NextProcessAt = now.Add(opt.Value().(time.Duration))
+		}
+	}
+	if t.info.NextProcessAt.After(now) {
+		t.info.State = asynq.TaskStateScheduled
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	tasks, ok := q.tasks[t.info.Queue]
+	if !ok {
+		q.queues = append(q.queues, t.info.Queue)
+		tasks = make(map[string]*localTask)
+		q.tasks[t.info.Queue] = tasks
+	}
+
+	if existing, ok := tasks[t.info.ID]; ok && !q.expired(existing, now) {
+		return nil, asynq.ErrTaskIDConflict
+	}
+	if !t.uniqueUntil.IsZero() {
+		for _, existing := range tasks {
+			if existing.info.Type == t.info.Type &&
+				string(existing.info.Payload) == string(t.info.Payload) &&
+				existing.uniqueUntil.After(now) &&
+				existing.info.State != asynq.TaskStateCompleted {
+				return nil, asynq.ErrDuplicateTask
+			}
+		}
+	}
+
+	tasks[t.info.ID] = t
+	q.notify()
+
+	info := t.info
+
+	return &info, nil
+}
+
+// expired reports whether a completed task is past its retention, its id can be
+// used again.
+func (q *localQueue) expired(t *localTask, now time.Time) bool {
+	return t.info.State == asynq.TaskStateCompleted && !t.info.CompletedAt.Add(t.info.Retention).After(now)
+}
+
+func (q *localQueue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (q *localQueue) Start(handler asynq.Handler) error {
+	q.handler = handler
+	q.stop = make(chan struct{})
+
+	for range q.concurrency {
+		q.wg.Add(1)
+		go func() {
+			defer q.wg.Done()
+			q.work()
+		}()
+	}
+
+	return nil
+}
+
+// Shutdown waits for the tasks being processed, queued ones are dropped.
+func (q *localQueue) Shutdown() {
+	if q.stop == nil {
+		return
+	}
+
+	close(q.stop)
+	q.wg.Wait()
+}
+
+func (q *localQueue) work() {
+	for {
+		select {
+		case <-q.stop:
+			return
+		default:
+		}
+
+		t, wait := q.next()
+		if t == nil {
+			select {
+			case <-q.stop:
+				return
+			case <-q.wake:
+			case <-time.After(wait):
+			}
+
+			continue
+		}
+
+		q.run(t)
+	}
+}
+
+// next takes the oldest due task of the highest priority queue that is not
+// paused. Without one it returns how long to wait for the next.
+func (q *localQueue) next() (*localTask, time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	wait := localPollInterval
+	for _, queue := range q.queues {
+		if q.paused[queue] {
+			continue
+		}
+
+		var due *localTask
+		for id, t := range q.tasks[queue] {
+			switch t.info.State {
+			case asynq.TaskStateCompleted:
+				if q.expired(t, now) {
+					delete(q.tasks[queue], id)
+				}
+			case asynq.TaskStatePending, asynq.TaskStateScheduled, asynq.TaskStateRetry:
+				if t.info.NextProcessAt.After(now) {
+					wait = min(wait, t.info.NextProcessAt.Sub(now))
+
+					continue
+				}
+				if due == nil || t.info.NextProcessAt.Before(due.info.NextProcessAt) {
+					due = t
+				}
+			}
+		}
+
+		if due != nil {
+			due.info.State = asynq.TaskStateActive
+
+			return due, 0
+		}
+	}
+
+	return nil, wait
+}
+
+func (q *localQueue) run(t *localTask) {
+	q.mu.Lock()
+	metadata := taskMetadata{
+		ID:       t.info.ID,
+		Queue:    t.info.Queue,
+		Retried:  t.info.Retried,
+		MaxRetry: t.info.MaxRetry,
+	}
+	task := asynq.NewTask(t.info.Type, t.info.Payload)
+	timeout := t.info.Timeout
+	q.mu.Unlock()
+
+	ctx := withTaskMetadata(context.Background(), metadata)
+	taskCtx, cancel := context.WithTimeout(ctx, timeout)
+	err := q.process(taskCtx, task)
+	cancel()
+
+	if err != nil && q.errorHandler != nil {
+		q.errorHandler.HandleError(ctx, task, err)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	switch {
+	case err == nil:
+		q.processed[t.info.Queue]++
+		if t.info.Retention <= 0 {
+			delete(q.tasks[t.info.Queue], t.info.ID)
+
+			return
+		}
+
+		t.info.State = asynq.TaskStateCompleted
+		t.info.CompletedAt = now
+	case errors.Is(err, asynq.RevokeTask):
+		delete(q.tasks[t.info.Queue], t.info.ID)
+	case !q.isFailure(err):
+		// retried without using up an attempt
+		t.info.State = asynq.TaskStateRetry
+		t.info.NextProcessAt = now.Add(q.retryDelay(t.info.Retried, err, task))
+	default:
+		q.failed[t.info.Queue]++
+		t.info.LastErr = err.Error()
+		t.info.LastFailedAt = now
+
+		if errors.Is(err, asynq.SkipRetry) || t.info.Retried >= t.info.MaxRetry {
+			t.info.State = asynq.TaskStateArchived
+
+			return
+		}
+
+		t.info.State = asynq.TaskStateRetry
+		t.info.NextProcessAt = now.Add(q.retryDelay(t.info.Retried, err, task))
+		t.info.Retried++
+	}
+}
+
+func (q *localQueue) process(ctx context.Context, task *asynq.Task) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("task %s panicked: %v", task.Type(), r)
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return q.handler.ProcessTask(ctx, task)
+}
+
+func (q *localQueue) Close() error {
+	return nil
+}
+
+func (q *localQueue) Queues() ([]string, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return slices.Clone(q.queues), nil
+}
+
+func (q *localQueue) GetQueueInfo(queue string) (*asynq.QueueInfo, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	tasks, ok := q.tasks[queue]
+	if !ok {
+		return nil, asynq.ErrQueueNotFound
+	}
+
+	info := &asynq.QueueInfo{
+		Queue:          queue,
+		Paused:         q.paused[queue],
+		Processed:      q.processed[queue],
+		Failed:         q.failed[queue],
+		ProcessedTotal: q.processed[queue],
+		FailedTotal:    q.failed[queue],
+		Timestamp:      time.Now(),
+	}
+	for _, t := range tasks {
+		switch t.info.State {
+		case asynq.TaskStatePending:
+			info.Pending++
+		case asynq.TaskStateActive:
+			info.Active++
+		case asynq.TaskStateScheduled:
+			info.Scheduled++
+		case asynq.TaskStateRetry:
+			info.Retry++
+		case asynq.TaskStateArchived:
+			info.Archived++
+		case asynq.TaskStateCompleted:
+			info.Completed++
+		}
+	}
+	info.Size = info.Pending + info.Active + info.Scheduled + info.Retry + info.Archived
+
+	return info, nil
+}
+
+func (q *localQueue) task(queue, id string) (*localTask, error) {
+	tasks, ok := q.tasks[queue]
+	if !ok {
+		return nil, asynq.ErrQueueNotFound
+	}
+
+	t, ok := tasks[id]
+	if !ok {
+		return nil, asynq.ErrTaskNotFound
+	}
+
+	return t, nil
+}
+
+func (q *localQueue) GetTaskInfo(queue, id string) (*asynq.TaskInfo, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	t, err := q.task(queue, id)
+	if err != nil {
+		return nil, err
+	}
+
+	info := t.info
+
+	return &info, nil
+}
+
+// ListArchived lists the dead tasks of a queue, the latest failure first.
+func (q *localQueue) ListArchived(queue string, page, pageSize int) ([]*asynq.TaskInfo, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	tasks, ok := q.tasks[queue]
+	if !ok {
+		return nil, asynq.ErrQueueNotFound
+	}
+
+	var archived []*asynq.TaskInfo
+	for _, t := range tasks {
+		if t.info.State == asynq.TaskStateArchived {
+			info := t.info
+			archived = append(archived, &info)
+		}
+	}
+	slices.SortFunc(archived, func(a, b *asynq.TaskInfo) int {
+		return b.LastFailedAt.Compare(a.LastFailedAt)
+	})
+
+	page = max(page, 1)
+	if pageSize <= 0 {
+		pageSize = localDefaultPageSize
+	}
+
+	start := min((page-1)*pageSize, len(archived))
+	end := min(start+pageSize, len(archived))
+
+	return archived[start:end], nil
+}
+
+func (q *localQueue) RunTask(queue, id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	t, err := q.task(queue, id)
+	if err != nil {
+		return err
+	}
+
+	switch t.info.State {
+	case asynq.TaskStateActive:
+		return fmt.Errorf("task %s is already running", id)
+	case asynq.TaskStatePending:
+		return fmt.Errorf("task %s is already pending", id)
+	case asynq.TaskStateCompleted:
+		return fmt.Errorf("task %s is already completed", id)
+	}
+
+	t.info.State = asynq.TaskStatePending
+	t.info.NextProcessAt = time.Now()
+	q.notify()
+
+	return nil
+}
+
+func (q *localQueue) RunAllArchivedTasks(queue string) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	tasks, ok := q.tasks[queue]
+	if !ok {
+		return 0, asynq.ErrQueueNotFound
+	}
+
+	n := 0
+	for _, t := range tasks {
+		if t.info.State == asynq.TaskStateArchived {
+			t.info.State = asynq.TaskStatePending
+			t.info.NextProcessAt = time.Now()
+			n++
+		}
+	}
+	q.notify()
+
+	return n, nil
+}
+
+func (q *localQueue) DeleteTask(queue, id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	t, err := q.task(queue, id)
+	if err != nil {
+		return err
+	}
+
+	if t.info.State == asynq.TaskStateActive {
+		return fmt.Errorf("cannot delete task %s while it is running", id)
+	}
+
+	delete(q.tasks[queue], id)
+
+	return nil
+}
+
+func (q *localQueue) PauseQueue(queue string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.paused[queue] {
+		return fmt.Errorf("queue %q is already paused", queue)
+	}
+	q.paused[queue] = true
+
+	return nil
+}
+
+func (q *localQueue) UnpauseQueue(queue string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if !q.paused[queue] {
+		return fmt.Errorf("queue %q is not paused", queue)
+	}
+	delete(q.paused, queue)
+	q.notify()
+
+	return nil
+}
@@ -0,0 +1,249 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/EmilioCliff/jonche/internal/services"
+	"github.com/EmilioCliff/jonche/pkg"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+)
+
+func newTestLocalQueue(t *testing.T, handler asynq.HandlerFunc) *localQueue {
+	t.Helper()
+
+	queue := newLocalQueue(
+		[]string{services.QueueCritical, services.QueueDefault},
+		2,
+		func(int, error, *asynq.Task) time.Duration { return time.Millisecond },
+		isFailure,
+		nil,
+	)
+	if err := queue.Start(handler); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	t.Cleanup(queue.Shutdown)
+
+	return queue
+}
+
+func enqueueTestTask(queue *localQueue, opts ...asynq.Option) (*asynq.TaskInfo, error) {
+	return queue.EnqueueContext(context.Background(), asynq.NewTask("test", nil), opts...)
+}
+
+// waitFor polls cond until it holds or the test times out.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the queue")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func queueInfo(t *testing.T, queue *localQueue, name string) *asynq.QueueInfo {
+	t.Helper()
+
+	info, err := queue.GetQueueInfo(name)
+	if err != nil {
+		t.Fatalf("GetQueueInfo(%s) error = %v", name, err)
+	}
+
+	return info
+}
+
+func TestLocalQueueProcessesTasks(t *testing.T) {
+	var processed atomic.Int32
+	queue := newTestLocalQueue(t, func(context.Context, *asynq.Task) error {
+		processed.Add(1)
+
+		return nil
+	})
+
+	for range 3 {
+		if _, err := enqueueTestTask(queue); err != nil {
+			t.Fatalf("EnqueueContext() error = %v", err)
+		}
+	}
+
+	waitFor(t, func() bool { return processed.Load() == 3 })
+	waitFor(t, func() bool { return queueInfo(t, queue, services.QueueDefault).Processed == 3 })
+}
+
+func TestLocalQueueRejectsTaskIDConflict(t *testing.T) {
+	release := make(chan struct{})
+	queue := newTestLocalQueue(t, func(context.Context, *asynq.Task) error {
+		<-release
+
+		return nil
+	})
+	defer close(release)
+
+	if _, err := enqueueTestTask(queue, asynq.TaskID("a")); err != nil {
+		t.Fatalf("EnqueueContext() error = %v", err)
+	}
+
+	_, err := enqueueTestTask(queue, asynq.TaskID("a"))
+	if !errors.Is(err, asynq.ErrTaskIDConflict) {
+		t.Fatalf("EnqueueContext() error = %v, want %v", err, asynq.ErrTaskIDConflict)
+	}
+}
+
+func TestLocalQueueArchivesAfterMaxRetry(t *testing.T) {
+	var attempts atomic.Int32
+	queue := newTestLocalQueue(t, func(context.Context, *asynq.Task) error {
+		attempts.Add(1)
+
+		return errors.New("failed")
+	})
+
+	info, err := enqueueTestTask(queue, asynq.MaxRetry(2))
+	if err != nil {
+		t.Fatalf("EnqueueContext() error = %v", err)
+	}
+
+	waitFor(t, func() bool { return queueInfo(t, queue, services.QueueDefault).Archived == 1 })
+
+	if got := attempts.Load(); got != 3 {
+		t.Errorf("attempts = %d, want 3", got)
+	}
+
+	task, err := queue.GetTaskInfo(services.QueueDefault, info.ID)
+	if err != nil {
+		t.Fatalf("GetTaskInfo() error = %v", err)
+	}
+	if task.Retried != 2 || task.LastErr != "failed" {
+		t.Errorf("task retried %d with %q, want 2 with %q", task.Retried, task.LastErr, "failed")
+	}
+
+	// a dead task can be replayed
+	if err := queue.RunTask(services.QueueDefault, info.ID); err != nil {
+		t.Fatalf("RunTask() error = %v", err)
+	}
+	waitFor(t, func() bool { return attempts.Load() == 4 })
+}
+
+func TestLocalQueueThrottledTasksKeepTheirRetries(t *testing.T) {
+	var attempts atomic.Int32
+	queue := newTestLocalQueue(t, func(context.Context, *asynq.Task) error {
+		if attempts.Add(1) < 3 {
+			return &rateLimitedError{provider: "test", retryIn: time.Millisecond}
+		}
+
+		return nil
+	})
+
+	if _, err := enqueueTestTask(queue, asynq.MaxRetry(0)); err != nil {
+		t.Fatalf("EnqueueContext() error = %v", err)
+	}
+
+	waitFor(t, func() bool { return queueInfo(t, queue, services.QueueDefault).Processed == 1 })
+
+	if failed := queueInfo(t, queue, services.QueueDefault).Failed; failed != 0 {
+		t.Errorf("failed = %d, want 0", failed)
+	}
+}
+
+func TestLocalQueueHoldsPausedQueues(t *testing.T) {
+	var processed atomic.Int32
+	queue := newTestLocalQueue(t, func(context.Context, *asynq.Task) error {
+		processed.Add(1)
+
+		return nil
+	})
+
+	if err := queue.PauseQueue(services.QueueCritical); err != nil {
+		t.Fatalf("PauseQueue() error = %v", err)
+	}
+
+	if _, err := enqueueTestTask(queue, asynq.Queue(services.QueueCritical)); err != nil {
+		t.Fatalf("EnqueueContext() error = %v", err)
+	}
+	if _, err := enqueueTestTask(queue); err != nil {
+		t.Fatalf("EnqueueContext() error = %v", err)
+	}
+
+	waitFor(t, func() bool { return processed.Load() == 1 })
+	if pending := queueInfo(t, queue, services.QueueCritical).Pending; pending != 1 {
+		t.Fatalf("held queue pending = %d, want 1", pending)
+	}
+
+	if err := queue.UnpauseQueue(services.QueueCritical); err != nil {
+		t.Fatalf("UnpauseQueue() error = %v", err)
+	}
+	waitFor(t, func() bool { return processed.Load() == 2 })
+}
+
+func newTestInProcessWorkerService(t *testing.T) *WorkerServiceImpl {
+	t.Helper()
+
+	worker, ok := NewWorkerService(
+		services.RedisConfig{},
+		nil,
+		pkg.Config{WORKER_BACKEND: WorkerBackendInProcess},
+		nil,
+	).(*WorkerServiceImpl)
+	if !ok {
+		t.Fatal("NewWorkerService() did not return a *WorkerServiceImpl")
+	}
+
+	return worker
+}
+
+func TestInProcessWorkerServiceDedupesSendSMS(t *testing.T) {
+	worker := newTestInProcessWorkerService(t)
+	payload := services.SendSMSPayload{
+		PhoneNumber: "0700000000",
+		Message:     "hello",
+		RefID:       uuid.NewString(),
+	}
+
+	for range 2 {
+		if err := worker.distributor.DistributeTaskSendSMS(
+			context.Background(),
+			payload,
+			asynq.Queue(services.QueueCritical),
+		); err != nil {
+			t.Fatalf("DistributeTaskSendSMS() error = %v", err)
+		}
+	}
+
+	info, err := worker.processor.inspector.GetQueueInfo(services.QueueCritical)
+	if err != nil {
+		t.Fatalf("GetQueueInfo() error = %v", err)
+	}
+	if info.Pending != 1 {
+		t.Errorf("pending = %d, want 1", info.Pending)
+	}
+}
+
+func TestInProcessWorkerServicePublishesEvents(t *testing.T) {
+	worker := newTestInProcessWorkerService(t)
+
+	events, unsubscribe := worker.SubscribeEvents()
+	defer unsubscribe()
+
+	payload := []byte(`{"id":"1","type":"loan.created","created_at":"2026-01-01T00:00:00Z","data":{}}`)
+	if err := worker.processor.ProcessTaskPublishEvent(
+		context.Background(),
+		asynq.NewTask(PublishEventTask, payload),
+	); err != nil {
+		t.Fatalf("ProcessTaskPublishEvent() error = %v", err)
+	}
+
+	select {
+	case event := <-events:
+		if event.ID != "1" || event.Type != services.EventLoanCreated {
+			t.Errorf("event = %s %s, want 1 %s", event.ID, event.Type, services.EventLoanCreated)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+	}
+}
@@ -0,0 +1,123 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/robfig/cron/v3"
+)
+
+// localScheduler enqueues periodic tasks on the in-process queue, syncing them
+// with the provider like asynq's PeriodicTaskManager does.
+type localScheduler struct {
+	queue        *localQueue
+	provider     asynq.PeriodicTaskConfigProvider
+	syncInterval time.Duration
+
+	cron    *cron.Cron
+	entries map[string]cron.EntryID
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+func newLocalScheduler(
+	queue *localQueue,
+	provider asynq.PeriodicTaskConfigProvider,
+	syncInterval time.Duration,
+) *localScheduler {
+	return &localScheduler{
+		queue:        queue,
+		provider:     provider,
+		syncInterval: syncInterval,
+		cron:         cron.New(),
+		entries:      make(map[string]cron.EntryID),
+		done:         make(chan struct{}),
+	}
+}
+
+func (s *localScheduler) Start() error {
+	if err := s.sync(); err != nil {
+		return err
+	}
+	s.cron.Start()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.syncInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.done:
+				return
+			case <-ticker.C:
+				if err := s.sync(); err != nil {
+					log.Printf("failed to sync periodic tasks: %s", err.Error())
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (s *localScheduler) Shutdown() {
+	close(s.done)
+	s.wg.Wait()
+	<-s.cron.Stop().Done()
+}
+
+// sync adds the new configs and removes the ones that are gone, the others keep
+// their schedule.
+func (s *localScheduler) sync() error {
+	configs, err := s.provider.GetConfigs()
+	if err != nil {
+		return err
+	}
+
+	current := make(map[string]bool, len(configs))
+	for _, config := range configs {
+		key := periodicTaskKey(config)
+		current[key] = true
+		if _, ok := s.entries[key]; ok {
+			continue
+		}
+
+		task, opts := config.Task, config.Opts
+		id, err := s.cron.AddFunc(config.Cronspec, func() {
+			if _, err := s.queue.EnqueueContext(context.Background(), task, opts...); err != nil {
+				log.Printf("failed to enqueue periodic task %s: %s", task.Type(), err.Error())
+			}
+		})
+		if err != nil {
+			log.Printf("failed to schedule %s on %q: %s", task.Type(), config.Cronspec, err.Error())
+
+			continue
+		}
+		s.entries[key] = id
+	}
+
+	for key, id := range s.entries {
+		if !current[key] {
+			s.cron.Remove(id)
+			delete(s.entries, key)
+		}
+	}
+
+	return nil
+}
+
+func periodicTaskKey(config *asynq.PeriodicTaskConfig) string {
+	parts := []string{config.Cronspec, config.Task.Type(), string(config.Task.Payload())}
+	for _, opt := range config.Opts {
+		parts = append(parts, opt.String())
+	}
+
+	return strings.Join(parts, "|")
+}
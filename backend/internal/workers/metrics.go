@@ -0,0 +1,113 @@
+package workers
+
+import (
+	"context"
+	"time"
+
+	"github.com/EmilioCliff/jonche/internal/metrics"
+	"github.com/EmilioCliff/jonche/internal/services"
+	"github.com/hibiken/asynq"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// instrumentTask counts and times every task the processor runs.
+func instrumentTask(next asynq.Handler) asynq.Handler {
+	return asynq.HandlerFunc(func(ctx context.Context, task *asynq.Task) error {
+		start := time.Now()
+		err := next.ProcessTask(ctx, task)
+
+		metrics.TaskDuration.WithLabelValues(task.Type()).Observe(time.Since(start).Seconds())
+		metrics.TasksProcessed.WithLabelValues(task.Type()).Inc()
+		switch {
+		case isRateLimited(err):
+			metrics.TasksThrottled.WithLabelValues(task.Type()).Inc()
+		case err != nil:
+			metrics.TasksFailed.WithLabelValues(task.Type()).Inc()
+		}
+
+		return err
+	})
+}
+
+var (
+	queueTasksDesc = prometheus.NewDesc(
+		"jonche_queue_tasks",
+		"Tasks in each queue by state.",
+		[]string{"queue", "state"},
+		nil,
+	)
+	queuePausedDesc = prometheus.NewDesc(
+		"jonche_queue_paused",
+		"1 when the queue is held, like when the sms balance runs out.",
+		[]string{"queue"},
+		nil,
+	)
+)
+
+// queueCollector reads the size of the queues whenever the metrics are scraped.
+type queueCollector struct {
+	processor *TaskProcessor
+}
+
+func (c queueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- queueTasksDesc
+	ch <- queuePausedDesc
+}
+
+func (c queueCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, info := range c.processor.queueInfos() {
+		states := []struct {
+			name string
+			size int
+		}{
+			{"pending", info.Pending},
+			{"active", info.Active},
+			{"scheduled", info.Scheduled},
+			{"retry", info.Retry},
+			{"archived", info.Archived},
+		}
+		for _, state := range states {
+			ch <- prometheus.MustNewConstMetric(
+				queueTasksDesc,
+				prometheus.GaugeValue,
+				float64(state.size),
+				info.Queue,
+				state.name,
+			)
+		}
+
+		var paused float64
+		if info.Paused {
+			paused = 1
+		}
+		ch <- prometheus.MustNewConstMetric(queuePausedDesc, prometheus.GaugeValue, paused, info.Queue)
+	}
+}
+
+// registerQueueMetrics reports the size of the queues on /metrics, a processor
+// that is started again takes over from the previous one.
+func (processor *TaskProcessor) registerQueueMetrics() {
+	collector := queueCollector{processor: processor}
+	prometheus.Unregister(collector)
+	prometheus.MustRegister(collector)
+}
+
+func (processor *TaskProcessor) queueInfos() []*asynq.QueueInfo {
+	var infos []*asynq.QueueInfo
+	for _, queue := range []string{
+		services.QueueCritical,
+		services.QueueDefault,
+		services.QueueWebhooks,
+		services.QueueSMS,
+		services.QueueLow,
+	} {
+		// a queue nothing was enqueued to yet is not known to redis
+		info, err := processor.inspector.GetQueueInfo(queue)
+		if err != nil {
+			continue
+		}
+		infos = append(infos, info)
+	}
+
+	return infos
+}
@@ -0,0 +1,51 @@
+package workers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/EmilioCliff/jonche/internal/services"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestQueueCollectorReportsHeldQueues(t *testing.T) {
+	worker := newTestInProcessWorkerService(t)
+	queue := worker.processor.inspector
+
+	if err := queue.PauseQueue(services.QueueSMS); err != nil {
+		t.Fatalf("PauseQueue() error = %v", err)
+	}
+	if err := worker.distributor.DistributeTaskSendSMS(
+		context.Background(),
+		services.SendSMSPayload{
+			PhoneNumber: "0700000000",
+			Message:     "hello",
+			RefID:       uuid.NewString(),
+		},
+		asynq.Queue(services.QueueSMS),
+	); err != nil {
+		t.Fatalf("DistributeTaskSendSMS() error = %v", err)
+	}
+
+	want := `
+# HELP jonche_queue_paused 1 when the queue is held, like when the sms balance runs out.
+# TYPE jonche_queue_paused gauge
+jonche_queue_paused{queue="critical"} 0
+jonche_queue_paused{queue="default"} 0
+jonche_queue_paused{queue="low"} 0
+jonche_queue_paused{queue="sms"} 1
+jonche_queue_paused{queue="webhooks"} 0
+`
+	collector := queueCollector{processor: worker.processor}
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(want), "jonche_queue_paused"); err != nil {
+		t.Error(err)
+	}
+
+	// five states for each of the five queues
+	if got := testutil.CollectAndCount(collector, "jonche_queue_tasks"); got != 25 {
+		t.Errorf("queue task series = %d, want 25", got)
+	}
+}
@@ -0,0 +1,89 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/EmilioCliff/jonche/internal/repository"
+	"github.com/EmilioCliff/jonche/pkg"
+	"github.com/hibiken/asynq"
+)
+
+const (
+	outboxBatchSize = 100
+	// published tasks stay in redis this long, a task the relay publishes twice
+	// within it is recognised by its id and queued once
+	outboxRetention = 24 * time.Hour
+	// published outbox rows are deleted by the prune_outbox job after a week
+	outboxKeep = 7 * 24 * time.Hour
+)
+
+func (distributor *TaskDistributor) publishOutboxTask(
+	ctx context.Context,
+	task *repository.OutboxTask,
+) error {
+	taskID := task.TaskID
+	if taskID == "" {
+		taskID = fmt.Sprintf("outbox:%d", task.ID)
+	}
+
+	opts := []asynq.Option{asynq.TaskID(taskID), asynq.Retention(outboxRetention)}
+	if task.Queue != "" {
+		opts = append(opts, asynq.Queue(task.Queue))
+	}
+	if task.MaxRetry >= 0 {
+		opts = append(opts, asynq.MaxRetry(task.MaxRetry))
+	}
+	if !task.ProcessAt.IsZero() {
+		opts = append(opts, asynq.ProcessAt(task.ProcessAt))
+	}
+
+	_, err := distributor.client.EnqueueContext(ctx, asynq.NewTask(task.TaskType, task.Payload), opts...)
+	if err != nil {
+		// already published before the row was marked
+		if errors.Is(err, asynq.ErrTaskIDConflict) {
+			return nil
+		}
+
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to enqueue task: %s", err.Error())
+	}
+
+	return nil
+}
+
+// runOutboxRelay publishes the tasks saved by the repositories once their
+// transaction has committed, until ctx is cancelled.
+func (processor *TaskProcessor) runOutboxRelay(ctx context.Context) {
+	relay := time.NewTicker(processor.config.OUTBOX_POLL_INTERVAL)
+	defer relay.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-relay.C:
+			processor.relayOutbox(ctx)
+		}
+	}
+}
+
+func (processor *TaskProcessor) relayOutbox(ctx context.Context) {
+	for {
+		published, err := processor.repo.OutboxRepo.RelayOutboxTasks(
+			ctx,
+			outboxBatchSize,
+			processor.distributor.publishOutboxTask,
+		)
+		if err != nil {
+			log.Printf("failed to relay outbox: %s", pkg.ErrorMessage(err))
+
+			return
+		}
+		if published < outboxBatchSize {
+			return
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/EmilioCliff/jonche/internal/postgres"
+	"github.com/EmilioCliff/jonche/internal/services"
+	"github.com/hibiken/asynq"
+)
+
+// periodicTaskConfigProvider feeds the periodic task manager the registered jobs
+// and the recurring campaigns stored in the database. With a lock only the
+// instance holding it gets any, the others schedule nothing until it stops.
+type periodicTaskConfigProvider struct {
+	repo *postgres.PostgresRepo
+	jobs []job
+	lock *schedulerLock
+}
+
+func (p *periodicTaskConfigProvider) GetConfigs() ([]*asynq.PeriodicTaskConfig, error) {
+	if p.lock != nil {
+		leader, err := p.lock.hold(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		if !leader {
+			return nil, nil
+		}
+	}
+
+	var configs []*asynq.PeriodicTaskConfig
+	for _, j := range p.jobs {
+		if j.cronspec == "" {
+			continue
+		}
+
+		configs = append(configs, &asynq.PeriodicTaskConfig{
+			Cronspec: j.cronspec,
+			Task:     asynq.NewTask(j.taskType, nil),
+			Opts:     j.opts,
+		})
+	}
+
+	campaigns, err := p.repo.SMSCampaignRepo.ListRecurringSMSCampaigns(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, campaign := range campaigns {
+		payload, err := json.Marshal(services.DispatchCampaignPayload{CampaignID: campaign.ID})
+		if err != nil {
+			return nil, err
+		}
+
+		configs = append(configs, &asynq.PeriodicTaskConfig{
+			Cronspec: campaign.CronSpec,
+			Task:     asynq.NewTask(DispatchCampaignTask, payload),
+			Opts:     []asynq.Option{asynq.Queue(services.QueueLow), asynq.MaxRetry(1)},
+		})
+	}
+
+	return configs, nil
+}
@@ -2,65 +2,215 @@ package workers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
+	"sync"
 	"time"
 
 	"github.com/EmilioCliff/jonche/internal/postgres"
 	"github.com/EmilioCliff/jonche/internal/services"
+	"github.com/EmilioCliff/jonche/internal/sms"
 	"github.com/EmilioCliff/jonche/pkg"
+	"github.com/google/uuid"
 	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
 )
 
 type TaskProcessor struct {
-	server *asynq.Server
-	repo   *postgres.PostgresRepo
-	config pkg.Config
+	server       taskServer
+	scheduler    taskScheduler
+	newScheduler func(provider asynq.PeriodicTaskConfigProvider) (taskScheduler, error)
+	inspector    taskInspector
+	lock         *schedulerLock
+	limiter      rateLimiter
+	// rdb carries the live events between instances, nil for the in-process backend
+	rdb           redis.UniversalClient
+	events        *eventHub
+	repo          *postgres.PostgresRepo
+	config        pkg.Config
+	smsProvider   services.SMSProvider
+	distributor   *TaskDistributor
+	webhookClient *http.Client
+	stopRelay     context.CancelFunc
+	relays        sync.WaitGroup
 }
 
 func NewTaskProcessor(
 	redisOpt asynq.RedisClientOpt,
 	repo *postgres.PostgresRepo,
 	config pkg.Config,
+	smsProvider services.SMSProvider,
+	distributor *TaskDistributor,
 ) *TaskProcessor {
 	server := asynq.NewServer(redisOpt, asynq.Config{
 		Queues: map[string]int{
 			services.QueueCritical: 10,
 			services.QueueDefault:  5,
+			services.QueueWebhooks: 4,
+			services.QueueSMS:      3,
 			services.QueueLow:      2,
 		},
-		RetryDelayFunc: CustomRetryDelayFunc,
+		RetryDelayFunc: newRetryPolicies(config).retryDelay,
+		IsFailure:      isFailure,
 		ErrorHandler:   asynq.ErrorHandlerFunc(ReportError),
 		LogLevel:       asynq.WarnLevel,
 	})
+	rdb := redisOpt.MakeRedisClient().(redis.UniversalClient)
 
-	return &TaskProcessor{server: server, repo: repo, config: config}
+	processor := &TaskProcessor{
+		server: server,
+		// the manager runs the registered jobs and the recurring campaigns, which live
+		// in the database, new, paused and cancelled ones are picked up on every sync
+		newScheduler: func(provider asynq.PeriodicTaskConfigProvider) (taskScheduler, error) {
+			return asynq.NewPeriodicTaskManager(asynq.PeriodicTaskManagerOpts{
+				RedisConnOpt:               redisOpt,
+				PeriodicTaskConfigProvider: provider,
+				SyncInterval:               time.Minute,
+				SchedulerOpts:              &asynq.SchedulerOpts{LogLevel: asynq.WarnLevel},
+			})
+		},
+		inspector: redisInspector{asynq.NewInspector(redisOpt)},
+		lock: &schedulerLock{
+			rdb: rdb,
+			id:  uuid.NewString(),
+		},
+		limiter:       &redisRateLimiter{rdb: rdb},
+		rdb:           rdb,
+		events:        newEventHub(),
+		repo:          repo,
+		config:        config,
+		smsProvider:   smsProvider,
+		distributor:   distributor,
+		webhookClient: newWebhookClient(config.WEBHOOK_TIMEOUT),
+	}
+	processor.smsProvider = sms.Throttle(smsProvider, processor.throttleSMS)
+
+	return processor
+}
+
+// NewInProcessTaskProcessor runs tasks on the in-process queue the distributor
+// enqueues to. There is a single instance so the scheduler takes no lock.
+func NewInProcessTaskProcessor(
+	queue *localQueue,
+	repo *postgres.PostgresRepo,
+	config pkg.Config,
+	smsProvider services.SMSProvider,
+	distributor *TaskDistributor,
+) *TaskProcessor {
+	processor := &TaskProcessor{
+		server: queue,
+		newScheduler: func(provider asynq.PeriodicTaskConfigProvider) (taskScheduler, error) {
+			return newLocalScheduler(queue, provider, time.Minute), nil
+		},
+		inspector:     queue,
+		limiter:       newLocalRateLimiter(),
+		events:        newEventHub(),
+		repo:          repo,
+		config:        config,
+		smsProvider:   smsProvider,
+		distributor:   distributor,
+		webhookClient: newWebhookClient(config.WEBHOOK_TIMEOUT),
+	}
+	processor.smsProvider = sms.Throttle(smsProvider, processor.throttleSMS)
+
+	return processor
 }
 
 func (processor *TaskProcessor) Start() error {
+	if err := processor.validateJobs(); err != nil {
+		return err
+	}
+
 	mux := asynq.NewServeMux()
+	mux.Use(instrumentTask)
 
 	mux.HandleFunc(SendSMSTask, processor.ProcessTaskSendSMS)
+	mux.HandleFunc(DispatchCampaignTask, processor.ProcessTaskDispatchCampaign)
+	mux.HandleFunc(DeliverWebhookTask, processor.ProcessTaskDeliverWebhook)
+	mux.HandleFunc(PublishEventTask, processor.ProcessTaskPublishEvent)
+	for _, j := range processor.jobs() {
+		mux.Handle(j.taskType, j.handler)
+	}
+
+	if err := processor.server.Start(mux); err != nil {
+		return err
+	}
+	processor.registerQueueMetrics()
+
+	scheduler, err := processor.newScheduler(&periodicTaskConfigProvider{
+		repo: processor.repo,
+		jobs: processor.jobs(),
+		lock: processor.lock,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := scheduler.Start(); err != nil {
+		return err
+	}
+	processor.scheduler = scheduler
+
+	relayCtx, stopRelay := context.WithCancel(context.Background())
+	processor.stopRelay = stopRelay
+	processor.relays.Add(1)
+	go func() {
+		defer processor.relays.Done()
+		processor.runOutboxRelay(relayCtx)
+	}()
+	if processor.rdb != nil {
+		processor.relays.Add(1)
+		go func() {
+			defer processor.relays.Done()
+			processor.relayEvents(relayCtx)
+		}()
+	}
 
-	return processor.server.Start(mux)
+	return nil
 }
 
 func (processor *TaskProcessor) Stop() {
+	if processor.stopRelay != nil {
+		processor.stopRelay()
+		processor.relays.Wait()
+	}
+	if processor.scheduler != nil {
+		processor.scheduler.Shutdown()
+	}
+	if processor.lock != nil {
+		// another instance can take over scheduling straight away
+		if err := processor.lock.release(context.Background()); err != nil {
+			log.Printf("failed to release scheduler lock: %s", err.Error())
+		}
+	}
 	processor.server.Shutdown()
+	processor.inspector.Close()
+	// shared by the lock, the rate limiter and the event relay, the handlers that
+	// were still running may have needed it
+	if processor.rdb != nil {
+		processor.rdb.Close()
+	}
 	log.Println("Task processor stopped successfully.")
 }
 
-func CustomRetryDelayFunc(_ int, _ error, _ *asynq.Task) time.Duration {
-	return 2 * time.Second
-}
-
 func ReportError(ctx context.Context, task *asynq.Task, err error) {
-	retried, _ := asynq.GetRetryCount(ctx)
-	maxRetry, _ := asynq.GetMaxRetry(ctx)
-	if retried >= maxRetry {
-		err = fmt.Errorf("retry exhausted for task %s: %w", task.Type(), err)
+	// throttled tasks are retried without counting as failures
+	if isRateLimited(err) {
+		return
+	}
+
+	metadata := getTaskMetadata(ctx)
+	if errors.Is(err, asynq.SkipRetry) || metadata.Retried >= metadata.MaxRetry {
+		// the task is archived, it can be replayed from /tasks/dead
+		err = fmt.Errorf(
+			"retry exhausted for task %s %s in %s queue, archived: %w",
+			task.Type(),
+			metadata.ID,
+			metadata.Queue,
+			err,
+		)
 	}
 	log.Println(err)
-	// log it or something
-	// errorReportingService.Notify(err)
 }
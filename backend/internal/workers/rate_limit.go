@@ -0,0 +1,171 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/EmilioCliff/jonche/internal/services"
+	"github.com/redis/go-redis/v9"
+)
+
+// rateLimiter hands out tokens from a bucket per key that refills at rate tokens a
+// second up to burst. take returns how long to wait when the bucket is empty.
+type rateLimiter interface {
+	take(ctx context.Context, key string, rate float64, burst int) (time.Duration, error)
+}
+
+// takeToken refills the bucket for the time passed and takes a token, returning
+// 0 or the milliseconds until one is available. Redis' clock is used so every
+// instance agrees on it.
+var takeToken = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "at")
+local tokens = tonumber(bucket[1]) or burst
+local at = tonumber(bucket[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - at) * rate / 1000)
+
+local wait = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+else
+	wait = math.ceil((1 - tokens) * 1000 / rate)
+end
+
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "at", now)
+redis.call("PEXPIRE", KEYS[1], math.ceil(burst * 1000 / rate) + 1000)
+return wait
+`)
+
+// redisRateLimiter shares the buckets between every instance running the worker.
+type redisRateLimiter struct {
+	rdb redis.UniversalClient
+}
+
+func (l *redisRateLimiter) take(
+	ctx context.Context,
+	key string,
+	rate float64,
+	burst int,
+) (time.Duration, error) {
+	wait, err := takeToken.Run(ctx, l.rdb, []string{"jonche:rate:" + key}, rate, burst).Int64()
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(wait) * time.Millisecond, nil
+}
+
+type tokenBucket struct {
+	tokens float64
+	at     time.Time
+}
+
+// localRateLimiter keeps the buckets in memory for the in-process backend.
+type localRateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+func newLocalRateLimiter() *localRateLimiter {
+	return &localRateLimiter{buckets: make(map[string]*tokenBucket)}
+}
+
+func (l *localRateLimiter) take(
+	_ context.Context,
+	key string,
+	rate float64,
+	burst int,
+) (time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: float64(burst), at: now}
+		l.buckets[key] = bucket
+	}
+
+	bucket.tokens = math.Min(float64(burst), bucket.tokens+now.Sub(bucket.at).Seconds()*rate)
+	bucket.at = now
+
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+
+		return 0, nil
+	}
+
+	return time.Duration(math.Ceil((1 - bucket.tokens) / rate * float64(time.Second))), nil
+}
+
+// rateLimitedError sends a task back to the queue until the gateway has capacity,
+// it does not count as a failed attempt.
+type rateLimitedError struct {
+	provider string
+	retryIn  time.Duration
+}
+
+func (e *rateLimitedError) Error() string {
+	return fmt.Sprintf("%s sms rate limit reached, retrying in %s", e.provider, e.retryIn)
+}
+
+func (e *rateLimitedError) Unwrap() error {
+	return services.ErrSMSThrottled
+}
+
+func isRateLimited(err error) bool {
+	var rateLimited *rateLimitedError
+
+	return errors.As(err, &rateLimited)
+}
+
+// isFailure is asynq's IsFailure, throttled tasks are retried without using up
+// their retries.
+func isFailure(err error) bool {
+	return !isRateLimited(err)
+}
+
+// smsRateLimit is how many messages a second the provider takes, 0 for no limit.
+func (processor *TaskProcessor) smsRateLimit(provider string) float64 {
+	switch strings.ToLower(provider) {
+	case services.SMSProviderTiara:
+		return processor.config.TIARA_RATE_LIMIT
+	case services.SMSProviderAfricasTalking:
+		return processor.config.AT_RATE_LIMIT
+	default:
+		return 0
+	}
+}
+
+// throttleSMS is the providers' SMSThrottle, it takes a token for the provider or
+// returns a rateLimitedError when it is sending as fast as it allows.
+func (processor *TaskProcessor) throttleSMS(ctx context.Context, provider string) error {
+	rate := processor.smsRateLimit(provider)
+	if rate <= 0 {
+		return nil
+	}
+
+	// a second's worth of messages can go out at once
+	wait, err := processor.limiter.take(ctx, "sms:"+provider, rate, max(int(math.Ceil(rate)), 1))
+	if err != nil {
+		// a limiter that is down should not stop messages going out
+		log.Printf("failed to take sms rate limit token: %s", err.Error())
+
+		return nil
+	}
+	if wait > 0 {
+		return &rateLimitedError{provider: provider, retryIn: wait}
+	}
+
+	return nil
+}
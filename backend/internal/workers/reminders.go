@@ -0,0 +1,166 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/EmilioCliff/jonche/internal/repository"
+	"github.com/EmilioCliff/jonche/internal/services"
+	"github.com/EmilioCliff/jonche/pkg"
+	"github.com/hibiken/asynq"
+)
+
+const (
+	SendRemindersTask = "task:send_reminders"
+)
+
+// ProcessTaskSendReminders texts customers about loans due soon, due today or
+// overdue. Each loan gets every stage at most once, a customer gets at most one
+// reminder per run and no more than REMINDER_WEEKLY_CAP in any 7 days.
+func (processor *TaskProcessor) ProcessTaskSendReminders(
+	ctx context.Context,
+	_ *asynq.Task,
+) error {
+	loc, err := time.LoadLocation(processor.config.REMINDER_TIMEZONE)
+	if err != nil {
+		return fmt.Errorf("invalid reminder timezone: %s: %w", err.Error(), asynq.SkipRetry)
+	}
+
+	now := time.Now().In(loc)
+	if inQuietHours(
+		now.Hour(),
+		processor.config.REMINDER_QUIET_START,
+		processor.config.REMINDER_QUIET_END,
+	) {
+		return nil
+	}
+
+	daysBefore := slices.Sorted(slices.Values(processor.config.REMINDER_DAYS_BEFORE))
+	overdueDays := slices.Sorted(slices.Values(processor.config.REMINDER_OVERDUE_DAYS))
+
+	// due dates have no time zone, compare them with the local calendar date
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	from, to := today, today
+	if len(overdueDays) > 0 {
+		from = today.AddDate(0, 0, -overdueDays[len(overdueDays)-1])
+	}
+	if len(daysBefore) > 0 {
+		to = today.AddDate(0, 0, daysBefore[len(daysBefore)-1])
+	}
+
+	// oldest first so an overdue loan is reminded before a newer one
+	instalments, err := processor.repo.ReminderRepo.ListDueInstalments(ctx, from, to)
+	if err != nil {
+		return err
+	}
+
+	reminded := make(map[uint32]bool)
+	for _, instalment := range instalments {
+		if reminded[instalment.CustomerID] {
+			continue
+		}
+
+		days := int(instalment.DueDate.Sub(today).Hours() / 24)
+		stage, template := reminderStage(days, daysBefore, overdueDays)
+		if stage == "" {
+			continue
+		}
+
+		if processor.config.REMINDER_WEEKLY_CAP > 0 {
+			sent, err := processor.repo.ReminderRepo.CountCustomerRemindersSince(
+				ctx,
+				instalment.CustomerID,
+				now.AddDate(0, 0, -7),
+			)
+			if err != nil {
+				return err
+			}
+
+			if sent >= int64(processor.config.REMINDER_WEEKLY_CAP) {
+				reminded[instalment.CustomerID] = true
+
+				continue
+			}
+		}
+
+		daysOverdue := 0
+		if days < 0 {
+			daysOverdue = -days
+		}
+
+		created, err := processor.repo.ReminderRepo.CreateLoanReminder(
+			ctx,
+			&repository.CreateLoanReminder{
+				LoanID:      instalment.LoanID,
+				CustomerID:  instalment.CustomerID,
+				PhoneNumber: instalment.PhoneNumber,
+				Stage:       stage,
+				Template:    template,
+				Params: pkg.CustomerTemplateParams{
+					Name:        instalment.Name,
+					PhoneNumber: instalment.PhoneNumber,
+					Loaned:      instalment.Loaned,
+					Credit:      instalment.Credit,
+					DueDate:     instalment.DueDate.Format("02 Jan 2006"),
+					DueAmount:   instalment.Outstanding,
+					DaysOverdue: daysOverdue,
+				},
+			},
+		)
+		if err != nil {
+			log.Printf(
+				"failed to send %s reminder for loan %d: %s",
+				stage,
+				instalment.LoanID,
+				pkg.ErrorMessage(err),
+			)
+
+			continue
+		}
+
+		if created {
+			reminded[instalment.CustomerID] = true
+		}
+	}
+
+	return nil
+}
+
+// reminderStage picks the latest stage a loan due in days has reached. A stage
+// missed by an earlier run, e.g. during quiet hours, is still sent later on.
+func reminderStage(days int, daysBefore, overdueDays []int) (string, string) {
+	switch {
+	case days == 0:
+		return "due", services.ReminderDueTemplate
+	case days > 0:
+		for _, before := range daysBefore {
+			if before >= days {
+				return fmt.Sprintf("before_%d", before), services.ReminderBeforeTemplate
+			}
+		}
+	default:
+		for i := len(overdueDays) - 1; i >= 0; i-- {
+			if overdueDays[i] <= -days {
+				return fmt.Sprintf("overdue_%d", overdueDays[i]), services.ReminderOverdueTemplate
+			}
+		}
+	}
+
+	return "", ""
+}
+
+// inQuietHours reports whether hour falls in the quiet window, which may wrap
+// past midnight.
+func inQuietHours(hour, start, end int) bool {
+	switch {
+	case start == end:
+		return false
+	case start < end:
+		return hour >= start && hour < end
+	default:
+		return hour >= start || hour < end
+	}
+}
@@ -0,0 +1,80 @@
+package workers
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/EmilioCliff/jonche/pkg"
+	"github.com/hibiken/asynq"
+)
+
+// retryPolicy doubles the wait after every failed attempt, from Base up to Max.
+type retryPolicy struct {
+	Base time.Duration
+	Max  time.Duration
+}
+
+// retryPolicies are looked up by task type, tasks not listed use the default.
+type retryPolicies struct {
+	byType   map[string]retryPolicy
+	fallback retryPolicy
+}
+
+func newRetryPolicies(config pkg.Config) *retryPolicies {
+	return &retryPolicies{
+		byType: map[string]retryPolicy{
+			SendSMSTask: {Base: config.SMS_RETRY_BASE_DELAY, Max: config.SMS_RETRY_MAX_DELAY},
+		},
+		fallback: retryPolicy{Base: config.TASK_RETRY_BASE_DELAY, Max: config.TASK_RETRY_MAX_DELAY},
+	}
+}
+
+// retryDelay is the asynq RetryDelayFunc. Half the delay is random so tasks that
+// failed together, like a batch during a gateway outage, do not all retry at once.
+func (p *retryPolicies) retryDelay(retried int, err error, task *asynq.Task) time.Duration {
+	// a throttled task comes back once the bucket has a token, spread over as long
+	// again so they do not all return together
+	var rateLimited *rateLimitedError
+	if errors.As(err, &rateLimited) {
+		return rateLimited.retryIn + rand.N(rateLimited.retryIn+time.Second)
+	}
+
+	policy, ok := p.byType[task.Type()]
+	if !ok {
+		policy = p.fallback
+	}
+
+	delay := policy.Base
+	for i := 0; i < retried && delay < policy.Max; i++ {
+		delay *= 2
+	}
+	if delay > policy.Max {
+		delay = policy.Max
+	}
+
+	half := delay / 2
+	if half <= 0 {
+		return delay
+	}
+
+	return half + rand.N(half)
+}
+
+// permanentError stops asynq from retrying a task that can never succeed, it is
+// archived straight away.
+func permanentError(err error) error {
+	if errors.Is(err, asynq.SkipRetry) {
+		return err
+	}
+
+	return fmt.Errorf("%s: %w", pkg.ErrorMessage(err), asynq.SkipRetry)
+}
+
+// isPermanentSMSError reports whether the gateway turned the message down for good,
+// like an invalid or blacklisted number. Outages, timeouts and a lack of credit
+// are worth retrying.
+func isPermanentSMSError(err error) bool {
+	return pkg.ErrorCode(err) == pkg.INVALID_ERROR && !isNoCredit(err)
+}
@@ -0,0 +1,59 @@
+package workers
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	schedulerLockKey = "jonche:scheduler:lock"
+	// outlives a few syncs of the periodic task manager so the instance holding it
+	// renews it long before it expires
+	schedulerLockTTL = 3 * time.Minute
+)
+
+// extendLock renews the lock only if this instance still holds it.
+var extendLock = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+var releaseLock = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// schedulerLock lets only one instance schedule jobs when several run the worker,
+// the others take over once it stops renewing the lock.
+type schedulerLock struct {
+	rdb redis.UniversalClient
+	id  string
+}
+
+// hold takes the lock or renews it and reports whether this instance has it.
+func (l *schedulerLock) hold(ctx context.Context) (bool, error) {
+	ok, err := l.rdb.SetNX(ctx, schedulerLockKey, l.id, schedulerLockTTL).Result()
+	if err != nil || ok {
+		return ok, err
+	}
+
+	extended, err := extendLock.Run(
+		ctx,
+		l.rdb,
+		[]string{schedulerLockKey},
+		l.id,
+		schedulerLockTTL.Milliseconds(),
+	).Int()
+
+	return extended == 1, err
+}
+
+func (l *schedulerLock) release(ctx context.Context) error {
+	return releaseLock.Run(ctx, l.rdb, []string{schedulerLockKey}, l.id).Err()
+}
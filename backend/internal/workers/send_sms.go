@@ -1,14 +1,13 @@
 package workers
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
-	"io"
-	"net/http"
-	"regexp"
-	"strings"
+	"errors"
+	"fmt"
+	"log"
 
+	"github.com/EmilioCliff/jonche/internal/metrics"
 	"github.com/EmilioCliff/jonche/internal/repository"
 	"github.com/EmilioCliff/jonche/internal/services"
 	"github.com/EmilioCliff/jonche/pkg"
@@ -17,41 +16,16 @@ import (
 )
 
 const (
-	SendSMSTask = "task:send_sms"
+	SendSMSTask = services.SendSMSTask
 )
 
-type tiaraSMSPayload struct {
-	From        string `json:"from"`
-	To          string `json:"to"`
-	Message     string `json:"message"`
-	RefId       string `json:"refId"`
-	MessageType string `json:"messageType"`
-}
-
-type tiaraSMSResponse struct {
-	Cost       string `json:"cost,omitempty"`
-	Mnc        string `json:"mnc,omitempty"`
-	Balance    string `json:"balance,omitempty"`
-	MsgId      string `json:"msgId,omitempty"`
-	To         string `json:"to,omitempty"`
-	Mcc        string `json:"mcc,omitempty"`
-	Desc       string `json:"desc,omitempty"`
-	Status     string `json:"status"`
-	StatusCode string `json:"statusCode,omitempty"`
-
-	Timestamp string `json:"timestamp,omitempty"`
-	Error     string `json:"error,omitempty"`
-	Message   string `json:"message,omitempty"`
-	Path      string `json:"path,omitempty"`
-}
-
 func (distributor *TaskDistributor) DistributeTaskSendSMS(
 	ctx context.Context,
 	payload services.SendSMSPayload,
 	opt ...asynq.Option,
 ) error {
-	if len(payload.Messages) != len(payload.PhoneNumbers) {
-		return pkg.Errorf(pkg.INVALID_ERROR, "mesages and phonumbers should be of the same length")
+	if payload.PhoneNumber == "" || payload.RefID == "" {
+		return pkg.Errorf(pkg.INVALID_ERROR, "phone number and ref id are required")
 	}
 
 	jsonPayload, err := json.Marshal(payload)
@@ -59,9 +33,16 @@ func (distributor *TaskDistributor) DistributeTaskSendSMS(
 		return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to marshal payload: %s", err.Error())
 	}
 
-	task := asynq.NewTask(SendSMSTask, jsonPayload, opt...)
-	_, err = distributor.client.EnqueueContext(ctx, task)
+	opt = append([]asynq.Option{asynq.TaskID(services.SendSMSTaskID(payload.RefID, payload.Resend))}, opt...)
+
+	task := asynq.NewTask(SendSMSTask, jsonPayload)
+	_, err = distributor.client.EnqueueContext(ctx, task, opt...)
 	if err != nil {
+		// this send of the message is already queued
+		if errors.Is(err, asynq.ErrTaskIDConflict) {
+			return nil
+		}
+
 		return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to enqueue task: %s", err.Error())
 	}
 
@@ -71,99 +52,128 @@ func (distributor *TaskDistributor) DistributeTaskSendSMS(
 func (processor *TaskProcessor) ProcessTaskSendSMS(ctx context.Context, task *asynq.Task) error {
 	var payload services.SendSMSPayload
 	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
-		return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to unmarshal payload: %s", err.Error())
+		return fmt.Errorf("failed to unmarshal payload: %s: %w", err.Error(), asynq.SkipRetry)
 	}
 
-	for i := range payload.PhoneNumbers {
-		requestBody := tiaraSMSPayload{
-			From:        services.From,
-			To:          setPhoneNumber(payload.PhoneNumbers[i]),
-			Message:     payload.Messages[i],
-			RefId:       payload.RefIds[i],
-			MessageType: "2",
-		}
-		jsonBody, err := json.Marshal(requestBody)
+	if payload.PhoneNumber == "" {
+		return fmt.Errorf("no phone number for %s: %w", payload.RefID, asynq.SkipRetry)
+	}
 
-		if err != nil {
-			return pkg.Errorf(pkg.INTERNAL_ERROR, "failed marshaling body: %s", err.Error())
-		}
+	refID, err := uuid.Parse(payload.RefID)
+	if err != nil {
+		return fmt.Errorf("invalid ref id %q: %w", payload.RefID, asynq.SkipRetry)
+	}
 
-		req, err := http.NewRequest(
-			http.MethodPost,
-			// processor.config.TIARA_ENDPOINT,
-			"",
-			bytes.NewBuffer(jsonBody),
-		)
-		if err != nil {
-			return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to create request: %s", err.Error())
-		}
+	submission, err := processor.repo.SMSRepo.BeginSMSSubmission(ctx, payload.RefID, payload.Resend)
+	if err != nil {
+		return err
+	}
 
-		req.Header.Set("Authorization", "Bearer "+processor.config.TIARA_API_KEY)
-		req.Header.Add("Content-Type", "application/json")
+	// a retry after the gateway took the message only finishes recording it
+	if submission.Status == repository.SMSSubmissionAccepted {
+		return processor.recordSMSSent(ctx, refID, submission)
+	}
 
-		client := &http.Client{}
+	rslt, err := processor.smsProvider.SendSMS(ctx, services.SMSMessage{
+		PhoneNumber: payload.PhoneNumber,
+		Message:     payload.Message,
+		RefID:       payload.RefID,
+	})
+	if err != nil {
+		// nothing went out, the retry begins the submission again
+		if isRateLimited(err) {
+			return err
+		}
+
+		// keep the failure on the row, the task is retried for this recipient only
+		if processor.config.SMS_HOLD_ON_NO_CREDIT && isNoCredit(err) {
+			processor.holdQueues()
+		}
 
-		resp, err := client.Do(req)
-		if err != nil {
-			return pkg.Errorf(pkg.INTERNAL_ERROR, "failed sending request: %s", err.Error())
+		update := &repository.UpdateSMS{RefID: refID}
+		desc := pkg.ErrorMessage(err)
+		update.Description = &desc
+
+		if failErr := processor.repo.SMSRepo.FailSMSSubmission(
+			ctx,
+			payload.RefID,
+			payload.Resend,
+			desc,
+		); failErr != nil {
+			log.Printf("failed to record sms submission %s: %s", payload.RefID, pkg.ErrorMessage(failErr))
 		}
-		defer resp.Body.Close()
 
-		responseBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return pkg.Errorf(pkg.INTERNAL_ERROR, "error reading resp body: %s", err.Error())
+		// the last attempt settles the message's status
+		metadata := getTaskMetadata(ctx)
+		permanent := isPermanentSMSError(err)
+		switch {
+		case permanent:
+			status := services.SMSStatusRejected
+			update.DeliveryStatus = &status
+		case metadata.Retried >= metadata.MaxRetry:
+			status := services.SMSStatusFailed
+			update.DeliveryStatus = &status
 		}
 
-		var tiaraRsp tiaraSMSResponse
-		if err := json.Unmarshal(responseBody, &tiaraRsp); err != nil {
-			return pkg.Errorf(
-				pkg.INTERNAL_ERROR,
-				"error unmarshaling resp body: %s",
-				err.Error(),
-			)
+		if updateErr := processor.repo.SMSRepo.UpdateSMS(ctx, update); updateErr != nil {
+			return updateErr
 		}
 
-		if resp.StatusCode == http.StatusOK {
-			par := repository.UpdateSMS{
-				RefID:       uuid.MustParse(payload.RefIds[0]),
-				Description: &tiaraRsp.Desc,
-				Cost:        &tiaraRsp.Cost,
-			}
-
-			if err := processor.repo.SMSRepo.UpdateSMS(ctx, &par); err != nil {
-				return err
-			}
-		} else {
-			pa := repository.UpdateSMS{
-				RefID:       uuid.MustParse(payload.RefIds[0]),
-				Description: &tiaraRsp.Desc,
-				Cost:        &tiaraRsp.Error, // just checking
-			}
-			if err := processor.repo.SMSRepo.UpdateSMS(ctx, &pa); err != nil {
-				return err
-			}
+		if permanent {
+			return permanentError(err)
 		}
 
+		return err
 	}
 
-	return nil
-}
+	submission = &repository.SMSSubmission{
+		RefID:             payload.RefID,
+		Resend:            payload.Resend,
+		Provider:          rslt.Provider,
+		ProviderMessageID: rslt.MessageID,
+		Cost:              pkg.ParseSMSAmount(rslt.Cost),
+		Description:       rslt.Description,
+	}
+	metrics.SMSSent.WithLabelValues(rslt.Provider).Inc()
+	metrics.SMSCost.WithLabelValues(rslt.Provider).Add(submission.Cost)
 
-func setPhoneNumber(phoneNumber string) string {
-	re := regexp.MustCompile(`\D`)
-	phoneNumber = re.ReplaceAllString(phoneNumber, "")
+	// the message is out, a retry that does not find the submission accepted
+	// would send it again, so it is better to lose the details than to retry
+	if err := processor.repo.SMSRepo.AcceptSMSSubmission(ctx, submission); err != nil {
+		log.Printf("failed to record sms submission %s: %s", payload.RefID, pkg.ErrorMessage(err))
 
-	if strings.HasPrefix(phoneNumber, "2547") {
-		return phoneNumber
+		if recordErr := processor.recordSMSSent(ctx, refID, submission); recordErr != nil {
+			log.Printf("failed to record sms %s: %s", payload.RefID, pkg.ErrorMessage(recordErr))
+		}
+
+		return permanentError(err)
 	}
 
-	if strings.HasPrefix(phoneNumber, "07") {
-		return "254" + phoneNumber[1:]
+	// a retry finds the submission accepted and only records it
+	if err := processor.recordSMSSent(ctx, refID, submission); err != nil {
+		return err
 	}
 
-	if strings.HasPrefix(phoneNumber, "7") {
-		return "254" + phoneNumber
+	if rslt.Balance != "" {
+		if err := processor.trackSMSBalance(ctx, rslt.Provider, pkg.ParseSMSAmount(rslt.Balance)); err != nil {
+			log.Printf("failed to track sms balance: %s", pkg.ErrorMessage(err))
+		}
 	}
 
-	return phoneNumber
+	return nil
+}
+
+// recordSMSSent stores what the gateway said about an accepted message on its row.
+func (processor *TaskProcessor) recordSMSSent(
+	ctx context.Context,
+	refID uuid.UUID,
+	submission *repository.SMSSubmission,
+) error {
+	return processor.repo.SMSRepo.UpdateSMS(ctx, &repository.UpdateSMS{
+		RefID:             refID,
+		Description:       &submission.Description,
+		Cost:              &submission.Cost,
+		Provider:          &submission.Provider,
+		ProviderMessageID: &submission.ProviderMessageID,
+	})
 }
@@ -0,0 +1,101 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/EmilioCliff/jonche/internal/services"
+	"github.com/EmilioCliff/jonche/pkg"
+	"github.com/google/uuid"
+)
+
+// heldQueues are paused while the gateway is out of credit instead of burning their
+// retries. Transactional messages like payment receipts keep trying.
+var heldQueues = []string{services.QueueSMS}
+
+// trackSMSBalance stores the balance the gateway reported and alerts admins once
+// when it drops below SMS_BALANCE_THRESHOLD.
+func (processor *TaskProcessor) trackSMSBalance(
+	ctx context.Context,
+	provider string,
+	balance float64,
+) error {
+	rslt, err := processor.repo.SMSBalanceRepo.UpdateSMSProviderBalance(ctx, provider, balance)
+	if err != nil {
+		return err
+	}
+
+	if balance > 0 && processor.config.SMS_HOLD_ON_NO_CREDIT {
+		processor.releaseQueues()
+	}
+
+	switch {
+	case balance < processor.config.SMS_BALANCE_THRESHOLD && !rslt.Alerted:
+		processor.alertLowBalance(ctx, provider, balance)
+
+		return processor.repo.SMSBalanceRepo.SetSMSProviderBalanceAlerted(ctx, provider, true)
+	case balance >= processor.config.SMS_BALANCE_THRESHOLD && rslt.Alerted:
+		// topped up, alert again next time it runs low
+		return processor.repo.SMSBalanceRepo.SetSMSProviderBalanceAlerted(ctx, provider, false)
+	}
+
+	return nil
+}
+
+// alertLowBalance texts the numbers in SMS_BALANCE_ALERT_TO straight through the
+// gateway, admins are not customers so nothing is stored.
+func (processor *TaskProcessor) alertLowBalance(ctx context.Context, provider string, balance float64) {
+	message := fmt.Sprintf(
+		"SMS credit on %s is down to KES %.2f, below the KES %.2f alert level. Please top up.",
+		provider,
+		balance,
+		processor.config.SMS_BALANCE_THRESHOLD,
+	)
+	log.Println(message)
+
+	for _, phoneNumber := range processor.config.SMS_BALANCE_ALERT_TO {
+		if _, err := processor.smsProvider.SendSMS(ctx, services.SMSMessage{
+			PhoneNumber: phoneNumber,
+			Message:     message,
+			RefID:       uuid.NewString(),
+		}); err != nil {
+			log.Printf("failed to send low sms balance alert to %s: %s", phoneNumber, pkg.ErrorMessage(err))
+		}
+	}
+}
+
+// isNoCredit reports whether the gateway turned a message down for lack of credit,
+// Tiara says "Insufficient balance" and Africa's Talking "InsufficientBalance".
+func isNoCredit(err error) bool {
+	return strings.Contains(strings.ToLower(pkg.ErrorMessage(err)), "insufficient")
+}
+
+func (processor *TaskProcessor) holdQueues() {
+	for _, queue := range heldQueues {
+		if err := processor.inspector.PauseQueue(queue); err != nil {
+			if !strings.Contains(err.Error(), "already paused") {
+				log.Printf("failed to hold %s queue: %s", queue, err.Error())
+			}
+
+			continue
+		}
+
+		log.Printf("sms gateway is out of credit, holding the %s queue", queue)
+	}
+}
+
+func (processor *TaskProcessor) releaseQueues() {
+	for _, queue := range heldQueues {
+		if err := processor.inspector.UnpauseQueue(queue); err != nil {
+			if !strings.Contains(err.Error(), "not paused") {
+				log.Printf("failed to release %s queue: %s", queue, err.Error())
+			}
+
+			continue
+		}
+
+		log.Printf("releasing the %s queue", queue)
+	}
+}
@@ -0,0 +1,178 @@
+package workers
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/EmilioCliff/jonche/internal/repository"
+	"github.com/EmilioCliff/jonche/internal/services"
+	"github.com/EmilioCliff/jonche/pkg"
+	"github.com/hibiken/asynq"
+)
+
+const (
+	DeliverWebhookTask = services.DeliverWebhookTask
+
+	// only the start of a subscriber's response is kept in the delivery log
+	webhookResponseLimit = 1024
+)
+
+// newWebhookClient refuses to connect to hosts that are not public, a host that
+// resolved to a public address when the subscription was saved may not any more.
+func newWebhookClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+
+			if !pkg.IsPublicIP(net.ParseIP(host)) {
+				return fmt.Errorf("webhook host %s is not public", host)
+			}
+
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+	}
+}
+
+func (processor *TaskProcessor) ProcessTaskDeliverWebhook(ctx context.Context, task *asynq.Task) error {
+	var payload services.DeliverWebhookPayload
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %s: %w", err.Error(), asynq.SkipRetry)
+	}
+
+	delivery, subscription, err := processor.repo.WebhookRepo.GetWebhookDelivery(ctx, payload.DeliveryID)
+	if err != nil {
+		// deleting a subscription deletes its deliveries
+		if pkg.ErrorCode(err) == pkg.NOT_FOUND_ERROR {
+			return permanentError(err)
+		}
+
+		return err
+	}
+
+	if delivery.Status == repository.WebhookDeliverySucceeded {
+		return nil
+	}
+
+	if !subscription.Active {
+		return processor.repo.WebhookRepo.RecordWebhookAttempt(ctx, &repository.WebhookAttempt{
+			DeliveryID: delivery.ID,
+			Status:     repository.WebhookDeliveryFailed,
+			Error:      "subscription is disabled",
+		})
+	}
+
+	attempt := processor.sendWebhook(ctx, delivery, subscription)
+
+	metadata := getTaskMetadata(ctx)
+	if attempt.Status != repository.WebhookDeliverySucceeded && metadata.Retried >= metadata.MaxRetry {
+		attempt.Status = repository.WebhookDeliveryFailed
+	}
+
+	if err := processor.repo.WebhookRepo.RecordWebhookAttempt(ctx, attempt); err != nil {
+		// sending it again is better than the subscriber never hearing of it
+		if attempt.Status != repository.WebhookDeliverySucceeded {
+			return err
+		}
+
+		log.Printf("failed to record webhook delivery %d: %s", delivery.ID, pkg.ErrorMessage(err))
+	}
+
+	if attempt.Status != repository.WebhookDeliverySucceeded {
+		return fmt.Errorf("webhook delivery %d to %s failed: %s", delivery.ID, subscription.URL, attempt.Error)
+	}
+
+	return nil
+}
+
+// sendWebhook posts the delivery's payload to the subscription, any 2xx response
+// is a success.
+func (processor *TaskProcessor) sendWebhook(
+	ctx context.Context,
+	delivery *repository.WebhookDelivery,
+	subscription *repository.WebhookSubscription,
+) *repository.WebhookAttempt {
+	attempt := &repository.WebhookAttempt{
+		DeliveryID: delivery.ID,
+		Status:     repository.WebhookDeliveryPending,
+	}
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		subscription.URL,
+		bytes.NewReader(delivery.Payload),
+	)
+	if err != nil {
+		attempt.Error = fmt.Sprintf("failed to create request: %s", err.Error())
+
+		return attempt
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "jonche-webhooks")
+	req.Header.Set("X-Jonche-Event", delivery.EventType)
+	req.Header.Set("X-Jonche-Delivery", strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set("X-Jonche-Timestamp", timestamp)
+	req.Header.Set("X-Jonche-Signature", signWebhook(subscription.Secret, timestamp, delivery.Payload))
+
+	start := time.Now()
+	resp, err := processor.webhookClient.Do(req)
+	attempt.Duration = time.Since(start)
+	if err != nil {
+		attempt.Error = err.Error()
+
+		return attempt
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseLimit))
+	attempt.ResponseStatus = resp.StatusCode
+	attempt.ResponseBody = string(body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		attempt.Error = fmt.Sprintf("unexpected status %d", resp.StatusCode)
+
+		return attempt
+	}
+	attempt.Status = repository.WebhookDeliverySucceeded
+
+	return attempt
+}
+
+// signWebhook is the X-Jonche-Signature header, "sha256=" and the hex HMAC-SHA256
+// of the timestamp, a dot and the body keyed by the subscription's secret.
+// Receivers recompute it and reject old timestamps to stop replays.
+func signWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -2,6 +2,7 @@ package workers
 
 import (
 	"context"
+	"runtime"
 
 	"github.com/EmilioCliff/jonche/internal/postgres"
 	"github.com/EmilioCliff/jonche/internal/services"
@@ -11,6 +12,11 @@ import (
 
 var _ services.WorkerService = (*WorkerServiceImpl)(nil)
 
+const (
+	WorkerBackendRedis     = "redis"
+	WorkerBackendInProcess = "inprocess"
+)
+
 type WorkerServiceImpl struct {
 	distributor *TaskDistributor
 	processor   *TaskProcessor
@@ -20,16 +26,51 @@ func NewWorkerService(
 	redisConfig services.RedisConfig,
 	repo *postgres.PostgresRepo,
 	config pkg.Config,
+	smsProvider services.SMSProvider,
 ) services.WorkerService {
+	if config.WORKER_BACKEND == WorkerBackendInProcess {
+		return newInProcessWorkerService(repo, config, smsProvider)
+	}
+
 	redisOpt := asynq.RedisClientOpt{
 		Addr:     redisConfig.Address,
 		DB:       redisConfig.DB,
 		Password: redisConfig.Password,
 	}
 
+	distributor := NewTaskDistributor(redisOpt)
+
+	return &WorkerServiceImpl{
+		distributor: distributor,
+		processor:   NewTaskProcessor(redisOpt, repo, config, smsProvider, distributor),
+	}
+}
+
+// newInProcessWorkerService runs tasks on goroutines instead of redis, for
+// development and tests that only have postgres.
+func newInProcessWorkerService(
+	repo *postgres.PostgresRepo,
+	config pkg.Config,
+	smsProvider services.SMSProvider,
+) services.WorkerService {
+	queue := newLocalQueue(
+		[]string{
+			services.QueueCritical,
+			services.QueueDefault,
+			services.QueueWebhooks,
+			services.QueueSMS,
+			services.QueueLow,
+		},
+		runtime.NumCPU(),
+		newRetryPolicies(config).retryDelay,
+		isFailure,
+		asynq.ErrorHandlerFunc(ReportError),
+	)
+	distributor := &TaskDistributor{client: queue}
+
 	return &WorkerServiceImpl{
-		distributor: NewTaskDistributor(redisOpt),
-		processor:   NewTaskProcessor(redisOpt, repo, config),
+		distributor: distributor,
+		processor:   NewInProcessTaskProcessor(queue, repo, config, smsProvider, distributor),
 	}
 }
 
@@ -41,6 +82,45 @@ func (w *WorkerServiceImpl) StopProcessor() {
 	w.processor.Stop()
 }
 
+func (w *WorkerServiceImpl) ReleaseHeldQueues() {
+	w.processor.releaseQueues()
+}
+
+func (w *WorkerServiceImpl) SubscribeEvents() (<-chan *services.LiveEvent, func()) {
+	return w.processor.events.subscribe()
+}
+
+func (w *WorkerServiceImpl) ListJobs() []*services.Job {
+	return w.processor.listJobs()
+}
+
+func (w *WorkerServiceImpl) TriggerJob(ctx context.Context, name string) (string, error) {
+	return w.processor.triggerJob(ctx, name)
+}
+
+func (w *WorkerServiceImpl) ListQueues() ([]*services.QueueInfo, error) {
+	return w.processor.listQueues()
+}
+
+func (w *WorkerServiceImpl) ListDeadTasks(
+	queue string,
+	pgData *pkg.PaginationMetadata,
+) ([]*services.DeadTask, pkg.PaginationMetadata, error) {
+	return w.processor.listDeadTasks(queue, pgData)
+}
+
+func (w *WorkerServiceImpl) RetryDeadTask(queue, id string) error {
+	return w.processor.retryDeadTask(queue, id)
+}
+
+func (w *WorkerServiceImpl) DeleteDeadTask(queue, id string) error {
+	return w.processor.deleteDeadTask(queue, id)
+}
+
+func (w *WorkerServiceImpl) ReplayDeadTasks(queue string, ids []string) (int, error) {
+	return w.processor.replayDeadTasks(queue, ids)
+}
+
 func (w *WorkerServiceImpl) DistributeTaskSendSMS(
 	ctx context.Context,
 	payload services.SendSMSPayload,
@@ -48,3 +128,11 @@ func (w *WorkerServiceImpl) DistributeTaskSendSMS(
 ) error {
 	return w.distributor.DistributeTaskSendSMS(ctx, payload, opt...)
 }
+
+func (w *WorkerServiceImpl) DistributeTaskDispatchCampaign(
+	ctx context.Context,
+	payload services.DispatchCampaignPayload,
+	opt ...asynq.Option,
+) error {
+	return w.distributor.DistributeTaskDispatchCampaign(ctx, payload, opt...)
+}
@@ -22,6 +22,36 @@ type Config struct {
 	REDIS_PASSWORD          string        `mapstructure:"REDIS_PASSWORD"`
 	TIARA_API_KEY           string        `mapstructure:"TIARA_API_KEY"`
 	TIARA_ENDPOINT          string        `mapstructure:"TIARA_ENDPOINT"`
+	AT_ENDPOINT             string        `mapstructure:"AT_ENDPOINT"`
+	AT_USERNAME             string        `mapstructure:"AT_USERNAME"`
+	AT_API_KEY              string        `mapstructure:"AT_API_KEY"`
+	AT_SENDER_ID            string        `mapstructure:"AT_SENDER_ID"`
+	TIARA_RATE_LIMIT        float64       `mapstructure:"TIARA_RATE_LIMIT"`
+	AT_RATE_LIMIT           float64       `mapstructure:"AT_RATE_LIMIT"`
+	SMS_PROVIDER            string        `mapstructure:"SMS_PROVIDER"`
+	SMS_FAILOVER_PROVIDER   string        `mapstructure:"SMS_FAILOVER_PROVIDER"`
+	SMS_CONSOLE_FILE        string        `mapstructure:"SMS_CONSOLE_FILE"`
+	SMS_COST_PER_SEGMENT    float64       `mapstructure:"SMS_COST_PER_SEGMENT"`
+	SMS_BALANCE_THRESHOLD   float64       `mapstructure:"SMS_BALANCE_THRESHOLD"`
+	SMS_BALANCE_ALERT_TO    []string      `mapstructure:"SMS_BALANCE_ALERT_TO"`
+	SMS_HOLD_ON_NO_CREDIT   bool          `mapstructure:"SMS_HOLD_ON_NO_CREDIT"`
+	SMS_RESEND_MAX          uint32        `mapstructure:"SMS_RESEND_MAX"`
+	SMS_RESEND_DELAY        time.Duration `mapstructure:"SMS_RESEND_DELAY"`
+	OUTBOX_POLL_INTERVAL    time.Duration `mapstructure:"OUTBOX_POLL_INTERVAL"`
+	WORKER_BACKEND          string        `mapstructure:"WORKER_BACKEND"`
+	SMS_RETRY_BASE_DELAY    time.Duration `mapstructure:"SMS_RETRY_BASE_DELAY"`
+	SMS_RETRY_MAX_DELAY     time.Duration `mapstructure:"SMS_RETRY_MAX_DELAY"`
+	TASK_RETRY_BASE_DELAY   time.Duration `mapstructure:"TASK_RETRY_BASE_DELAY"`
+	TASK_RETRY_MAX_DELAY    time.Duration `mapstructure:"TASK_RETRY_MAX_DELAY"`
+	REMINDER_DAYS_BEFORE    []int         `mapstructure:"REMINDER_DAYS_BEFORE"`
+	REMINDER_OVERDUE_DAYS   []int         `mapstructure:"REMINDER_OVERDUE_DAYS"`
+	REMINDER_QUIET_START    int           `mapstructure:"REMINDER_QUIET_START"`
+	REMINDER_QUIET_END      int           `mapstructure:"REMINDER_QUIET_END"`
+	REMINDER_TIMEZONE       string        `mapstructure:"REMINDER_TIMEZONE"`
+	REMINDER_WEEKLY_CAP     int           `mapstructure:"REMINDER_WEEKLY_CAP"`
+	JOB_SEND_REMINDERS_CRON string        `mapstructure:"JOB_SEND_REMINDERS_CRON"`
+	JOB_PRUNE_OUTBOX_CRON   string        `mapstructure:"JOB_PRUNE_OUTBOX_CRON"`
+	WEBHOOK_TIMEOUT         time.Duration `mapstructure:"WEBHOOK_TIMEOUT"`
 }
 
 func LoanConfig(path, name, configType string) (Config, error) {
@@ -45,4 +75,48 @@ func LoanConfig(path, name, configType string) (Config, error) {
 	return config, viper.Unmarshal(&config)
 }
 
-func setDefaults() {}
+func setDefaults() {
+	viper.SetDefault("TIARA_ENDPOINT", "https://api2.tiaraconnect.io/api/messaging/sendsms")
+	viper.SetDefault("AT_ENDPOINT", "https://api.africastalking.com/version1/messaging")
+	// messages a second each gateway takes across all workers, 0 for no limit
+	viper.SetDefault("TIARA_RATE_LIMIT", 10)
+	viper.SetDefault("AT_RATE_LIMIT", 10)
+	viper.SetDefault("SMS_PROVIDER", "tiara")
+	viper.SetDefault("SMS_FAILOVER_PROVIDER", "")
+	viper.SetDefault("SMS_CONSOLE_FILE", "")
+	// KES charged by the gateway for every 160 (GSM-7) or 70 (UCS-2) character part
+	viper.SetDefault("SMS_COST_PER_SEGMENT", 0.8)
+	// admins are texted once when the gateway balance drops below the threshold, the
+	// default and low queues are held while there is no credit left
+	viper.SetDefault("SMS_BALANCE_THRESHOLD", 500)
+	viper.SetDefault("SMS_BALANCE_ALERT_TO", []string{})
+	viper.SetDefault("SMS_HOLD_ON_NO_CREDIT", true)
+	// failed and expired messages are sent again twice, 15 minutes after the report
+	viper.SetDefault("SMS_RESEND_MAX", 2)
+	viper.SetDefault("SMS_RESEND_DELAY", 15*time.Minute)
+	// how often tasks saved with their rows are published to the queue
+	viper.SetDefault("OUTBOX_POLL_INTERVAL", time.Second)
+	// "inprocess" runs tasks on goroutines without redis, queued tasks are lost on
+	// restart so it is only for development and tests
+	viper.SetDefault("WORKER_BACKEND", "redis")
+	// failed tasks wait twice as long before every retry, sms from 10 seconds up to
+	// 30 minutes and the other tasks from 30 seconds up to an hour
+	viper.SetDefault("SMS_RETRY_BASE_DELAY", 10*time.Second)
+	viper.SetDefault("SMS_RETRY_MAX_DELAY", 30*time.Minute)
+	viper.SetDefault("TASK_RETRY_BASE_DELAY", 30*time.Second)
+	viper.SetDefault("TASK_RETRY_MAX_DELAY", time.Hour)
+	// reminders go out 3 days before a loan is due, on the day and then 1, 7, 14 and
+	// 30 days late, never between 20:00 and 08:00 and at most twice a week per customer
+	viper.SetDefault("REMINDER_DAYS_BEFORE", []int{3})
+	viper.SetDefault("REMINDER_OVERDUE_DAYS", []int{1, 7, 14, 30})
+	viper.SetDefault("REMINDER_QUIET_START", 20)
+	viper.SetDefault("REMINDER_QUIET_END", 8)
+	viper.SetDefault("REMINDER_TIMEZONE", "Africa/Nairobi")
+	viper.SetDefault("REMINDER_WEEKLY_CAP", 2)
+	// schedules of the worker's jobs, an empty schedule only runs the job when it
+	// is triggered
+	viper.SetDefault("JOB_SEND_REMINDERS_CRON", "@hourly")
+	viper.SetDefault("JOB_PRUNE_OUTBOX_CRON", "0 3 * * *")
+	// how long a webhook subscriber has to answer before the delivery is retried
+	viper.SetDefault("WEBHOOK_TIMEOUT", 10*time.Second)
+}
@@ -0,0 +1,121 @@
+package pkg
+
+import (
+	"strings"
+	"unicode"
+)
+
+// NameSimilarity scores how alike two names are from 0 to 1. Word order is ignored
+// and words are compared by edit distance so typos and missing names still score.
+func NameSimilarity(a, b string) float64 {
+	wordsA, wordsB := nameWords(a), nameWords(b)
+	if len(wordsA) == 0 || len(wordsB) == 0 {
+		return 0
+	}
+	if len(wordsA) > len(wordsB) {
+		wordsA, wordsB = wordsB, wordsA
+	}
+
+	matched := 0.0
+	for _, wa := range wordsA {
+		best := 0.0
+		for _, wb := range wordsB {
+			if score := wordSimilarity(wa, wb); score > best {
+				best = score
+			}
+		}
+		matched += best
+	}
+
+	// average over both lengths so "JOHN" is close to, but not the same as, "JOHN DOE"
+	return (matched/float64(len(wordsA)) + matched/float64(len(wordsB))) / 2
+}
+
+// PhoneSimilarity compares two phone numbers on their last nine digits so 07..,
+// 2547.. and +2547.. forms match. M-Pesa masks part of the number with '*', masked
+// numbers that agree on every visible digit score 0.5.
+func PhoneSimilarity(a, b string) float64 {
+	na, nb := phoneDigits(a), phoneDigits(b)
+	if len(na) < 9 || len(nb) < 9 {
+		return 0
+	}
+	na, nb = na[len(na)-9:], nb[len(nb)-9:]
+
+	masked := false
+	for i := range na {
+		if na[i] == '*' || nb[i] == '*' {
+			masked = true
+
+			continue
+		}
+		if na[i] != nb[i] {
+			return 0
+		}
+	}
+	if masked {
+		return 0.5
+	}
+
+	return 1
+}
+
+// PhoneSuffix returns the last nine digits of a phone number, the part shared by
+// the 07.., 2547.. and +2547.. forms.
+func PhoneSuffix(phone string) string {
+	digits := strings.ReplaceAll(phoneDigits(phone), "*", "")
+	if len(digits) < 9 {
+		return digits
+	}
+
+	return digits[len(digits)-9:]
+}
+
+func nameWords(name string) []string {
+	return strings.FieldsFunc(strings.ToUpper(name), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+}
+
+func phoneDigits(phone string) string {
+	var b strings.Builder
+	for _, r := range phone {
+		if unicode.IsDigit(r) || r == '*' {
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+func wordSimilarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
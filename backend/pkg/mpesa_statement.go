@@ -0,0 +1,158 @@
+package pkg
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var mpesaStatementColumns = map[string][]string{
+	"receipt":     {"receipt no.", "receipt no", "receipt", "transid"},
+	"completed":   {"completion time", "completed time", "transaction time"},
+	"paid_in":     {"paid in", "paid in (ksh)", "amount"},
+	"status":      {"transaction status", "status"},
+	"other_party": {"other party info", "other party", "opposite party"},
+	"details":     {"details"},
+}
+
+// ReadCSVRows reads every record of a csv file, allowing ragged rows.
+func ReadCSVRows(r io.Reader) ([][]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, Errorf(INVALID_ERROR, "failed to read csv: %s", err.Error())
+	}
+
+	return rows, nil
+}
+
+// ParseMpesaStatement reads the completed paid in lines from an M-Pesa paybill
+// statement export. The portal puts a few summary rows above the header, so the
+// header is found by looking for the receipt column.
+func ParseMpesaStatement(rows [][]string) ([]StatementLine, error) {
+	headerRow := -1
+	columns := map[string]int{}
+	for i, row := range rows {
+		found := map[string]int{}
+		for j, cell := range row {
+			name := strings.ToLower(strings.TrimSpace(cell))
+			for key, aliases := range mpesaStatementColumns {
+				for _, alias := range aliases {
+					if name == alias {
+						if _, ok := found[key]; !ok {
+							found[key] = j
+						}
+					}
+				}
+			}
+		}
+
+		if _, ok := found["receipt"]; ok {
+			headerRow = i
+			columns = found
+			break
+		}
+	}
+	if headerRow < 0 {
+		return nil, Errorf(INVALID_ERROR, "statement has no receipt number column")
+	}
+	for _, key := range []string{"completed", "paid_in"} {
+		if _, ok := columns[key]; !ok {
+			return nil, Errorf(INVALID_ERROR, "statement is missing the %s column", key)
+		}
+	}
+
+	field := func(row []string, key string) string {
+		idx, ok := columns[key]
+		if !ok || idx >= len(row) {
+			return ""
+		}
+
+		return strings.TrimSpace(row[idx])
+	}
+
+	var lines []StatementLine
+	for i, row := range rows[headerRow+1:] {
+		lineNo := headerRow + i + 2
+
+		receipt := field(row, "receipt")
+		if receipt == "" {
+			continue
+		}
+
+		status := field(row, "status")
+		if status != "" && !strings.EqualFold(status, "completed") {
+			continue
+		}
+
+		paidIn := field(row, "paid_in")
+		if paidIn == "" {
+			continue
+		}
+		amount, err := parseStatementAmount(paidIn)
+		if err != nil {
+			return nil, Errorf(INVALID_ERROR, "invalid paid in amount on line %d: %s", lineNo, paidIn)
+		}
+		if amount <= 0 {
+			continue
+		}
+
+		completedAt, err := parseMpesaTime(field(row, "completed"))
+		if err != nil {
+			return nil, Errorf(
+				INVALID_ERROR,
+				"invalid completion time on line %d: %s",
+				lineNo,
+				field(row, "completed"),
+			)
+		}
+
+		// other party info looks like "2547XXXXXXXX - JOHN DOE"
+		payerName, payerPhone := field(row, "other_party"), ""
+		if phone, name, ok := strings.Cut(payerName, " - "); ok {
+			payerName, payerPhone = name, strings.TrimSpace(phone)
+		}
+
+		lines = append(lines, StatementLine{
+			Line:        lineNo,
+			Reference:   receipt,
+			PayerName:   strings.TrimSpace(payerName),
+			PayerPhone:  payerPhone,
+			Amount:      amount,
+			Date:        completedAt,
+			Description: field(row, "details"),
+		})
+	}
+
+	return lines, nil
+}
+
+// parseMpesaTime handles both the text dates of csv exports and the date serials
+// xlsx exports store. Statement times are local wall clock times.
+func parseMpesaTime(s string) (time.Time, error) {
+	if serial, err := strconv.ParseFloat(s, 64); err == nil {
+		t := XLSXSerialToTime(serial)
+
+		return time.Date(
+			t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.Local,
+		), nil
+	}
+
+	for _, layout := range []string{
+		"2006-01-02 15:04:05",
+		"02-01-2006 15:04:05",
+		"02/01/2006 15:04:05",
+		"02/01/2006 15:04",
+	} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	return parseStatementDate(s, "")
+}
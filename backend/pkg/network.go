@@ -0,0 +1,49 @@
+package pkg
+
+import (
+	"context"
+	"net"
+	"strings"
+)
+
+// IsPublicIP reports whether ip is reachable on the internet, loopback, private,
+// link-local and unspecified addresses are not.
+func IsPublicIP(ip net.IP) bool {
+	return ip != nil &&
+		!ip.IsLoopback() &&
+		!ip.IsPrivate() &&
+		!ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() &&
+		!ip.IsUnspecified()
+}
+
+// CheckPublicHost returns an error unless every address host resolves to is
+// public, so urls given by users cannot reach the server's own network.
+func CheckPublicHost(ctx context.Context, host string) error {
+	name := strings.ToLower(host)
+	if name == "" || name == "localhost" || strings.HasSuffix(name, ".localhost") {
+		return Errorf(INVALID_ERROR, "host %q is not public", host)
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		if !IsPublicIP(ip) {
+			return Errorf(INVALID_ERROR, "host %q is not public", host)
+		}
+
+		return nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return Errorf(INVALID_ERROR, "failed to resolve host %q: %s", host, err.Error())
+	}
+
+	for _, addr := range addrs {
+		if !IsPublicIP(addr.IP) {
+			return Errorf(INVALID_ERROR, "host %q resolves to %s which is not public", host, addr.IP)
+		}
+	}
+
+	return nil
+}
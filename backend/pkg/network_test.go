@@ -0,0 +1,44 @@
+package pkg
+
+import (
+	"context"
+	"net"
+	"testing"
+)
+
+func TestIsPublicIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"8.8.8.8", true},
+		{"2606:4700:4700::1111", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.0.0.5", false},
+		{"172.16.3.4", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"0.0.0.0", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsPublicIP(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("IsPublicIP(%s) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestCheckPublicHost(t *testing.T) {
+	for _, host := range []string{"", "localhost", "api.localhost", "127.0.0.1", "169.254.169.254", "::1"} {
+		if err := CheckPublicHost(context.Background(), host); err == nil {
+			t.Errorf("CheckPublicHost(%q) = nil, want an error", host)
+		}
+	}
+
+	if err := CheckPublicHost(context.Background(), "8.8.8.8"); err != nil {
+		t.Errorf("CheckPublicHost(8.8.8.8) = %v, want nil", err)
+	}
+}
@@ -3,14 +3,21 @@ package pkg
 import (
 	"bytes"
 	"html/template"
+	"reflect"
+	"slices"
+	"text/template/parse"
 )
 
 type CustomerTemplateParams struct {
 	Name        string
 	PhoneNumber string
 	Loaned      float64
+	Credit      float64
 	Paid        float64
 	PaidDate    string
+	DueDate     string
+	DueAmount   float64
+	DaysOverdue int
 }
 
 // "Hello {{.Name}}, of phoneNumber {{.PhoneNumber}} we have received your payment{{.Paid}}. Your
@@ -33,3 +40,98 @@ func GenerateMessages(templateText string, customers []CustomerTemplateParams) (
 
 	return messages, nil
 }
+
+// TemplatePlaceholders lists the fields an sms template can use, e.g. {{.Name}}.
+func TemplatePlaceholders() []string {
+	t := reflect.TypeOf(CustomerTemplateParams{})
+
+	placeholders := make([]string, t.NumField())
+	for i := range placeholders {
+		placeholders[i] = t.Field(i).Name
+	}
+
+	return placeholders
+}
+
+// ValidateSMSTemplate parses the template, checks every placeholder it uses,
+// including those in branches that would not run, and runs it against empty
+// params so unknown placeholders are caught before the template is saved.
+func ValidateSMSTemplate(templateText string) error {
+	tmpl, err := template.New("sms").Parse(templateText)
+	if err != nil {
+		return Errorf(INVALID_ERROR, "invalid template: %s", err.Error())
+	}
+
+	if tmpl.Tree != nil {
+		if err := validateTemplateNode(tmpl.Tree.Root, TemplatePlaceholders()); err != nil {
+			return err
+		}
+	}
+
+	var msgBuffer bytes.Buffer
+	if err := tmpl.Execute(&msgBuffer, CustomerTemplateParams{}); err != nil {
+		return Errorf(INVALID_ERROR, "invalid template placeholder: %s", err.Error())
+	}
+
+	return nil
+}
+
+func validateTemplateNode(node parse.Node, placeholders []string) error {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+		for _, child := range n.Nodes {
+			if err := validateTemplateNode(child, placeholders); err != nil {
+				return err
+			}
+		}
+	case *parse.ActionNode:
+		return validateTemplateNode(n.Pipe, placeholders)
+	case *parse.IfNode:
+		return validateTemplateBranch(&n.BranchNode, placeholders)
+	case *parse.RangeNode:
+		return validateTemplateBranch(&n.BranchNode, placeholders)
+	case *parse.WithNode:
+		return validateTemplateBranch(&n.BranchNode, placeholders)
+	case *parse.TemplateNode:
+		return validateTemplateNode(n.Pipe, placeholders)
+	case *parse.PipeNode:
+		if n == nil {
+			return nil
+		}
+		for _, cmd := range n.Cmds {
+			if err := validateTemplateNode(cmd, placeholders); err != nil {
+				return err
+			}
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			if err := validateTemplateNode(arg, placeholders); err != nil {
+				return err
+			}
+		}
+	case *parse.ChainNode:
+		return validateTemplateNode(n.Node, placeholders)
+	case *parse.FieldNode:
+		// the params only have flat fields, so .Name.Foo is as wrong as .Foo
+		if len(n.Ident) != 1 || !slices.Contains(placeholders, n.Ident[0]) {
+			return Errorf(INVALID_ERROR, "invalid template placeholder: %s", n.String())
+		}
+	}
+
+	return nil
+}
+
+func validateTemplateBranch(branch *parse.BranchNode, placeholders []string) error {
+	if err := validateTemplateNode(branch.Pipe, placeholders); err != nil {
+		return err
+	}
+
+	if err := validateTemplateNode(branch.List, placeholders); err != nil {
+		return err
+	}
+
+	return validateTemplateNode(branch.ElseList, placeholders)
+}
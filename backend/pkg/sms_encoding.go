@@ -0,0 +1,103 @@
+package pkg
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+const (
+	SMSEncodingGSM7 = "GSM-7"
+	SMSEncodingUCS2 = "UCS-2"
+)
+
+// gsm7Basic is the GSM 03.38 default alphabet, each character takes one septet.
+const gsm7Basic = "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?" +
+	"¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+
+// gsm7Extension characters are sent behind an escape so they take two septets.
+const gsm7Extension = "\f^{}\\[~]|€"
+
+// SMSEstimate is what a message costs to send to one recipient. Characters is
+// counted in the units of the encoding: septets for GSM-7 and UTF-16 code units
+// for UCS-2.
+type SMSEstimate struct {
+	Encoding   string  `json:"encoding"`
+	Characters int     `json:"characters"`
+	Segments   int     `json:"segments"`
+	Cost       float64 `json:"cost"`
+}
+
+// EstimateSMS works out the encoding and number of segments the gateway will
+// split the message into. A single character outside the GSM-7 alphabet, like an
+// emoji, sends the whole message as UCS-2 which fits 70 characters instead of 160.
+func EstimateSMS(message string, costPerSegment float64) SMSEstimate {
+	estimate := SMSEstimate{Encoding: SMSEncodingGSM7}
+
+	// width of every character in the encoding's units, a concatenated segment
+	// never splits an escape sequence or a surrogate pair
+	var widths []int
+	for _, r := range message {
+		switch {
+		case strings.ContainsRune(gsm7Basic, r):
+			widths = append(widths, 1)
+		case strings.ContainsRune(gsm7Extension, r):
+			widths = append(widths, 2)
+		default:
+			estimate.Encoding = SMSEncodingUCS2
+		}
+	}
+
+	single, multi := 160, 153
+	if estimate.Encoding == SMSEncodingUCS2 {
+		single, multi = 70, 67
+
+		widths = widths[:0]
+		for _, r := range message {
+			widths = append(widths, utf16.RuneLen(r))
+		}
+	}
+
+	for _, w := range widths {
+		estimate.Characters += w
+	}
+
+	switch {
+	case estimate.Characters == 0:
+		estimate.Segments = 0
+	case estimate.Characters <= single:
+		estimate.Segments = 1
+	default:
+		used := 0
+		estimate.Segments = 1
+		for _, w := range widths {
+			if used+w > multi {
+				estimate.Segments++
+				used = 0
+			}
+			used += w
+		}
+	}
+
+	estimate.Cost = math.Round(float64(estimate.Segments)*costPerSegment*10000) / 10000
+
+	return estimate
+}
+
+var smsAmountPattern = regexp.MustCompile(`[0-9]+(\.[0-9]+)?`)
+
+// ParseSMSAmount reads a cost or balance reported by a gateway, which may carry a
+// currency like "KES 0.8000". Anything without a number is 0.
+func ParseSMSAmount(amount string) float64 {
+	// "1,234.50" is a thousand, not one
+	amount = strings.ReplaceAll(amount, ",", "")
+
+	value, err := strconv.ParseFloat(smsAmountPattern.FindString(amount), 64)
+	if err != nil {
+		return 0
+	}
+
+	return value
+}
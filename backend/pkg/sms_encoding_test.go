@@ -0,0 +1,23 @@
+package pkg
+
+import "testing"
+
+func TestParseSMSAmount(t *testing.T) {
+	tests := []struct {
+		amount string
+		want   float64
+	}{
+		{"KES 0.8000", 0.8},
+		{"1,234.50", 1234.5},
+		{"KES 12,345", 12345},
+		{"12", 12},
+		{"", 0},
+		{"none", 0},
+	}
+
+	for _, tt := range tests {
+		if got := ParseSMSAmount(tt.amount); got != tt.want {
+			t.Errorf("ParseSMSAmount(%q) = %v, want %v", tt.amount, got, tt.want)
+		}
+	}
+}
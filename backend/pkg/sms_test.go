@@ -0,0 +1,30 @@
+package pkg
+
+import "testing"
+
+func TestValidateSMSTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		wantErr  bool
+	}{
+		{"known placeholders", "Hi {{.Name}}, you owe KES {{.DueAmount}}", false},
+		{"unknown placeholder", "Hi {{.Nme}}", true},
+		{"known placeholder in if", "Hi {{.Name}}{{if .Credit}} {{.Credit}}{{end}}", false},
+		{"unknown placeholder in if", "Hi {{.Name}}{{if .Credit}} {{.Credt}}{{end}}", true},
+		{"unknown placeholder in else", "{{if .Credit}}{{.Credit}}{{else}}{{.Balance}}{{end}}", true},
+		{"unknown placeholder in with", "{{with .DueDate}}{{.Due}}{{end}}", true},
+		{"unknown placeholder in range", "{{range .Loans}}{{.Amount}}{{end}}", true},
+		{"nested field", "{{.Name.First}}", true},
+		{"parse error", "Hi {{.Name", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSMSTemplate(tt.template)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateSMSTemplate(%q) error = %v, wantErr %v", tt.template, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,293 @@
+package pkg
+
+import (
+	"bufio"
+	"encoding/csv"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	StatementFormatCSV   = "csv"
+	StatementFormatMT940 = "mt940"
+)
+
+type StatementLine struct {
+	Line        int       `json:"line"`
+	Reference   string    `json:"reference"`
+	PayerName   string    `json:"payer_name"`
+	PayerPhone  string    `json:"payer_phone,omitempty"`
+	Amount      float64   `json:"amount"`
+	Date        time.Time `json:"date"`
+	Description string    `json:"description"`
+}
+
+// CSVColumnMapping names the header of each column we read from a csv statement.
+// An empty DateLayout tries the layouts in statementDateLayouts.
+type CSVColumnMapping struct {
+	Reference   string `json:"reference"`
+	PayerName   string `json:"payer_name"`
+	Amount      string `json:"amount"`
+	Date        string `json:"date"`
+	Description string `json:"description"`
+	DateLayout  string `json:"date_layout"`
+}
+
+var DefaultCSVColumnMapping = CSVColumnMapping{
+	Reference:   "Reference",
+	PayerName:   "Name",
+	Amount:      "Amount",
+	Date:        "Date",
+	Description: "Description",
+}
+
+var statementDateLayouts = []string{
+	"2006-01-02",
+	"2006-01-02 15:04:05",
+	"02/01/2006",
+	"02/01/2006 15:04:05",
+	"02-01-2006",
+	"02 Jan 2006",
+	"02-Jan-2006",
+}
+
+// ParseCSVStatement reads credit lines from a csv statement. Rows without a positive
+// amount (debits, balances) are left out.
+func ParseCSVStatement(r io.Reader, mapping CSVColumnMapping) ([]StatementLine, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, Errorf(INVALID_ERROR, "failed to read statement header: %s", err.Error())
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	column := func(name string, required bool) (int, error) {
+		if name == "" && !required {
+			return -1, nil
+		}
+
+		idx, ok := columns[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			if required {
+				return 0, Errorf(INVALID_ERROR, "statement is missing column %q", name)
+			}
+
+			return -1, nil
+		}
+
+		return idx, nil
+	}
+
+	refIdx, err := column(mapping.Reference, true)
+	if err != nil {
+		return nil, err
+	}
+	amountIdx, err := column(mapping.Amount, true)
+	if err != nil {
+		return nil, err
+	}
+	dateIdx, err := column(mapping.Date, true)
+	if err != nil {
+		return nil, err
+	}
+	nameIdx, _ := column(mapping.PayerName, false)
+	descIdx, _ := column(mapping.Description, false)
+
+	field := func(record []string, idx int) string {
+		if idx < 0 || idx >= len(record) {
+			return ""
+		}
+
+		return strings.TrimSpace(record[idx])
+	}
+
+	var lines []StatementLine
+	lineNo := 1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		lineNo++
+		if err != nil {
+			return nil, Errorf(INVALID_ERROR, "failed to read statement line %d: %s", lineNo, err.Error())
+		}
+
+		amountStr := field(record, amountIdx)
+		if amountStr == "" {
+			continue
+		}
+
+		amount, err := parseStatementAmount(amountStr)
+		if err != nil {
+			return nil, Errorf(INVALID_ERROR, "invalid amount on line %d: %s", lineNo, amountStr)
+		}
+		if amount <= 0 {
+			continue
+		}
+
+		date, err := parseStatementDate(field(record, dateIdx), mapping.DateLayout)
+		if err != nil {
+			return nil, Errorf(INVALID_ERROR, "invalid date on line %d: %s", lineNo, field(record, dateIdx))
+		}
+
+		lines = append(lines, StatementLine{
+			Line:        lineNo,
+			Reference:   field(record, refIdx),
+			PayerName:   field(record, nameIdx),
+			Amount:      amount,
+			Date:        date,
+			Description: field(record, descIdx),
+		})
+	}
+
+	return lines, nil
+}
+
+var mt940TransactionRe = regexp.MustCompile(
+	`^(\d{6})(\d{4})?(RC|RD|C|D)([A-Z])?(\d+,\d*)[NF][A-Z0-9]{3}([^/]*)(?://(.*))?`,
+)
+
+// ParseMT940Statement reads credit lines from an MT940 statement. Each :61: field is
+// paired with the :86: field that follows it for the payer details.
+func ParseMT940Statement(r io.Reader) ([]StatementLine, error) {
+	type field struct {
+		tag   string
+		value string
+		line  int
+	}
+
+	var fields []field
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		text := strings.TrimRight(scanner.Text(), "\r")
+
+		if strings.HasPrefix(text, ":") {
+			end := strings.Index(text[1:], ":")
+			if end > 0 {
+				fields = append(fields, field{
+					tag:   text[1 : end+1],
+					value: text[end+2:],
+					line:  lineNo,
+				})
+
+				continue
+			}
+		}
+
+		if len(fields) > 0 && text != "-" && !strings.HasPrefix(text, "{") {
+			fields[len(fields)-1].value += "\n" + text
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, Errorf(INVALID_ERROR, "failed to read statement: %s", err.Error())
+	}
+
+	var lines []StatementLine
+	for i, f := range fields {
+		if f.tag != "61" {
+			continue
+		}
+
+		first, _, _ := strings.Cut(f.value, "\n")
+		m := mt940TransactionRe.FindStringSubmatch(first)
+		if m == nil {
+			return nil, Errorf(INVALID_ERROR, "invalid :61: field on line %d", f.line)
+		}
+
+		// only credits (and reversed debits) are money coming in
+		if m[3] != "C" && m[3] != "RD" {
+			continue
+		}
+
+		date, err := time.Parse("060102", m[1])
+		if err != nil {
+			return nil, Errorf(INVALID_ERROR, "invalid date on line %d: %s", f.line, m[1])
+		}
+
+		amount, err := parseStatementAmount(strings.Replace(m[5], ",", ".", 1))
+		if err != nil {
+			return nil, Errorf(INVALID_ERROR, "invalid amount on line %d: %s", f.line, m[5])
+		}
+
+		reference := strings.TrimSpace(m[6])
+		if reference == "" || strings.EqualFold(reference, "NONREF") {
+			reference = strings.TrimSpace(m[7])
+		}
+
+		line := StatementLine{
+			Line:      f.line,
+			Reference: reference,
+			Amount:    amount,
+			Date:      date,
+		}
+
+		if i+1 < len(fields) && fields[i+1].tag == "86" {
+			line.Description, line.PayerName = mt940Information(fields[i+1].value)
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines, nil
+}
+
+// mt940Information returns the :86: text and the payer name in it. Banks that use
+// structured ?32/?33 sub-fields get the name from those, otherwise the first line
+// of the free text is taken as the name.
+func mt940Information(value string) (string, string) {
+	if !strings.Contains(value, "?32") {
+		name, _, _ := strings.Cut(value, "\n")
+
+		return strings.ReplaceAll(value, "\n", " "), strings.TrimSpace(name)
+	}
+
+	info := strings.ReplaceAll(value, "\n", "")
+
+	var name strings.Builder
+	for _, part := range strings.Split(info, "?")[1:] {
+		if len(part) < 2 {
+			continue
+		}
+		if part[:2] == "32" || part[:2] == "33" {
+			name.WriteString(part[2:])
+		}
+	}
+
+	return info, strings.TrimSpace(name.String())
+}
+
+func parseStatementAmount(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "KES"), "KSH")
+	s = strings.ReplaceAll(s, ",", "")
+
+	return StringToFloat64(s)
+}
+
+func parseStatementDate(s, layout string) (time.Time, error) {
+	if layout != "" {
+		return time.Parse(layout, s)
+	}
+
+	var err error
+	for _, l := range statementDateLayouts {
+		var t time.Time
+		if t, err = time.Parse(l, s); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, err
+}
@@ -0,0 +1,165 @@
+package pkg
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type xlsxSharedStrings struct {
+	Items []struct {
+		Text string `xml:"t"`
+		Runs []struct {
+			Text string `xml:"t"`
+		} `xml:"r"`
+	} `xml:"si"`
+}
+
+type xlsxWorksheet struct {
+	Rows []struct {
+		Ref   int `xml:"r,attr"`
+		Cells []struct {
+			Ref    string `xml:"r,attr"`
+			Type   string `xml:"t,attr"`
+			Value  string `xml:"v"`
+			Inline struct {
+				Text string `xml:"t"`
+			} `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// ReadXLSXRows returns the cell values of the first worksheet in an xlsx file. It only
+// understands what spreadsheet exports need: shared, inline and plain values.
+func ReadXLSXRows(r io.ReaderAt, size int64) ([][]string, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, Errorf(INVALID_ERROR, "failed to open xlsx: %s", err.Error())
+	}
+
+	var sheets []*zip.File
+	var sharedFile *zip.File
+	for _, f := range zr.File {
+		switch {
+		case f.Name == "xl/sharedStrings.xml":
+			sharedFile = f
+		case strings.HasPrefix(f.Name, "xl/worksheets/sheet") && strings.HasSuffix(f.Name, ".xml"):
+			sheets = append(sheets, f)
+		}
+	}
+	if len(sheets) == 0 {
+		return nil, Errorf(INVALID_ERROR, "xlsx has no worksheets")
+	}
+	sort.Slice(sheets, func(i, j int) bool {
+		return xlsxSheetNumber(sheets[i].Name) < xlsxSheetNumber(sheets[j].Name)
+	})
+
+	var shared []string
+	if sharedFile != nil {
+		var sst xlsxSharedStrings
+		if err := decodeZipXML(sharedFile, &sst); err != nil {
+			return nil, err
+		}
+
+		shared = make([]string, len(sst.Items))
+		for i, item := range sst.Items {
+			if len(item.Runs) == 0 {
+				shared[i] = item.Text
+				continue
+			}
+
+			var b strings.Builder
+			for _, run := range item.Runs {
+				b.WriteString(run.Text)
+			}
+			shared[i] = b.String()
+		}
+	}
+
+	var sheet xlsxWorksheet
+	if err := decodeZipXML(sheets[0], &sheet); err != nil {
+		return nil, err
+	}
+
+	rows := make([][]string, 0, len(sheet.Rows))
+	for _, row := range sheet.Rows {
+		// keep row positions when the sheet skips empty rows
+		for row.Ref > 0 && len(rows) < row.Ref-1 {
+			rows = append(rows, nil)
+		}
+
+		var values []string
+		for i, cell := range row.Cells {
+			col := xlsxColumnIndex(cell.Ref)
+			if col < 0 {
+				col = i
+			}
+			for len(values) <= col {
+				values = append(values, "")
+			}
+
+			switch cell.Type {
+			case "s":
+				idx, err := strconv.Atoi(cell.Value)
+				if err == nil && idx >= 0 && idx < len(shared) {
+					values[col] = shared[idx]
+				}
+			case "inlineStr":
+				values[col] = cell.Inline.Text
+			default:
+				values[col] = cell.Value
+			}
+		}
+
+		rows = append(rows, values)
+	}
+
+	return rows, nil
+}
+
+// XLSXSerialToTime converts a spreadsheet date serial (days since 1899-12-30) to time.
+func XLSXSerialToTime(serial float64) time.Time {
+	base := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+	return base.Add(time.Duration(serial * 24 * float64(time.Hour))).Round(time.Second)
+}
+
+func decodeZipXML(f *zip.File, v any) error {
+	rc, err := f.Open()
+	if err != nil {
+		return Errorf(INVALID_ERROR, "failed to open %s: %s", f.Name, err.Error())
+	}
+	defer rc.Close()
+
+	if err := xml.NewDecoder(rc).Decode(v); err != nil {
+		return Errorf(INVALID_ERROR, "failed to read %s: %s", f.Name, err.Error())
+	}
+
+	return nil
+}
+
+func xlsxSheetNumber(name string) int {
+	n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, "xl/worksheets/sheet"), ".xml"))
+	if err != nil {
+		return int(^uint(0) >> 1)
+	}
+
+	return n
+}
+
+// xlsxColumnIndex turns a cell reference like "AB12" into a zero based column index.
+func xlsxColumnIndex(ref string) int {
+	col := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = col*26 + int(r-'A'+1)
+	}
+
+	return col - 1
+}